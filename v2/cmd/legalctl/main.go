@@ -0,0 +1,345 @@
+// Command legalctl runs the document pipeline without the web UI, for
+// scripting intake over case folders. Run it from the v2 directory so the
+// services find config/ the same way the server does.
+//
+//	legalctl [-v] extract FILE
+//	legalctl [-v] classify FILE
+//	legalctl [-v] analyze [-template ID] [-o DIR] FOLDER...
+//	legalctl [-v] generate [-template ID] [-format html|pdf|docx] [-o FILE] CASE.json
+//	legalctl [-v] validate FILE
+//
+// Results are written to stdout as JSON; service logs go to stderr with -v.
+// validate exits with status 1 when the document has high-severity issues.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"mallon-legal-v2/services"
+)
+
+const defaultTemplateID = "fcra-credit-card-fraud"
+
+// exitIssues is the exit status of validate when the document has errors;
+// usage and processing failures exit with 2
+const exitIssues = 1
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+// commands is filled in by init, since the commands print their own usage from it
+var commands []command
+
+func init() {
+	commands = []command{
+		{"extract", "extract FILE", "print the extracted text, pages and metadata of a document", runExtract},
+		{"classify", "classify FILE", "classify a document by type", runClassify},
+		{"analyze", "analyze [-template ID] [-o DIR] FOLDER...", "process the documents of case folders into ClientCase JSON", runAnalyze},
+		{"generate", "generate [-template ID] [-format html|pdf|docx] [-o FILE] CASE.json", "generate a complaint from ClientCase JSON", runGenerate},
+		{"validate", "validate FILE", "check a complaint against court filing requirements", runValidate},
+	}
+}
+
+// errIssues is returned by validate so main exits with exitIssues instead of 2
+type errIssues struct{ count int }
+
+func (e errIssues) Error() string {
+	return fmt.Sprintf("%d high-severity issues", e.count)
+}
+
+func main() {
+	verbose := flag.Bool("v", false, "write service logs to stderr")
+	flag.Usage = usage
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(flag.Args()[1:])
+		if issues, ok := err.(errIssues); ok {
+			fmt.Fprintf(os.Stderr, "legalctl %s: %v\n", name, issues)
+			os.Exit(exitIssues)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "legalctl %s: %v\n", name, err)
+			os.Exit(2)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "legalctl: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: legalctl [-v] COMMAND [flags] ARGS\n\nCommands:\n")
+	writer := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	writer.Flush()
+	fmt.Fprintf(os.Stderr, "\nRun 'legalctl COMMAND -h' for a command's flags.\n")
+}
+
+// newFlagSet returns the flag set of a command, printing its usage line on errors
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(os.Stderr, "Usage: legalctl %s\n", cmd.usage)
+			}
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// fileArg parses a command that takes exactly one file
+func fileArg(name string, args []string) (string, error) {
+	flags := newFlagSet(name)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return "", fmt.Errorf("expected one file, got %d arguments", flags.NArg())
+	}
+	return flags.Arg(0), nil
+}
+
+// writeJSON writes a value to stdout as indented JSON
+func writeJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// newDocumentService returns a document service over the local filesystem.
+// The store is rooted at /, so absolute paths are its object paths.
+func newDocumentService() (*services.DocumentService, services.CaseStore) {
+	store := services.NewLocalCaseStore("/", "")
+	return services.NewDocumentServiceWithStore(store, ""), store
+}
+
+func runExtract(args []string) error {
+	file, err := fileArg("extract", args)
+	if err != nil {
+		return err
+	}
+	content, err := services.NewDocumentExtractor().ExtractTextContext(context.Background(), file)
+	if err != nil {
+		return err
+	}
+	return writeJSON(content)
+}
+
+func runClassify(args []string) error {
+	file, err := fileArg("classify", args)
+	if err != nil {
+		return err
+	}
+	content, err := services.NewDocumentExtractor().ExtractTextContext(context.Background(), file)
+	if err != nil {
+		return err
+	}
+	analyzer, err := services.NewContentAnalyzer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize content analyzer: %v", err)
+	}
+	classifier, err := services.NewDocumentClassifier(analyzer)
+	if err != nil {
+		return fmt.Errorf("failed to initialize document classifier: %v", err)
+	}
+	classification, err := classifier.ClassifyDocument(file, content.RawText)
+	if err != nil {
+		return err
+	}
+	return writeJSON(classification)
+}
+
+func runAnalyze(args []string) error {
+	flags := newFlagSet("analyze")
+	templateID := flags.String("template", defaultTemplateID, "complaint template ID")
+	outputDir := flags.String("o", "", "write each case to DIR/<folder name>.json instead of stdout")
+	flags.Parse(args)
+	folders := flags.Args()
+	if len(folders) == 0 {
+		flags.Usage()
+		return fmt.Errorf("no case folders given")
+	}
+	if len(folders) > 1 && *outputDir == "" {
+		return fmt.Errorf("-o is required to analyze more than one folder")
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	docService, store := newDocumentService()
+	failed := 0
+	for _, folder := range folders {
+		clientCase, err := analyzeFolder(docService, store, folder, *templateID)
+		if err == nil {
+			if *outputDir == "" {
+				err = writeJSON(clientCase)
+			} else {
+				err = writeCaseFile(filepath.Join(*outputDir, filepath.Base(filepath.Clean(folder))+".json"), clientCase)
+			}
+		}
+		if err != nil {
+			// Keep going so one bad folder doesn't stop a batch
+			fmt.Fprintf(os.Stderr, "legalctl analyze: %s: %v\n", folder, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d case folders failed", failed, len(folders))
+	}
+	return nil
+}
+
+// analyzeFolder runs the processing pipeline over the readable documents of a folder
+func analyzeFolder(docService *services.DocumentService, store services.CaseStore, folder, templateID string) (*services.ClientCase, error) {
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := docService.ReadableDocuments(store, folder)
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no readable documents")
+	}
+	_, clientCase, err := docService.ProcessSelectedDocumentsContext(context.Background(), store, documents, templateID, services.DefaultProcessingOptions())
+	return clientCase, err
+}
+
+func writeCaseFile(file string, clientCase *services.ClientCase) error {
+	data, err := json.MarshalIndent(clientCase, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+func runGenerate(args []string) error {
+	flags := newFlagSet("generate")
+	templateID := flags.String("template", defaultTemplateID, "complaint template ID")
+	format := flags.String("format", "html", "output format: html, pdf or docx")
+	output := flags.String("o", "", "write the complaint to FILE instead of stdout")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one ClientCase JSON file, got %d arguments", flags.NArg())
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var clientCase services.ClientCase
+	if err := json.Unmarshal(data, &clientCase); err != nil {
+		return fmt.Errorf("invalid ClientCase JSON in %s: %v", flags.Arg(0), err)
+	}
+
+	docService, _ := newDocumentService()
+	document, err := docService.GenerateComplaint(*templateID, &clientCase)
+	if err != nil {
+		return err
+	}
+
+	formatter := services.NewLegalDocumentFormatter()
+	switch strings.ToLower(*format) {
+	case "html":
+		data = []byte(formatter.FormatAsHTML(document.Content))
+	case "pdf":
+		data, err = formatter.FormatDocumentAsPDF(document)
+	case "docx":
+		data, err = formatter.FormatDocumentAsDOCX(document)
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format complaint as %s: %v", *format, err)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// ValidationReport is the output of validate
+type ValidationReport struct {
+	File   string                     `json:"file"`
+	Score  float64                    `json:"score"`
+	Issues []services.ValidationIssue `json:"issues"`
+}
+
+func runValidate(args []string) error {
+	file, err := fileArg("validate", args)
+	if err != nil {
+		return err
+	}
+
+	// Generated complaints are HTML; anything else goes through the extractor
+	var content string
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".html", ".htm", ".txt":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	default:
+		extracted, err := services.NewDocumentExtractor().ExtractTextContext(context.Background(), file)
+		if err != nil {
+			return err
+		}
+		content = extracted.RawText
+	}
+
+	validator := services.NewDocumentValidator()
+	issues := validator.ValidateForCourtFiling(content)
+	if issues == nil {
+		issues = []services.ValidationIssue{}
+	}
+	if err := writeJSON(ValidationReport{File: file, Score: validator.GetValidationScore(issues), Issues: issues}); err != nil {
+		return err
+	}
+
+	high := 0
+	for _, issue := range issues {
+		if issue.Severity == "high" {
+			high++
+		}
+	}
+	if high > 0 {
+		return errIssues{count: high}
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandlers serves the user administration API and page. Every route is
+// mounted behind the users:manage permission.
+type AdminHandlers struct {
+	userService    *services.UserService
+	sessionService *services.PersistentSessionService
+	templates      *template.Template
+}
+
+// adminUserView is a user as shown to administrators, without the password hash
+type adminUserView struct {
+	ID            string     `json:"id"`
+	Username      string     `json:"username"`
+	DisplayName   string     `json:"displayName"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	Active        bool       `json:"active"`
+	Created       time.Time  `json:"created"`
+	LastLogin     *time.Time `json:"lastLogin"`
+	AssignedCases []string   `json:"assignedCases"`
+}
+
+// adminPageData is the data for the admin page and its user table fragment
+type adminPageData struct {
+	Username    string
+	CurrentUser string
+	Users       []adminUserView
+	Roles       []string
+	Error       string
+	Message     string
+}
+
+// createUserRequest is the body of POST /api/admin/users
+type createUserRequest struct {
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	DisplayName   string   `json:"displayName"`
+	Email         string   `json:"email"`
+	Role          string   `json:"role"`
+	AssignedCases []string `json:"assignedCases"`
+}
+
+// updateUserRequest is the body of PATCH /api/admin/users/:username; omitted
+// fields are left unchanged
+type updateUserRequest struct {
+	DisplayName   *string   `json:"displayName"`
+	Email         *string   `json:"email"`
+	Role          *string   `json:"role"`
+	Active        *bool     `json:"active"`
+	AssignedCases *[]string `json:"assignedCases"`
+}
+
+// NewAdminHandlers creates the admin handlers and parses the admin templates
+func NewAdminHandlers(userService *services.UserService, sessionService *services.PersistentSessionService) *AdminHandlers {
+	tmpl := template.New("").Funcs(template.FuncMap{
+		"stringEq": func(a, b string) bool { return a == b },
+	})
+	tmpl = template.Must(tmpl.ParseFiles("templates/admin_users.gohtml", "templates/_admin_user_table.gohtml"))
+
+	return &AdminHandlers{
+		userService:    userService,
+		sessionService: sessionService,
+		templates:      tmpl,
+	}
+}
+
+// ListUsers returns every user and the assignable roles
+func (h *AdminHandlers) ListUsers(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users": h.userViews(),
+		"roles": h.userService.GetUserRoles(),
+	})
+}
+
+// GetUser returns one user
+func (h *AdminHandlers) GetUser(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	user, exists := h.userService.GetUser(c.Param("username"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusOK, newAdminUserView(user))
+}
+
+// CreateUser adds a user from a JSON body
+func (h *AdminHandlers) CreateUser(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var request createUserRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, err := h.createUser(c, request)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, newAdminUserView(user))
+}
+
+// UpdateUser changes a user's role, active flag, contact details or assigned cases
+func (h *AdminHandlers) UpdateUser(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var request updateUserRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, err := h.updateUser(c, c.Param("username"), request)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newAdminUserView(user))
+}
+
+// ResetPassword sets a new password for a user
+func (h *AdminHandlers) ResetPassword(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var request struct {
+		Password string `json:"password"`
+	}
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.resetPassword(c, c.Param("username"), request.Password); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DeactivateUser marks a user inactive. Users are never removed so their
+// history stays attributable.
+func (h *AdminHandlers) DeactivateUser(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	inactive := false
+	user, err := h.updateUser(c, c.Param("username"), updateUserRequest{Active: &inactive})
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newAdminUserView(user))
+}
+
+// ShowUsersPage renders the user administration page
+func (h *AdminHandlers) ShowUsersPage(c *gin.Context) {
+	data := h.pageData(c)
+	if h.userService == nil {
+		data.Error = "User accounts are unavailable: config/users.json could not be loaded"
+	}
+	if err := h.templates.ExecuteTemplate(c.Writer, "admin_users.gohtml", data); err != nil {
+		log.Printf("Error executing template admin_users.gohtml: %v", err)
+		c.String(http.StatusInternalServerError, "Error rendering page")
+	}
+}
+
+// CreateUserForm handles the add-user form on the admin page
+func (h *AdminHandlers) CreateUserForm(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	request := createUserRequest{
+		Username:      strings.TrimSpace(c.PostForm("username")),
+		Password:      c.PostForm("password"),
+		DisplayName:   strings.TrimSpace(c.PostForm("displayName")),
+		Email:         strings.TrimSpace(c.PostForm("email")),
+		Role:          c.PostForm("role"),
+		AssignedCases: splitAssignedCases(c.PostForm("assignedCases")),
+	}
+
+	user, err := h.createUser(c, request)
+	if err != nil {
+		h.renderUserTable(c, "", err)
+		return
+	}
+	h.renderUserTable(c, "Created user "+user.Username, nil)
+}
+
+// UpdateUserForm handles the role and assigned case form in a table row
+func (h *AdminHandlers) UpdateUserForm(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	role := c.PostForm("role")
+	assignedCases := splitAssignedCases(c.PostForm("assignedCases"))
+	username := c.Param("username")
+
+	if _, err := h.updateUser(c, username, updateUserRequest{Role: &role, AssignedCases: &assignedCases}); err != nil {
+		h.renderUserTable(c, "", err)
+		return
+	}
+	h.renderUserTable(c, "Updated "+username, nil)
+}
+
+// SetActiveForm handles the deactivate and reactivate buttons
+func (h *AdminHandlers) SetActiveForm(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	active := c.PostForm("active") == "true"
+	username := c.Param("username")
+
+	if _, err := h.updateUser(c, username, updateUserRequest{Active: &active}); err != nil {
+		h.renderUserTable(c, "", err)
+		return
+	}
+	if active {
+		h.renderUserTable(c, "Reactivated "+username, nil)
+	} else {
+		h.renderUserTable(c, "Deactivated "+username, nil)
+	}
+}
+
+// ResetPasswordForm handles the password reset form in a table row
+func (h *AdminHandlers) ResetPasswordForm(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	username := c.Param("username")
+	if err := h.resetPassword(c, username, c.PostForm("password")); err != nil {
+		h.renderUserTable(c, "", err)
+		return
+	}
+	h.renderUserTable(c, "Password reset for "+username, nil)
+}
+
+// createUser adds an active user
+func (h *AdminHandlers) createUser(c *gin.Context, request createUserRequest) (*services.User, error) {
+	user := &services.User{
+		Username:      request.Username,
+		Password:      request.Password,
+		DisplayName:   request.DisplayName,
+		Email:         request.Email,
+		Role:          services.NormalizeRole(request.Role),
+		Active:        true,
+		AssignedCases: request.AssignedCases,
+	}
+	if err := h.userService.AddUser(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ADMIN] %s created user %s with role %s", c.GetString("username"), user.Username, user.Role)
+	return user, nil
+}
+
+// updateUser applies a partial update. Administrators cannot deactivate their
+// own account or remove their own admin role, so the firm is never left
+// without someone able to manage users.
+func (h *AdminHandlers) updateUser(c *gin.Context, username string, request updateUserRequest) (*services.User, error) {
+	actor := c.GetString("username")
+	if username == actor {
+		if request.Active != nil && !*request.Active {
+			return nil, errAdminSelfChange
+		}
+		if request.Role != nil && !services.RoleHasPermission(*request.Role, services.PermissionManageUsers) {
+			return nil, errAdminSelfChange
+		}
+	}
+
+	deactivated := false
+	err := h.userService.ModifyUser(username, func(user *services.User) error {
+		if request.DisplayName != nil {
+			user.DisplayName = strings.TrimSpace(*request.DisplayName)
+		}
+		if request.Email != nil {
+			user.Email = strings.TrimSpace(*request.Email)
+		}
+		if request.Role != nil {
+			user.Role = services.NormalizeRole(*request.Role)
+		}
+		if request.AssignedCases != nil {
+			user.AssignedCases = *request.AssignedCases
+		}
+		if request.Active != nil {
+			deactivated = user.Active && !*request.Active
+			user.Active = *request.Active
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deactivated {
+		h.sessionService.DeleteUserSessions(username)
+	}
+	log.Printf("[ADMIN] %s updated user %s", actor, username)
+
+	user, _ := h.userService.GetUser(username)
+	return user, nil
+}
+
+// resetPassword sets a user's password and signs out their other sessions
+func (h *AdminHandlers) resetPassword(c *gin.Context, username, password string) error {
+	if err := h.userService.SetPassword(username, password); err != nil {
+		return err
+	}
+
+	actor := c.GetString("username")
+	if username != actor {
+		h.sessionService.DeleteUserSessions(username)
+	}
+	log.Printf("[ADMIN] %s reset the password for %s", actor, username)
+	return nil
+}
+
+// renderUserTable answers an admin page form with the refreshed user table
+func (h *AdminHandlers) renderUserTable(c *gin.Context, message string, err error) {
+	data := h.pageData(c)
+	data.Message = message
+	if err != nil {
+		data.Error = err.Error()
+	}
+	if err := h.templates.ExecuteTemplate(c.Writer, "_admin_user_table.gohtml", data); err != nil {
+		log.Printf("Error executing template _admin_user_table.gohtml: %v", err)
+		c.String(http.StatusInternalServerError, "Error rendering user table")
+	}
+}
+
+func (h *AdminHandlers) pageData(c *gin.Context) adminPageData {
+	data := adminPageData{
+		Username:    c.GetString("displayName"),
+		CurrentUser: c.GetString("username"),
+	}
+	if h.userService != nil {
+		data.Users = h.userViews()
+		data.Roles = h.userService.GetUserRoles()
+	}
+	return data
+}
+
+func (h *AdminHandlers) userViews() []adminUserView {
+	views := []adminUserView{}
+	for _, user := range h.userService.GetAllUsers() {
+		views = append(views, newAdminUserView(user))
+	}
+	return views
+}
+
+// available answers 503 when users.json could not be loaded at startup
+func (h *AdminHandlers) available(c *gin.Context) bool {
+	if h.userService == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "User accounts are unavailable"})
+		return false
+	}
+	return true
+}
+
+// errAdminSelfChange is returned when an administrator tries to lock themselves out
+var errAdminSelfChange = errors.New("you cannot deactivate your own account or remove your own admin role")
+
+// adminErrorStatus maps a user service error to an HTTP status
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUserExists):
+		return http.StatusConflict
+	case errors.Is(err, errAdminSelfChange):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func newAdminUserView(user *services.User) adminUserView {
+	assignedCases := user.AssignedCases
+	if assignedCases == nil {
+		assignedCases = []string{}
+	}
+	return adminUserView{
+		ID:            user.ID,
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		Role:          services.NormalizeRole(user.Role),
+		Active:        user.Active,
+		Created:       user.Created,
+		LastLogin:     user.LastLogin,
+		AssignedCases: assignedCases,
+	}
+}
+
+// splitAssignedCases reads a comma or newline separated list of case folders or IDs
+func splitAssignedCases(value string) []string {
+	cases := []string{}
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		if field = strings.TrimSpace(field); field != "" {
+			cases = append(cases, field)
+		}
+	}
+	return cases
+}
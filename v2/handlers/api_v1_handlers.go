@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of the /api/v1 error envelope, {"error": message, "code": code}.
+// The login and permission checks answer with the same envelope.
+const (
+	apiErrorBadRequest        = "bad_request"
+	apiErrorUnauthorized      = "unauthorized"
+	apiErrorForbidden         = "forbidden"
+	apiErrorNotFound          = "not_found"
+	apiErrorNoCase            = "no_case_selected"
+	apiErrorNotProcessed      = "not_processed"
+	apiErrorSourceUnavailable = "source_unavailable"
+	apiErrorProcessingFailed  = "processing_failed"
+	apiErrorInternal          = "internal_error"
+)
+
+// apiError ends an /api/v1 request with the error envelope
+func apiError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message, "code": code})
+}
+
+// APIWorkflow is the session's progress through the wizard steps
+type APIWorkflow struct {
+	CurrentStep          int      `json:"currentStep"`
+	DocumentProvider     string   `json:"documentProvider"`
+	SourceConnected      bool     `json:"sourceConnected"`
+	SelectedParentFolder string   `json:"selectedParentFolder,omitempty"`
+	SelectedCaseFolder   string   `json:"selectedCaseFolder,omitempty"`
+	CaseID               string   `json:"caseId,omitempty"`
+	SelectedDocuments    []string `json:"selectedDocuments"`
+	SelectedTemplate     string   `json:"selectedTemplate,omitempty"`
+	Processed            bool     `json:"processed"`
+}
+
+// APICaseData is the outcome of processing the selected documents
+type APICaseData struct {
+	ProcessingResult *services.DocumentProcessingResult `json:"processingResult"`
+	ClientCase       *services.ClientCase               `json:"clientCase"`
+}
+
+// apiWorkflow summarizes the session's workflow state. It is read back from
+// the session service, since the state restored for the request predates any
+// update the handler made.
+func (h *UIHandlers) apiWorkflow(c *gin.Context) APIWorkflow {
+	state := h.getWorkflowState(c)
+	if sessionService := h.getSessionService(c); sessionService != nil {
+		state = sessionService.GetSession(h.getSessionID(c))
+	}
+	provider := state.DocumentProvider
+	if provider == "" {
+		provider = h.icloudService.DefaultProvider()
+	}
+	workflow := APIWorkflow{
+		CurrentStep:          state.CurrentStep,
+		DocumentProvider:     provider,
+		SourceConnected:      state.ICloudConnected,
+		SelectedParentFolder: state.SelectedParentFolder,
+		SelectedCaseFolder:   state.SelectedCaseFolder,
+		CaseID:               state.CaseID,
+		SelectedDocuments:    state.SelectedDocuments,
+		SelectedTemplate:     state.SelectedTemplate,
+		Processed:            state.ClientCase != nil,
+	}
+	if workflow.SelectedDocuments == nil {
+		workflow.SelectedDocuments = []string{}
+	}
+	return workflow
+}
+
+// APIGetWorkflow returns where the session is in the workflow
+func (h *UIHandlers) APIGetWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, h.apiWorkflow(c))
+}
+
+// APIListProviders lists the document providers a session can connect (Step 0)
+func (h *UIHandlers) APIListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"providers": h.icloudService.Providers(),
+		"default":   h.icloudService.DefaultProvider(),
+	})
+}
+
+// APIConnectSource connects the session's document provider (Step 0)
+func (h *UIHandlers) APIConnectSource(c *gin.Context) {
+	var req struct {
+		Provider    string `json:"provider"`
+		Username    string `json:"username"`
+		AppPassword string `json:"appPassword"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Invalid request format: "+err.Error())
+		return
+	}
+	if req.Provider == "" {
+		req.Provider = h.icloudService.DefaultProvider()
+	}
+	if err := h.connectDocumentSource(c, req.Provider, req.Username, req.AppPassword); err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, h.apiWorkflow(c))
+}
+
+// APIListFolders lists the top-level folders of the session's document
+// source, or the case folders inside ?parent= (Step 0)
+func (h *UIHandlers) APIListFolders(c *gin.Context) {
+	parent := c.Query("parent")
+	state := h.getWorkflowState(c)
+
+	var folders []services.ICloudDocument
+	var err error
+	if parent == "" {
+		folders, err = h.icloudService.GetRootFolders(state.DocumentProvider, state.ICloudUsername)
+	} else {
+		if !h.caseAccess(c).AllowsBrowsing(parent) {
+			denyAccess(c, currentUser(c), "folder="+parent)
+			return
+		}
+		folders, err = h.icloudService.GetSubfolders(state.DocumentProvider, state.ICloudUsername, parent)
+	}
+	if err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, "Could not load folders: "+err.Error())
+		return
+	}
+
+	folders = h.filterFolders(c, folders)
+	if folders == nil {
+		folders = []services.ICloudDocument{}
+	}
+	c.JSON(http.StatusOK, gin.H{"parent": parent, "folders": folders})
+}
+
+// APISelectCase makes a case folder the session's case and lists its
+// documents (Step 0 to Step 1)
+func (h *UIHandlers) APISelectCase(c *gin.Context) {
+	var req struct {
+		CaseFolder string `json:"caseFolder"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.CaseFolder == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "caseFolder is required")
+		return
+	}
+	if !h.authorizeCasePath(c, req.CaseFolder) {
+		return
+	}
+
+	caseID := h.selectCaseFolder(c, req.CaseFolder)
+	state := h.getWorkflowState(c)
+	documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, req.CaseFolder)
+	if err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, "Could not load documents: "+err.Error())
+		return
+	}
+	if documents == nil {
+		documents = []services.ICloudDocument{}
+	}
+	c.JSON(http.StatusOK, gin.H{"caseId": caseID, "caseFolder": req.CaseFolder, "documents": documents})
+}
+
+// APIListDocuments lists the documents of the selected case folder, or of
+// ?folder= (Step 1)
+func (h *UIHandlers) APIListDocuments(c *gin.Context) {
+	state := h.getWorkflowState(c)
+	folder := c.DefaultQuery("folder", state.SelectedCaseFolder)
+	if folder == "" {
+		apiError(c, http.StatusConflict, apiErrorNoCase, "No case selected. Select a case folder first.")
+		return
+	}
+	if !h.authorizeCasePath(c, folder) {
+		return
+	}
+
+	documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, folder)
+	if err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, "Could not load documents: "+err.Error())
+		return
+	}
+	if documents == nil {
+		documents = []services.ICloudDocument{}
+	}
+	c.JSON(http.StatusOK, gin.H{"folder": folder, "documents": documents})
+}
+
+// APISelectDocuments sets the documents to process (Step 1 to Step 2)
+func (h *UIHandlers) APISelectDocuments(c *gin.Context) {
+	var req struct {
+		Documents []string `json:"documents"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Documents) == 0 {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "documents must list at least one document path")
+		return
+	}
+	for _, docPath := range req.Documents {
+		if !h.authorizeCasePath(c, docPath) {
+			return
+		}
+	}
+
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		state.SelectedDocuments = req.Documents
+		state.CurrentStep = 2
+	})
+	c.JSON(http.StatusOK, h.apiWorkflow(c))
+}
+
+// APIListTemplates lists the complaint templates (Step 2)
+func (h *UIHandlers) APIListTemplates(c *gin.Context) {
+	templates, err := h.docService.GetTemplates()
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, apiErrorInternal, "Failed to load templates: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"errors":    h.docService.GetTemplateLoadErrors(),
+	})
+}
+
+// APIProcess processes the selected documents with a template and returns
+// the processing result and ClientCase (Step 2 to Step 3). Unlike the wizard
+// it answers when processing is done; a disconnecting client cancels it.
+func (h *UIHandlers) APIProcess(c *gin.Context) {
+	var req struct {
+		TemplateID string   `json:"templateId"`
+		Documents  []string `json:"documents"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Invalid request format: "+err.Error())
+		return
+	}
+	if req.TemplateID == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "templateId is required")
+		return
+	}
+
+	state := h.getWorkflowState(c)
+	documents := req.Documents
+	if len(documents) == 0 {
+		documents = state.SelectedDocuments
+	}
+	if len(documents) == 0 {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "No documents selected for processing")
+		return
+	}
+	for _, docPath := range documents {
+		if !h.authorizeCasePath(c, docPath) {
+			return
+		}
+	}
+
+	// A run started from the wizard would overwrite these results when it finishes
+	if job := h.processing.get(h.getSessionID(c)); job != nil {
+		job.cancel()
+	}
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		state.SelectedTemplate = req.TemplateID
+		state.SelectedDocuments = documents
+		state.CurrentStep = 3
+		state.ClearProcessingResults()
+	})
+
+	started := time.Now()
+	processingResult, clientCase, err := h.processSelectedDocuments(c.Request.Context(), state, documents, req.TemplateID, h.processingOptions)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Printf("[INFO] API document processing was cancelled by the client")
+			return
+		}
+		log.Printf("[ERROR] API document processing failed: %v", err)
+		apiError(c, http.StatusUnprocessableEntity, apiErrorProcessingFailed, "Error processing documents: "+err.Error())
+		return
+	}
+
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		state.SetProcessingResults(processingResult, clientCase)
+	})
+	log.Printf("[INFO] API processed %d documents in %s, %.1f%% coverage",
+		len(documents), time.Since(started).Round(time.Millisecond), processingResult.DataCoverage)
+	c.JSON(http.StatusOK, APICaseData{ProcessingResult: processingResult, ClientCase: clientCase})
+}
+
+// APIGetCaseData returns the session's processing result and ClientCase (Step 3)
+func (h *UIHandlers) APIGetCaseData(c *gin.Context) {
+	state := h.getWorkflowState(c)
+	if state.ClientCase == nil {
+		apiError(c, http.StatusConflict, apiErrorNotProcessed, "No case data available. Process the selected documents first.")
+		return
+	}
+	c.JSON(http.StatusOK, APICaseData{ProcessingResult: state.ProcessingResult, ClientCase: state.ClientCase})
+}
+
+// APIGenerate generates the complaint from the session's ClientCase (Step 4)
+func (h *UIHandlers) APIGenerate(c *gin.Context) {
+	var req struct {
+		TemplateID string `json:"templateId"`
+	}
+	// The body is optional; the template defaults to the one documents were processed with
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	state := h.getWorkflowState(c)
+	if state.ClientCase == nil {
+		apiError(c, http.StatusConflict, apiErrorNotProcessed, "No case data available. Process the selected documents first.")
+		return
+	}
+	templateID := req.TemplateID
+	if templateID == "" {
+		templateID = state.SelectedTemplate
+	}
+	if templateID == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "templateId is required")
+		return
+	}
+
+	document, err := h.docService.GenerateComplaint(templateID, state.ClientCase)
+	if err != nil {
+		log.Printf("[ERROR] API complaint generation failed: %v", err)
+		apiError(c, http.StatusUnprocessableEntity, apiErrorProcessingFailed, "Failed to generate document: "+err.Error())
+		return
+	}
+
+	h.recordDocumentVersion(state, services.GeneratedDocumentVersion{
+		Source:          services.VersionGenerated,
+		TemplateID:      document.Metadata.TemplateID,
+		TemplateVersion: document.Metadata.TemplateVersion,
+		Content:         services.DocumentBodyHTML(h.formatter.FormatAsHTML(document.Content)),
+		Document:        document,
+	})
+	c.JSON(http.StatusOK, document)
+}
+
+// APISaveDocument saves an edited complaint to the case (Step 4)
+func (h *UIHandlers) APISaveDocument(c *gin.Context) {
+	var req struct {
+		Content      string `json:"content"`
+		ClientName   string `json:"clientName"`
+		DocumentType string `json:"documentType"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Invalid request format: "+err.Error())
+		return
+	}
+	if req.Content == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "content is required")
+		return
+	}
+
+	state := h.getWorkflowState(c)
+	if req.ClientName == "" && state.ClientCase != nil {
+		req.ClientName = state.ClientCase.ClientName
+	}
+	if req.ClientName == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "clientName is required when the case has not been processed")
+		return
+	}
+	if req.DocumentType == "" {
+		req.DocumentType = "complaint"
+	}
+
+	documentPath, latestPath, err := h.writeSavedDocument(c, req.DocumentType, req.ClientName, req.Content)
+	if err != nil {
+		log.Printf("[ERROR] Error saving document to %s: %v", documentPath, err)
+		apiError(c, http.StatusInternalServerError, apiErrorInternal, "Error saving document: "+err.Error())
+		return
+	}
+	h.recordDocumentVersion(state, services.GeneratedDocumentVersion{
+		DocumentType: req.DocumentType,
+		Source:       services.VersionManual,
+		Content:      req.Content,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"path":       documentPath,
+		"latestPath": latestPath,
+		"savedAt":    time.Now(),
+	})
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionCookieName is the cookie holding the login session token
+const sessionCookieName = "session_token"
+
+// anonymousSessionID holds workflow state for requests without a login session
+const anonymousSessionID = "temp_session"
+
+// AuthHandlers contains the login, logout and session validation handlers
+type AuthHandlers struct {
+	userService    *services.UserService
+	sessionService *services.PersistentSessionService
+	cookieMaxAge   int
+}
+
+// NewAuthHandlers creates auth handlers; userService may be nil when users.json
+// could not be loaded, in which case every login is refused
+func NewAuthHandlers(userService *services.UserService, sessionService *services.PersistentSessionService, cookieMaxAge int) *AuthHandlers {
+	return &AuthHandlers{
+		userService:    userService,
+		sessionService: sessionService,
+		cookieMaxAge:   cookieMaxAge,
+	}
+}
+
+// SessionMiddleware resolves the request's login session and puts the session
+// service, session ID and user into the context for the other handlers
+func (h *AuthHandlers) SessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := anonymousSessionID
+
+		if token := requestSessionToken(c); token != "" {
+			if sessionData := h.sessionService.ValidateAuthSession(token); sessionData != nil {
+				sessionID = token
+				c.Set("username", sessionData.Username)
+				c.Set("displayName", sessionData.Username)
+				if h.userService != nil {
+					if user, exists := h.userService.GetUser(sessionData.Username); exists && user.Active {
+						c.Set("user", user)
+						c.Set("displayName", user.Name())
+					}
+				}
+
+				c.Set("restoredSession", true)
+				c.Set("workflowState", sessionData.WorkflowState)
+				log.Printf("[INFO] Session restored for %s, step %d", sessionData.Username, sessionData.WorkflowState.CurrentStep)
+			}
+		}
+
+		c.Set("sessionService", h.sessionService)
+		c.Set("sessionID", sessionID)
+		c.Next()
+	}
+}
+
+// RequireLogin redirects page requests without a login session to /login
+func (h *AuthHandlers) RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("username") == "" {
+			c.Redirect(http.StatusFound, "/login")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Login checks credentials and starts a new session with its own random token
+func (h *AuthHandlers) Login(c *gin.Context) {
+	var loginRequest struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := c.BindJSON(&loginRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request format"})
+		return
+	}
+
+	if h.userService == nil {
+		log.Printf("[LOGIN] Login refused for %s: user service unavailable", loginRequest.Username)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "Login is temporarily unavailable"})
+		return
+	}
+
+	user, isValid := h.userService.ValidateUser(loginRequest.Username, loginRequest.Password)
+	if !isValid {
+		log.Printf("[LOGIN] Invalid login attempt for user: %s", loginRequest.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid credentials"})
+		return
+	}
+
+	// Drop any session this browser already had before issuing a new token
+	if oldToken := requestSessionToken(c); oldToken != "" {
+		h.sessionService.DeleteSession(oldToken)
+	}
+
+	token, err := h.sessionService.CreateAuthSession(user.Username)
+	if err != nil {
+		log.Printf("[LOGIN] Failed to create session for %s: %v", user.Username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Could not start session"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookieName, token, h.cookieMaxAge, "/", "", c.Request.TLS != nil, true)
+	log.Printf("[LOGIN] User %s logged in with role: %s", user.Username, user.Role)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"user": gin.H{
+			"username":    user.Username,
+			"displayName": user.Name(),
+			"role":        user.Role,
+		},
+		"sessionToken": token,
+	})
+}
+
+// ValidateSession reports whether the request's session token belongs to an active user
+func (h *AuthHandlers) ValidateSession(c *gin.Context) {
+	sessionData := h.sessionService.ValidateAuthSession(requestSessionToken(c))
+	if sessionData == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"valid": false})
+		return
+	}
+
+	response := gin.H{
+		"valid":     true,
+		"username":  sessionData.Username,
+		"expiresAt": sessionData.ExpiresAt,
+	}
+	if h.userService != nil {
+		user, exists := h.userService.GetUser(sessionData.Username)
+		if !exists || !user.Active {
+			c.JSON(http.StatusUnauthorized, gin.H{"valid": false})
+			return
+		}
+		response["displayName"] = user.Name()
+		response["role"] = user.Role
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout deletes the session and clears its cookie
+func (h *AuthHandlers) Logout(c *gin.Context) {
+	if token := requestSessionToken(c); token != "" {
+		h.sessionService.DeleteSession(token)
+	}
+	c.SetCookie(sessionCookieName, "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out"})
+}
+
+// requestSessionToken reads the session token from the cookie, falling back
+// to the Authorization header used by API clients
+func requestSessionToken(c *gin.Context) string {
+	if token, err := c.Cookie(sessionCookieName); err == nil && token != "" {
+		return token
+	}
+	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
+	return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+}
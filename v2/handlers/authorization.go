@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// stepPermissions maps each workflow step to the permission needed to open it
+var stepPermissions = map[int]services.Permission{
+	0: services.PermissionBrowseCases,
+	1: services.PermissionSelectDocuments,
+	2: services.PermissionSelectDocuments,
+	3: services.PermissionReviewData,
+	4: services.PermissionGenerateDocuments,
+	5: services.PermissionGenerateDocuments,
+}
+
+// currentUser returns the logged-in user set by the session middleware
+func currentUser(c *gin.Context) *services.User {
+	if value, exists := c.Get("user"); exists {
+		if user, ok := value.(*services.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// RequirePermission rejects requests from users whose role lacks a permission
+func (h *AuthHandlers) RequirePermission(permission services.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorize(c, permission) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireStepPermission checks the permission for the workflow step in the :step parameter
+func (h *AuthHandlers) RequireStepPermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		step, err := strconv.Atoi(c.Param("step"))
+		if err != nil {
+			step = 0
+		}
+		permission, ok := stepPermissions[step]
+		if !ok {
+			permission = services.PermissionBrowseCases
+		}
+		if !authorize(c, permission) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorize checks a permission for the current user, logging and answering
+// the request when it is denied
+func authorize(c *gin.Context, permission services.Permission) bool {
+	user := currentUser(c)
+	if user == nil {
+		log.Printf("[AUTHZ] Denied anonymous request: permission=%s path=%s", permission, c.Request.URL.Path)
+		if c.GetHeader("HX-Request") != "" {
+			c.Header("HX-Redirect", "/login")
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Login required", "code": apiErrorUnauthorized})
+		return false
+	}
+	if !user.Can(permission) {
+		denyAccess(c, user, "permission="+string(permission))
+		return false
+	}
+	return true
+}
+
+// denyAccess logs a denial and answers with 403
+func denyAccess(c *gin.Context, user *services.User, reason string) {
+	username, role := "anonymous", ""
+	if user != nil {
+		username, role = user.Username, user.Role
+	}
+	log.Printf("[AUTHZ] Denied user=%s role=%s %s method=%s path=%s", username, role, reason, c.Request.Method, c.Request.URL.Path)
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have access to this resource", "code": apiErrorForbidden})
+}
+
+// caseAccess returns the case scope of the logged-in user
+func (h *UIHandlers) caseAccess(c *gin.Context) *services.CaseAccess {
+	return services.NewCaseAccess(currentUser(c), h.caseStore)
+}
+
+// authorizeCasePath denies the request when a case folder or document is
+// outside the user's assigned cases
+func (h *UIHandlers) authorizeCasePath(c *gin.Context, objectPath string) bool {
+	if h.caseAccess(c).AllowsPath(objectPath) {
+		return true
+	}
+	denyAccess(c, currentUser(c), "case="+objectPath)
+	return false
+}
+
+// filterFolders keeps the folders the user may browse
+func (h *UIHandlers) filterFolders(c *gin.Context, folders []services.ICloudDocument) []services.ICloudDocument {
+	access := h.caseAccess(c)
+	if access.AllCases() {
+		return folders
+	}
+	visible := []services.ICloudDocument{}
+	for _, folder := range folders {
+		if access.AllowsBrowsing(folder.Path) {
+			visible = append(visible, folder)
+		}
+	}
+	return visible
+}
+
+// RequireSelectedCaseAccess rejects requests that act on the session's case
+// folder when that folder is not assigned to the user
+func (h *UIHandlers) RequireSelectedCaseAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := h.getWorkflowState(c)
+		if state.SelectedCaseFolder != "" && !h.authorizeCasePath(c, state.SelectedCaseFolder) {
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportCaseCalendar serves the selected case's statutory deadlines and
+// service milestones as an iCalendar (.ics) file
+func (h *UIHandlers) ExportCaseCalendar(c *gin.Context) {
+	state := h.getWorkflowState(c)
+	if state.SelectedCaseFolder == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No case selected. Please select a case folder first."})
+		return
+	}
+
+	caseID := state.CaseID
+	if caseID == "" {
+		caseID = state.SelectedCaseFolder
+	}
+	caseName := path.Base(state.SelectedCaseFolder)
+	calendar := services.NewCaseCalendar(caseID, caseName+" - Deadlines")
+
+	// Service milestones and answer deadlines from each summons in the selection
+	var summonses []*services.SummonsDocument
+	for _, documentPath := range state.SelectedDocuments {
+		if !strings.Contains(strings.ToLower(path.Base(documentPath)), "summons") {
+			continue
+		}
+		summons, validation := h.validateSummonsService(documentPath)
+		if summons != nil {
+			summonses = append(summonses, summons)
+		}
+		if validation != nil {
+			calendar.AddMilestones(validation.ServiceTimeline.Milestones...)
+		}
+	}
+
+	// Reinvestigation and answer deadlines from the case timeline
+	calendar.AddDeadlines(h.timeline.CaseDeadlines(state.ClientCase, summonses)...)
+
+	// Limitations deadlines, from the Step 3 review when it has been done
+	if state.ClientCase != nil {
+		limitations := state.ClientCase.Limitations
+		if limitations == nil {
+			analysis := h.limitations.Check(state.ClientCase, nil)
+			limitations = &analysis
+		}
+		for _, claim := range limitations.Claims {
+			for _, deadline := range claim.Deadlines {
+				// One event per claim and period, whatever date triggered it
+				deadline.DeadlineID = fmt.Sprintf("limitations_%s_%s", claim.ClaimID, deadline.DeadlineType)
+				deadline.DeadlineDescription = fmt.Sprintf("%s: %s", claim.ViolationName, deadline.DeadlineDescription)
+				calendar.AddDeadlines(deadline)
+			}
+		}
+	}
+
+	events := calendar.Events()
+	log.Printf("[INFO] Exporting %d calendar events for case %s", len(events), caseID)
+
+	filename := fmt.Sprintf("%s_deadlines.ics", strings.ToLower(strings.Replace(caseName, " ", "_", -1)))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", calendar.ICS())
+}
+
+// validateSummonsService parses a summons and validates its service. Either
+// result is nil when the step that produces it is unavailable or fails.
+func (h *UIHandlers) validateSummonsService(documentPath string) (*services.SummonsDocument, *services.ServiceValidationResult) {
+	if h.summonsParser == nil {
+		return nil, nil
+	}
+
+	extractedText, err := h.extractTextFromDocument(documentPath)
+	if err != nil {
+		log.Printf("Error extracting text from %s: %v", documentPath, err)
+		return nil, nil
+	}
+	summons, err := h.summonsParser.ParseSummons(extractedText, documentPath)
+	if err != nil {
+		log.Printf("Error parsing summons %s: %v", documentPath, err)
+		return nil, nil
+	}
+	if h.courtAnalyzer == nil || h.serviceValidator == nil {
+		return summons, nil
+	}
+
+	courtAnalysis, err := h.courtAnalyzer.AnalyzeCourt(extractedText, &summons.Defendant)
+	if err != nil {
+		log.Printf("Error analyzing court information for %s: %v", documentPath, err)
+		return summons, nil
+	}
+	validation, err := h.serviceValidator.ValidateService(summons, courtAnalysis)
+	if err != nil {
+		log.Printf("Error validating service for %s: %v", documentPath, err)
+		return summons, nil
+	}
+	return summons, validation
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CaseAnalysis returns the cross-document analysis of a case as a versioned
+// JSON report. The documents are the ones selected in the case's latest
+// extraction, or every readable document in the case folder before that.
+func (h *UIHandlers) CaseAnalysis(c *gin.Context) {
+	caseID := c.Param("id")
+	metadata, err := h.caseStore.GetCase(caseID)
+	if err != nil {
+		if errors.Is(err, services.ErrCaseObjectNotFound) {
+			apiError(c, http.StatusNotFound, apiErrorNotFound, "Case not found: "+caseID)
+			return
+		}
+		log.Printf("[ERROR] Failed to look up case %s: %v", caseID, err)
+		apiError(c, http.StatusInternalServerError, apiErrorInternal, "Failed to look up case: "+err.Error())
+		return
+	}
+	if !h.authorizeCasePath(c, metadata.Folder) {
+		return
+	}
+
+	state := h.getWorkflowState(c)
+	source, err := h.icloudService.Source(metadata.Provider, state.ICloudUsername)
+	if err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, "Document source unavailable: "+err.Error())
+		return
+	}
+
+	var documents []string
+	if h.matters != nil {
+		if matter, err := h.matters.GetMatter(caseID); err == nil {
+			documents = matter.SelectedDocuments
+		}
+	}
+	if len(documents) == 0 {
+		if documents, err = h.docService.ReadableDocuments(source, metadata.Folder); err != nil {
+			apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, err.Error())
+			return
+		}
+	}
+	if len(documents) == 0 {
+		apiError(c, http.StatusUnprocessableEntity, apiErrorProcessingFailed, "Case has no documents to analyze")
+		return
+	}
+
+	report, err := h.caseAnalysis.AnalyzeCase(c.Request.Context(), source, metadata, documents)
+	if err != nil {
+		log.Printf("[ERROR] Case analysis of %s failed: %v", caseID, err)
+		apiError(c, http.StatusUnprocessableEntity, apiErrorProcessingFailed, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentType shows a Step 1 document's classified type with a control to
+// correct it
+func (h *UIHandlers) DocumentType(c *gin.Context) {
+	docPath := c.Query("path")
+	if docPath == "" || !h.authorizeCasePath(c, docPath) {
+		return
+	}
+
+	review, err := h.withDocumentSource(c, func(source services.DocumentSource) (*services.DocumentTypeReview, error) {
+		return h.docService.ReviewDocumentType(c.Request.Context(), source, docPath)
+	})
+	if err != nil {
+		log.Printf("[DOCUMENT_TYPE] Error classifying %s: %v", docPath, err)
+	}
+	h.renderDocumentType(c, docPath, review, err)
+}
+
+// LabelDocumentType stores the document type a user set in Step 1, which the
+// learned classifier is retrained with
+func (h *UIHandlers) LabelDocumentType(c *gin.Context) {
+	docPath := c.PostForm("path")
+	if docPath == "" || !h.authorizeCasePath(c, docPath) {
+		return
+	}
+	user := currentUser(c)
+
+	review, err := h.withDocumentSource(c, func(source services.DocumentSource) (*services.DocumentTypeReview, error) {
+		return h.docService.LabelDocumentType(c.Request.Context(), source, docPath, c.PostForm("documentType"), user.Username)
+	})
+	if err != nil {
+		log.Printf("[DOCUMENT_TYPE] Error labeling %s: %v", docPath, err)
+	} else {
+		// Let the metrics panel know the labeled set changed
+		c.Header("HX-Trigger", "classificationLabeled")
+	}
+	h.renderDocumentType(c, docPath, review, err)
+}
+
+// ClassifierMetrics renders the classifier's per-type precision and recall on
+// the labeled documents
+func (h *UIHandlers) ClassifierMetrics(c *gin.Context) {
+	metrics, err := h.docService.ClassifierMetrics()
+	data := gin.H{"Metrics": metrics}
+	if err != nil {
+		data["Error"] = err.Error()
+	}
+	if err := h.templates.ExecuteTemplate(c.Writer, "_classifier_metrics.gohtml", data); err != nil {
+		log.Printf("Error executing template _classifier_metrics.gohtml: %v", err)
+	}
+}
+
+// APIDocumentType returns a document's classified type and its label, if any
+func (h *UIHandlers) APIDocumentType(c *gin.Context) {
+	docPath := c.Query("path")
+	if docPath == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "A document path is required.")
+		return
+	}
+	if !h.authorizeCasePath(c, docPath) {
+		return
+	}
+
+	review, err := h.withDocumentSource(c, func(source services.DocumentSource) (*services.DocumentTypeReview, error) {
+		return h.docService.ReviewDocumentType(c.Request.Context(), source, docPath)
+	})
+	if err != nil {
+		apiError(c, http.StatusBadGateway, apiErrorSourceUnavailable, "Failed to classify the document: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, newAPIDocumentType(review))
+}
+
+// APILabelDocumentType stores the document type a user set for a document
+func (h *UIHandlers) APILabelDocumentType(c *gin.Context) {
+	var req struct {
+		Path         string `json:"path"`
+		DocumentType string `json:"documentType"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Path == "" {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "A document path and type are required.")
+		return
+	}
+	if docType, ok := services.DocumentTypeByName(req.DocumentType); !ok || docType == services.DocumentTypeUnknown {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Unknown document type: "+req.DocumentType)
+		return
+	}
+	if !h.authorizeCasePath(c, req.Path) {
+		return
+	}
+	user := currentUser(c)
+
+	review, err := h.withDocumentSource(c, func(source services.DocumentSource) (*services.DocumentTypeReview, error) {
+		return h.docService.LabelDocumentType(c.Request.Context(), source, req.Path, req.DocumentType, user.Username)
+	})
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, apiErrorInternal, "Failed to label the document: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, newAPIDocumentType(review))
+}
+
+// APIClassifierMetrics returns the classifier's precision and recall on the labeled documents
+func (h *UIHandlers) APIClassifierMetrics(c *gin.Context) {
+	metrics, err := h.docService.ClassifierMetrics()
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, apiErrorInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// withDocumentSource opens the session's document source for fn
+func (h *UIHandlers) withDocumentSource(c *gin.Context, fn func(services.DocumentSource) (*services.DocumentTypeReview, error)) (*services.DocumentTypeReview, error) {
+	state := h.getWorkflowState(c)
+	source, err := h.icloudService.Source(state.DocumentProvider, state.ICloudUsername)
+	if err != nil {
+		return nil, err
+	}
+	return fn(source)
+}
+
+func (h *UIHandlers) renderDocumentType(c *gin.Context, docPath string, review *services.DocumentTypeReview, err error) {
+	data := gin.H{
+		"Path":          docPath,
+		"Review":        review,
+		"DocumentTypes": services.DocumentTypeNames(),
+	}
+	if err != nil {
+		data["Error"] = err.Error()
+	}
+	if err := h.templates.ExecuteTemplate(c.Writer, "_document_type.gohtml", data); err != nil {
+		log.Printf("Error executing template _document_type.gohtml: %v", err)
+	}
+}
+
+// apiDocumentType is a document's type as the API returns it
+type apiDocumentType struct {
+	Path         string     `json:"path"`
+	DocumentType string     `json:"documentType"`
+	Predicted    string     `json:"predicted"`
+	Confidence   float64    `json:"confidence"`
+	LearnedModel bool       `json:"learnedModel"`
+	Labeled      bool       `json:"labeled"`
+	LabeledBy    string     `json:"labeledBy,omitempty"`
+	LabeledAt    *time.Time `json:"labeledAt,omitempty"`
+}
+
+func newAPIDocumentType(review *services.DocumentTypeReview) apiDocumentType {
+	view := apiDocumentType{
+		Path:         review.Path,
+		DocumentType: review.DocumentType(),
+		Predicted:    review.Predicted,
+		Confidence:   review.Confidence,
+		LearnedModel: review.LearnedModel,
+	}
+	if review.Label != nil {
+		view.Labeled = true
+		view.LabeledBy = review.Label.LabeledBy
+		view.LabeledAt = &review.Label.LabeledAt
+	}
+	return view
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+var errVersionHistoryUnavailable = errors.New("version history needs the matter database and a selected case folder")
+
+// DocumentVersions shows the version history of the case's complaint, with a
+// diff of the two most recent versions
+func (h *UIHandlers) DocumentVersions(c *gin.Context) {
+	h.renderDocumentVersions(c, nil)
+}
+
+// renderDocumentVersions renders the version history, noting a version that
+// was just restored
+func (h *UIHandlers) renderDocumentVersions(c *gin.Context, restored *services.GeneratedDocumentVersion) {
+	state := h.getWorkflowState(c)
+	history, err := h.documentHistory(state)
+	if err != nil {
+		h.renderVersionError(c, err.Error())
+		return
+	}
+
+	data := gin.H{
+		"Versions":   newestFirst(history),
+		"CanRestore": currentUser(c).Can(services.PermissionEditDocuments),
+		"View":       diffView(c),
+		"Restored":   restored,
+	}
+	if len(history) >= 2 {
+		from, to := history[len(history)-2].Version, history[len(history)-1].Version
+		if diff, err := services.DiffDocumentVersions(history, from, to); err == nil {
+			data["Diff"] = diff
+		}
+	}
+
+	if err := h.templates.ExecuteTemplate(c.Writer, "_document_versions.gohtml", data); err != nil {
+		log.Printf("Error executing template _document_versions.gohtml: %v", err)
+	}
+}
+
+// DocumentVersionDiff compares any two versions of the case's complaint
+func (h *UIHandlers) DocumentVersionDiff(c *gin.Context) {
+	state := h.getWorkflowState(c)
+	history, err := h.documentHistory(state)
+	if err != nil {
+		h.renderVersionError(c, err.Error())
+		return
+	}
+
+	from, _ := strconv.Atoi(c.Query("from"))
+	to, _ := strconv.Atoi(c.Query("to"))
+	diff, err := services.DiffDocumentVersions(history, from, to)
+	if err != nil {
+		h.renderVersionError(c, "Choose two versions of this document to compare.")
+		return
+	}
+
+	data := gin.H{"Diff": diff, "View": diffView(c)}
+	if err := h.templates.ExecuteTemplate(c.Writer, "_document_version_diff.gohtml", data); err != nil {
+		log.Printf("Error executing template _document_version_diff.gohtml: %v", err)
+	}
+}
+
+// RestoreDocumentVersion makes an older version of the complaint the latest
+// again. The restore is itself a new version, so nothing in the history is lost.
+func (h *UIHandlers) RestoreDocumentVersion(c *gin.Context) {
+	state := h.getWorkflowState(c)
+	if h.matters == nil || state.MatterID == "" {
+		h.renderVersionError(c, errVersionHistoryUnavailable.Error())
+		return
+	}
+
+	number, _ := strconv.Atoi(c.Param("version"))
+	old, err := h.matters.DocumentVersion(state.MatterID, number)
+	if err != nil {
+		h.renderVersionError(c, "That version of the document no longer exists.")
+		return
+	}
+
+	clientName := "Eman Youssef"
+	if old.ClientCase != nil && old.ClientCase.ClientName != "" {
+		clientName = old.ClientCase.ClientName
+	}
+	if _, _, err := h.writeSavedDocument(c, old.DocumentType, clientName, old.Content); err != nil {
+		log.Printf("[ERROR] Error writing restored document version %d: %v", number, err)
+		h.renderVersionError(c, "Error restoring document: "+err.Error())
+		return
+	}
+
+	restored, _, err := h.matters.RecordDocumentVersion(state.MatterID, services.GeneratedDocumentVersion{
+		DocumentType:    old.DocumentType,
+		Source:          services.VersionRestored,
+		RestoredFrom:    old.Version,
+		Author:          state.Username,
+		TemplateID:      old.TemplateID,
+		TemplateVersion: old.TemplateVersion,
+		ClientCase:      old.ClientCase,
+		Content:         old.Content,
+		Document:        old.Document,
+	})
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		h.renderVersionError(c, "Error restoring document: "+err.Error())
+		return
+	}
+	log.Printf("[INFO] %s restored version %d of the %s for matter %s as version %d", state.Username, old.Version, old.DocumentType, state.MatterID, restored.Version)
+
+	h.renderDocumentVersions(c, restored)
+}
+
+// recordDocumentVersion adds a version to the matter's document history,
+// snapshotting the session's case data and template. It does nothing when the
+// matter database is off or no case folder is selected.
+func (h *UIHandlers) recordDocumentVersion(state *services.WorkflowState, version services.GeneratedDocumentVersion) {
+	if h.matters == nil || state.MatterID == "" {
+		return
+	}
+
+	if version.DocumentType == "" {
+		version.DocumentType = "complaint"
+	}
+	version.Author = state.Username
+	version.ClientCase = state.ClientCase
+	if version.TemplateID == "" {
+		version.TemplateID = state.SelectedTemplate
+	}
+	if version.TemplateVersion == "" {
+		version.TemplateVersion = h.templateVersion(version.TemplateID)
+	}
+
+	recorded, stored, err := h.matters.RecordDocumentVersion(state.MatterID, version)
+	if err != nil {
+		log.Printf("[WARNING] %v", err)
+	} else if stored {
+		log.Printf("[INFO] Recorded %s version %d of the %s for matter %s", version.Source, recorded.Version, version.DocumentType, state.MatterID)
+	}
+}
+
+// documentHistory returns the matter's document versions, oldest first
+func (h *UIHandlers) documentHistory(state *services.WorkflowState) ([]services.GeneratedDocumentVersion, error) {
+	if h.matters == nil || state.MatterID == "" {
+		return nil, errVersionHistoryUnavailable
+	}
+	history, err := h.matters.DocumentVersions(state.MatterID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load document versions for matter %s: %v", state.MatterID, err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// templateVersion returns the version of a loaded complaint template
+func (h *UIHandlers) templateVersion(templateID string) string {
+	templates, err := h.docService.GetTemplates()
+	if err != nil {
+		return ""
+	}
+	for _, template := range templates {
+		if template.ID == templateID {
+			return template.Version
+		}
+	}
+	return ""
+}
+
+// renderVersionError shows a message in place of the version history
+func (h *UIHandlers) renderVersionError(c *gin.Context, message string) {
+	h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{"Error": message})
+}
+
+// diffView returns the requested diff layout, "inline" or "side"
+func diffView(c *gin.Context) string {
+	if c.Query("view") == "side" {
+		return "side"
+	}
+	return "inline"
+}
+
+// newestFirst returns versions in reverse order
+func newestFirst(history []services.GeneratedDocumentVersion) []services.GeneratedDocumentVersion {
+	versions := make([]services.GeneratedDocumentVersion, len(history))
+	for i, version := range history {
+		versions[len(history)-1-i] = version
+	}
+	return versions
+}
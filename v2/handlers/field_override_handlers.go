@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// OverrideField records or withdraws an attorney's correction of an extracted
+// ClientCase field and re-renders the Step 3 field overrides panel
+func (h *UIHandlers) OverrideField(c *gin.Context) {
+	field := c.PostForm("field")
+	withdraw := c.PostForm("action") == "withdraw"
+	user := currentUser(c)
+
+	var views []services.FieldOverrideView
+	var overrideErr error
+	found := false
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		if state.ClientCase == nil {
+			return
+		}
+		found = true
+		if withdraw {
+			state.ClientCase.ClearFieldOverride(field)
+		} else {
+			overrideErr = state.ClientCase.OverrideField(field, c.PostForm("value"), c.PostForm("reason"), user.Username)
+		}
+		if overrideErr == nil {
+			h.recheckLimitations(state, field)
+		}
+		views = state.ClientCase.FieldOverrideViews()
+	})
+
+	data := PageData{
+		FieldOverrides:    views,
+		CanOverrideFields: true,
+	}
+	switch {
+	case !found:
+		data.FieldOverrideError = "No case data in this session. Please open Step 3 review again."
+	case overrideErr != nil:
+		data.FieldOverrideError = overrideErr.Error()
+	case withdraw:
+		log.Printf("[FIELD_OVERRIDE] %s withdrew the override of %s", user.Username, field)
+	default:
+		log.Printf("[FIELD_OVERRIDE] %s overrode %s", user.Username, field)
+	}
+
+	if err := h.templates.ExecuteTemplate(c.Writer, "_field_overrides_panel.gohtml", data); err != nil {
+		log.Printf("Error executing template _field_overrides_panel.gohtml: %v", err)
+	}
+}
+
+// APIOverrideField records an attorney's correction of a ClientCase field and
+// returns the session's case data
+func (h *UIHandlers) APIOverrideField(c *gin.Context) {
+	var req struct {
+		Field  string `json:"field"`
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	user := currentUser(c)
+
+	var clientCase *services.ClientCase
+	var overrideErr error
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		if state.ClientCase == nil {
+			return
+		}
+		overrideErr = state.ClientCase.OverrideField(req.Field, req.Value, req.Reason, user.Username)
+		if overrideErr == nil {
+			h.recheckLimitations(state, req.Field)
+		}
+		clientCase = state.ClientCase
+	})
+	switch {
+	case clientCase == nil:
+		apiError(c, http.StatusConflict, apiErrorNotProcessed, "No case data available. Process the selected documents first.")
+		return
+	case overrideErr != nil:
+		apiError(c, http.StatusBadRequest, apiErrorBadRequest, overrideErr.Error())
+		return
+	}
+	log.Printf("[FIELD_OVERRIDE] %s overrode %s", user.Username, req.Field)
+	c.JSON(http.StatusOK, clientCase)
+}
+
+// APIClearFieldOverride withdraws an override so the extracted value is used
+// again and returns the session's case data
+func (h *UIHandlers) APIClearFieldOverride(c *gin.Context) {
+	field := c.Param("field")
+	user := currentUser(c)
+
+	var clientCase *services.ClientCase
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		if state.ClientCase == nil {
+			return
+		}
+		state.ClientCase.ClearFieldOverride(field)
+		h.recheckLimitations(state, field)
+		clientCase = state.ClientCase
+	})
+	if clientCase == nil {
+		apiError(c, http.StatusConflict, apiErrorNotProcessed, "No case data available. Process the selected documents first.")
+		return
+	}
+	log.Printf("[FIELD_OVERRIDE] %s withdrew the override of %s", user.Username, field)
+	c.JSON(http.StatusOK, clientCase)
+}
+
+// recheckLimitations checks limitations again when an override changed a date
+// claims are dated from, so generation doesn't use the check of the old date
+func (h *UIHandlers) recheckLimitations(state *services.WorkflowState, field string) {
+	if !services.IsLimitationsDate(field) {
+		return
+	}
+	h.docService.RecheckLimitations(state.ProcessingResult, state.ClientCase, state.SelectedDocuments)
+	log.Printf("[FIELD_OVERRIDE] Rechecked limitations after a change to %s: %d of %d claims time-barred",
+		field, state.ClientCase.Limitations.TimeBarredCount, len(state.ClientCase.Limitations.Claims))
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"log"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// OverrideLimitations records or withdraws an attorney's override of a
+// time-barred claim and re-renders the Step 3 limitations panel
+func (h *UIHandlers) OverrideLimitations(c *gin.Context) {
+	claimID := c.PostForm("claimId")
+	withdraw := c.PostForm("action") == "withdraw"
+	user := currentUser(c)
+
+	var limitations *services.LimitationsAnalysis
+	var overrideErr error
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		if state.ClientCase == nil || state.ClientCase.Limitations == nil {
+			return
+		}
+		if withdraw {
+			state.ClientCase.ClearLimitationsOverride(claimID)
+		} else {
+			overrideErr = state.ClientCase.OverrideLimitations(claimID, c.PostForm("reason"), user.Username)
+		}
+		limitations = state.ClientCase.Limitations
+	})
+
+	data := PageData{
+		LegalAnalysis:          LegalAnalysis{Limitations: limitations},
+		CanOverrideLimitations: true,
+	}
+	switch {
+	case limitations == nil:
+		data.LimitationsError = "No limitations analysis in this session. Please open Step 3 review again."
+	case overrideErr != nil:
+		data.LimitationsError = overrideErr.Error()
+	case withdraw:
+		log.Printf("[LIMITATIONS] %s withdrew the override of claim %s", user.Username, claimID)
+	default:
+		log.Printf("[LIMITATIONS] %s overrode the time bar on claim %s", user.Username, claimID)
+	}
+
+	if err := h.templates.ExecuteTemplate(c.Writer, "_limitations_panel.gohtml", data); err != nil {
+		log.Printf("Error executing template _limitations_panel.gohtml: %v", err)
+	}
+}
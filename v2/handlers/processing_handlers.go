@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mallon-legal-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Status of a processing job, also the name of the event that ends its stream
+const (
+	processingRunning   = "running"
+	processingComplete  = "complete"
+	processingFailed    = "failed"
+	processingCancelled = "cancelled"
+)
+
+// finishedJobRetention is how long a finished job stays around for a
+// reconnecting browser to pick up its outcome
+const finishedJobRetention = 10 * time.Minute
+
+// ProcessingProgress is a snapshot of a processing job for the progress panel
+type ProcessingProgress struct {
+	Documents []services.ProcessingEvent
+	Correlate *services.ProcessingEvent
+	Status    string
+	Error     string
+	Finished  int
+	Total     int
+}
+
+// Running reports whether the job is still processing documents
+func (p *ProcessingProgress) Running() bool {
+	return p.Status == processingRunning
+}
+
+// Percent is how far through the pipeline the job is, counting correlation as one step
+func (p *ProcessingProgress) Percent() int {
+	steps, done := p.Total+1, p.Finished
+	if p.Correlate != nil && p.Correlate.Finished() {
+		done++
+	}
+	if p.Status == processingComplete {
+		done = steps
+	}
+	return done * 100 / steps
+}
+
+// processingJob is one session's run of the document processing pipeline.
+// It outlives the request that started it so the browser can follow along
+// over server-sent events.
+type processingJob struct {
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	documents []services.ProcessingEvent
+	revisions []int
+	correlate *services.ProcessingEvent
+	status    string
+	err       string
+	updated   chan struct{}
+}
+
+func newProcessingJob(selectedDocs []string, cancel context.CancelFunc) *processingJob {
+	job := &processingJob{
+		cancel:    cancel,
+		documents: make([]services.ProcessingEvent, len(selectedDocs)),
+		revisions: make([]int, len(selectedDocs)),
+		status:    processingRunning,
+		updated:   make(chan struct{}),
+	}
+	for i, docPath := range selectedDocs {
+		job.documents[i] = services.ProcessingEvent{Index: i, Total: len(selectedDocs), Document: filepath.Base(docPath), Path: docPath, Stage: services.StageQueued, Status: services.ProcessingRunning}
+	}
+	return job
+}
+
+// record keeps the latest event for each document; it is the pipeline's progress callback
+func (j *processingJob) record(event services.ProcessingEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if event.Index < 0 {
+		j.correlate = &event
+	} else if event.Index < len(j.documents) {
+		j.documents[event.Index] = event
+		j.revisions[event.Index]++
+	}
+	j.notifyLocked()
+}
+
+// finish ends the job with a status and, for failures, what went wrong
+func (j *processingJob) finish(status, message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status, j.err = status, message
+	j.notifyLocked()
+}
+
+// notifyLocked wakes everyone waiting for the next update
+func (j *processingJob) notifyLocked() {
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// snapshot returns the job's progress, the revision of each document's
+// latest event, and a channel that is closed on the next update
+func (j *processingJob) snapshot() (*ProcessingProgress, []int, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	progress := &ProcessingProgress{
+		Documents: append([]services.ProcessingEvent(nil), j.documents...),
+		Status:    j.status,
+		Error:     j.err,
+		Total:     len(j.documents),
+	}
+	if j.correlate != nil {
+		correlate := *j.correlate
+		progress.Correlate = &correlate
+	}
+	for _, document := range j.documents {
+		if document.Finished() {
+			progress.Finished++
+		}
+	}
+	return progress, append([]int(nil), j.revisions...), j.updated
+}
+
+// processingJobs holds the current processing job of each session
+type processingJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*processingJob
+}
+
+func newProcessingJobs() *processingJobs {
+	return &processingJobs{jobs: make(map[string]*processingJob)}
+}
+
+// start makes job the session's current job, cancelling the one it replaces
+func (p *processingJobs) start(sessionID string, job *processingJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if previous, ok := p.jobs[sessionID]; ok {
+		previous.cancel()
+	}
+	p.jobs[sessionID] = job
+}
+
+// get returns the session's current job, if any
+func (p *processingJobs) get(sessionID string) *processingJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jobs[sessionID]
+}
+
+// isCurrent reports whether job is still the session's current job
+func (p *processingJobs) isCurrent(sessionID string, job *processingJob) bool {
+	return p.get(sessionID) == job
+}
+
+// forget drops a finished job once nobody needs its outcome
+func (p *processingJobs) forget(sessionID string, job *processingJob) {
+	time.AfterFunc(finishedJobRetention, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.jobs[sessionID] == job {
+			delete(p.jobs, sessionID)
+		}
+	})
+}
+
+// startProcessing runs the processing pipeline for the session in the
+// background and returns its progress so far. The results are saved to the
+// session when every document is through, unless a newer run replaced it.
+func (h *UIHandlers) startProcessing(c *gin.Context, selectedDocs []string, templateID string) (*ProcessingProgress, error) {
+	sessionService := h.getSessionService(c)
+	if sessionService == nil {
+		return nil, errors.New("session not available")
+	}
+	sessionID := h.getSessionID(c)
+	state := h.getWorkflowState(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := newProcessingJob(selectedDocs, cancel)
+	h.processing.start(sessionID, job)
+
+	options := h.processingOptions
+	options.Progress = job.record
+
+	go func() {
+		defer cancel()
+		defer h.processing.forget(sessionID, job)
+
+		started := time.Now()
+		processingResult, clientCase, err := h.processSelectedDocuments(ctx, state, selectedDocs, templateID, options)
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Printf("[INFO] Document processing for session %s was cancelled", sessionID)
+			job.finish(processingCancelled, "")
+			return
+		case err != nil:
+			log.Printf("[ERROR] Error processing selected documents: %v", err)
+			job.finish(processingFailed, "Error processing documents: "+err.Error())
+			return
+		case !h.processing.isCurrent(sessionID, job):
+			job.finish(processingCancelled, "")
+			return
+		}
+
+		sessionService.UpdateSession(sessionID, func(state *services.WorkflowState) {
+			state.SetProcessingResults(processingResult, clientCase)
+		})
+		log.Printf("[INFO] Processed %d documents for session %s in %s, %.1f%% coverage",
+			len(selectedDocs), sessionID, time.Since(started).Round(time.Millisecond), processingResult.DataCoverage)
+		job.finish(processingComplete, "")
+	}()
+
+	progress, _, _ := job.snapshot()
+	return progress, nil
+}
+
+// runningProcessing returns the progress of the session's job while it is
+// still processing documents
+func (h *UIHandlers) runningProcessing(c *gin.Context) *ProcessingProgress {
+	job := h.processing.get(h.getSessionID(c))
+	if job == nil {
+		return nil
+	}
+	if progress, _, _ := job.snapshot(); progress.Running() {
+		return progress
+	}
+	return nil
+}
+
+// ProcessingEvents streams the session's processing job to the progress
+// panel as server-sent events: a "document" event with the rendered row each
+// time a document changes stage, a "summary" event with the overall progress,
+// and finally "complete", "failed" or "cancelled".
+func (h *UIHandlers) ProcessingEvents(c *gin.Context) {
+	job := h.processing.get(h.getSessionID(c))
+	if job == nil {
+		c.SSEvent(processingCancelled, "")
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	var sent []int
+	c.Stream(func(w io.Writer) bool {
+		progress, revisions, updated := job.snapshot()
+		if sent == nil {
+			sent = make([]int, len(revisions))
+			for i := range sent {
+				sent[i] = -1
+			}
+		}
+		for i, document := range progress.Documents {
+			if revisions[i] != sent[i] {
+				c.SSEvent("document", h.renderFragment("_processing_document", document))
+				sent[i] = revisions[i]
+			}
+		}
+		c.SSEvent("summary", h.renderFragment("_processing_summary", progress))
+
+		if !progress.Running() {
+			c.SSEvent(progress.Status, "")
+			return false
+		}
+
+		select {
+		case <-updated:
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// CancelProcessing stops the session's processing job and returns to template selection
+func (h *UIHandlers) CancelProcessing(c *gin.Context) {
+	if job := h.processing.get(h.getSessionID(c)); job != nil {
+		job.cancel()
+	}
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		state.CurrentStep = 2
+	})
+
+	state := h.getWorkflowState(c)
+	templates, err := h.docService.GetTemplates()
+	if err != nil {
+		log.Printf("[ERROR] Failed to load templates: %v", err)
+		templates = []services.Template{}
+	}
+	data := PageData{
+		CurrentStep:        2,
+		Username:           c.GetString("username"),
+		ICloudConnected:    true,
+		SelectedCaseFolder: state.SelectedCaseFolder,
+		SelectedDocuments:  state.SelectedDocuments,
+		SelectedTemplate:   state.SelectedTemplate,
+		Templates:          templates,
+		Error:              "Document processing was cancelled.",
+	}
+	h.templates.ExecuteTemplate(c.Writer, "_step_wrapper.gohtml", data)
+}
+
+// renderFragment renders a template to a string for an event stream
+func (h *UIHandlers) renderFragment(name string, data interface{}) string {
+	var buffer bytes.Buffer
+	if err := h.templates.ExecuteTemplate(&buffer, name, data); err != nil {
+		log.Printf("Error executing template %s: %v", name, err)
+	}
+	return buffer.String()
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
-	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -21,10 +22,19 @@ type UIHandlers struct {
 	templates         *template.Template
 	icloudService     *services.ICloudService
 	docService        *services.DocumentService
+	caseAnalysis      *services.CaseAnalysisService
 	summonsParser     *services.SummonsParser
 	courtAnalyzer     *services.CourtAnalyzer
 	defendantAnalyzer *services.DefendantAnalyzer
 	serviceValidator  *services.ServiceValidator
+	limitations       *services.LimitationsChecker
+	timeline          *services.TimelineCorrelationEngine
+	formatter         *services.LegalDocumentFormatter
+	caseStore         services.CaseStore
+	matters           *services.MatterStore
+	processing        *processingJobs
+	processingOptions services.ProcessingOptions
+	savedDocsFolder   string
 }
 
 // PageData represents the data passed to templates
@@ -48,8 +58,17 @@ type PageData struct {
 	DocumentFilename     string
 	LastSaved            string
 	ProcessingResult     *services.DocumentProcessingResult
+	Processing           *ProcessingProgress
 	ClientCase           *services.ClientCase
 	SelectedDocuments    []string
+	DocumentProviders    []services.DocumentProvider
+	DocumentProvider     string
+	IsAdmin              bool
+	CanOverrideLimitations bool
+	LimitationsError     string
+	FieldOverrides       []services.FieldOverrideView
+	CanOverrideFields    bool
+	FieldOverrideError   string
 	
 	// Session state for UI restoration
 	SessionState         *services.WorkflowState
@@ -63,6 +82,7 @@ type LegalAnalysis struct {
 	LegalViolations []LegalViolationItem
 	SourceDocs     []string
 	ExtractionDate string
+	Limitations    *services.LimitationsAnalysis
 }
 
 // CauseOfActionItem represents a specific cause of action
@@ -106,6 +126,36 @@ type HighlightedText struct {
 	EndPos     int
 }
 
+// SourceFactView is a source fact prepared for the editor's source panel
+type SourceFactView struct {
+	Fact          services.SourceFact
+	ExcerptBefore string
+	ExcerptMatch  string
+	ExcerptAfter  string
+}
+
+// SourcePageView is a page of a source document with the region a fact came
+// from highlighted. PDF pages are redrawn word by word at their positions;
+// other pages show their text with the fact marked.
+type SourcePageView struct {
+	Citation   string
+	Page       int
+	PageCount  int
+	Positioned bool
+	PageStyle  template.CSS
+	Words      []SourcePageWord
+	Highlights []template.CSS
+	Before     string
+	Match      string
+	After      string
+}
+
+// SourcePageWord is a word drawn at its position on a PDF page
+type SourcePageWord struct {
+	Text  string
+	Style template.CSS
+}
+
 // NewUIHandlers creates a new UI handlers instance
 func NewUIHandlers() *UIHandlers {
 	// Parse all templates
@@ -164,14 +214,27 @@ func NewUIHandlers() *UIHandlers {
 	
 	serviceValidator := services.NewServiceValidator()
 	
+	// Case folders, source documents and saved documents all go through one store
+	caseStore, storageConfig := services.DefaultCaseStore()
+	docService := services.NewDocumentServiceWithStore(caseStore, storageConfig.DocumentsFolder)
+	
 	return &UIHandlers{
 		templates:         tmpl,
-		icloudService:     services.NewICloudService(),
-		docService:        services.NewDocumentService(),
+		icloudService:     services.NewICloudServiceWithStore(caseStore, storageConfig.Sources),
+		docService:        docService,
+		caseAnalysis:      services.NewCaseAnalysisService(docService),
 		summonsParser:     summonsParser,
 		courtAnalyzer:     courtAnalyzer,
 		defendantAnalyzer: defendantAnalyzer,
 		serviceValidator:  serviceValidator,
+		limitations:       services.NewLimitationsChecker(),
+		timeline:          services.NewTimelineCorrelationEngine(),
+		formatter:         services.NewLegalDocumentFormatter(),
+		caseStore:         caseStore,
+		matters:           services.DefaultMatterStore(),
+		processing:        newProcessingJobs(),
+		processingOptions: services.DefaultProcessingOptions(),
+		savedDocsFolder:   storageConfig.SavedDocumentsFolder,
 	}
 }
 
@@ -225,7 +288,11 @@ func (h *UIHandlers) updateWorkflowState(c *gin.Context, updateFunc func(*servic
 
 // ShowMainPage renders the main application page
 func (h *UIHandlers) ShowMainPage(c *gin.Context) {
-	username := c.GetString("username")
+	// The session middleware sets the logged-in user's display name
+	username := c.GetString("displayName")
+	if username == "" {
+		username = c.GetString("username")
+	}
 	if username == "" {
 		username = "User"
 	}
@@ -246,6 +313,7 @@ func (h *UIHandlers) ShowMainPage(c *gin.Context) {
 		SelectedTemplate:     state.SelectedTemplate,
 		SessionState:         state,
 		IsReturningUser:      state.CurrentStep > 0 || isRestored,
+		IsAdmin:              currentUser(c).Can(services.PermissionManageUsers),
 	}
 	
 	err := h.templates.ExecuteTemplate(c.Writer, "index.gohtml", data)
@@ -288,6 +356,11 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 		}
 	}
 	
+	// Later steps work on the selected case, which must be assigned to the user
+	if step > 0 && state.SelectedCaseFolder != "" && !h.authorizeCasePath(c, state.SelectedCaseFolder) {
+		return
+	}
+	
 	// Log session state for debugging
 	log.Printf("[DEBUG] GetStep - Step: %d, Session CurrentStep: %d, HasCaseFolder: %v, HasDocuments: %d, HasTemplate: %v", 
 		step, state.CurrentStep, state.SelectedCaseFolder != "", len(state.SelectedDocuments), state.SelectedTemplate != "")
@@ -322,9 +395,9 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 	case 0:
 		// If iCloud is connected, load root folders automatically
 		if icloudConnected {
-			folders, err := h.icloudService.GetRootFolders("", "")
+			folders, err := h.icloudService.GetRootFolders(state.DocumentProvider, state.ICloudUsername)
 			if err == nil {
-				data.Folders = folders
+				data.Folders = h.filterFolders(c, folders)
 				log.Printf("Loaded %d iCloud folders for connected user", len(folders))
 			} else {
 				log.Printf("[ERROR] Failed to load iCloud folders: %v", err)
@@ -333,9 +406,9 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 		}
 		// If parent folder is selected, load case folders
 		if state.SelectedParentFolder != "" {
-			caseFolders, err := h.icloudService.GetSubfolders("", "", state.SelectedParentFolder)
+			caseFolders, err := h.icloudService.GetSubfolders(state.DocumentProvider, state.ICloudUsername, state.SelectedParentFolder)
 			if err == nil {
-				data.CaseFolders = caseFolders
+				data.CaseFolders = h.filterFolders(c, caseFolders)
 				log.Printf("Loaded %d case folders from session state", len(caseFolders))
 			} else {
 				log.Printf("[ERROR] Failed to load case folders from %s: %v", state.SelectedParentFolder, err)
@@ -350,7 +423,7 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 		
 		// Load documents for step 1 - prioritize from session state
 		if state.SelectedCaseFolder != "" {
-			documents, err := h.icloudService.GetDocuments("", "", state.SelectedCaseFolder)
+			documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, state.SelectedCaseFolder)
 			if err == nil {
 				data.Documents = documents
 				log.Printf("Loaded %d documents from session case folder: %s", len(documents), state.SelectedCaseFolder)
@@ -394,7 +467,7 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 	case 3:
 		// Load available documents for Missing Content analysis early
 		if state.SelectedCaseFolder != "" {
-			documents, err := h.icloudService.GetDocuments("", "", state.SelectedCaseFolder)
+			documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, state.SelectedCaseFolder)
 			if err != nil {
 				log.Printf("[ERROR] Failed to load documents for Missing Content analysis: %v", err)
 			} else {
@@ -418,6 +491,12 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 			break
 		}
 		
+		// Documents still going through the pipeline show their progress instead
+		if progress := h.runningProcessing(c); progress != nil {
+			data.Processing = progress
+			break
+		}
+		
 		// Check if we have processing results in session
 		if state.ProcessingResult == nil || state.ClientCase == nil {
 			log.Printf("[WARNING] Missing processing results in session for step 3")
@@ -425,7 +504,7 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 			// If we have selected documents and template, try to reprocess
 			if len(state.SelectedDocuments) > 0 && state.SelectedTemplate != "" {
 				log.Printf("[INFO] Reprocessing documents for step 3")
-				processingResult, clientCase, err := h.docService.ProcessSelectedDocuments(state.SelectedDocuments, state.SelectedTemplate)
+				processingResult, clientCase, err := h.processSelectedDocuments(c.Request.Context(), state, state.SelectedDocuments, state.SelectedTemplate, h.processingOptions)
 				if err != nil {
 					log.Printf("[ERROR] Failed to reprocess documents: %v", err)
 					data.Error = "Failed to process documents. Please try again."
@@ -438,8 +517,7 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 				
 				// Save the reprocessed results to session
 				h.updateWorkflowState(c, func(state *services.WorkflowState) {
-					state.ProcessingResult = processingResult
-					state.ClientCase = clientCase
+					state.SetProcessingResults(processingResult, clientCase)
 				})
 				
 				data.ProcessingResult = processingResult
@@ -459,9 +537,29 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 			data.ClientCase = state.ClientCase
 		}
 		
-		// Load legal analysis for step 3 using actual extraction results
-		legalAnalysis := h.generateLegalAnalysisFromExtraction(state.ProcessingResult, state.ClientCase, state.SelectedDocuments)
+		// Load legal analysis for step 3 using actual extraction results, as
+		// corrected by the attorney so an overridden date moves the deadlines
+		reviewedCase := state.ClientCase
+		if reviewedCase != nil {
+			reviewedCase = reviewedCase.WithFieldOverrides()
+		}
+		legalAnalysis := h.generateLegalAnalysisFromExtraction(state.MatterID, state.ProcessingResult, reviewedCase, state.SelectedDocuments)
+		
+		// Keep the limitations check on the case so generation leaves out time-barred counts
+		if legalAnalysis.Limitations != nil {
+			h.updateWorkflowState(c, func(state *services.WorkflowState) {
+				if state.ClientCase != nil {
+					state.ClientCase.SetLimitations(*legalAnalysis.Limitations)
+					legalAnalysis.Limitations = state.ClientCase.Limitations
+				}
+			})
+		}
 		data.LegalAnalysis = legalAnalysis
+		data.CanOverrideLimitations = currentUser(c).Can(services.PermissionOverrideLimitations)
+		data.CanOverrideFields = currentUser(c).Can(services.PermissionOverrideFields)
+		if data.ClientCase != nil {
+			data.FieldOverrides = data.ClientCase.FieldOverrideViews()
+		}
 		
 		// Ensure we have selected documents list
 		if len(state.SelectedDocuments) > 0 {
@@ -478,7 +576,8 @@ func (h *UIHandlers) GetStep(c *gin.Context) {
 
 // GetICloudFolders handles HTMX request for iCloud parent folders
 func (h *UIHandlers) GetICloudFolders(c *gin.Context) {
-	folders, err := h.icloudService.GetRootFolders("", "")
+	state := h.getWorkflowState(c)
+	folders, err := h.icloudService.GetRootFolders(state.DocumentProvider, state.ICloudUsername)
 	if err != nil {
 		// Return an HTML fragment indicating error
 		data := PageData{
@@ -490,7 +589,7 @@ func (h *UIHandlers) GetICloudFolders(c *gin.Context) {
 	}
 
 	data := PageData{
-		Folders: folders,
+		Folders: h.filterFolders(c, folders),
 	}
 	h.templates.ExecuteTemplate(c.Writer, "_icloud_folder_list.gohtml", data)
 }
@@ -506,7 +605,13 @@ func (h *UIHandlers) GetCaseFolders(c *gin.Context) {
 		return
 	}
 	
-	caseFolders, err := h.icloudService.GetSubfolders("", "", parentFolder)
+	if !h.caseAccess(c).AllowsBrowsing(parentFolder) {
+		denyAccess(c, currentUser(c), "folder="+parentFolder)
+		return
+	}
+	
+	state := h.getWorkflowState(c)
+	caseFolders, err := h.icloudService.GetSubfolders(state.DocumentProvider, state.ICloudUsername, parentFolder)
 	if err != nil {
 		log.Printf("Error accessing case folders: %v", err)
 		data := PageData{
@@ -518,56 +623,101 @@ func (h *UIHandlers) GetCaseFolders(c *gin.Context) {
 	}
 	
 	data := PageData{
-		CaseFolders:  caseFolders,
+		CaseFolders:  h.filterFolders(c, caseFolders),
 		ParentFolder: parentFolder,
 	}
 	h.templates.ExecuteTemplate(c.Writer, "_case_folder_list.gohtml", data)
 }
 
-// HandleICloudAuth handles iCloud authentication
+// HandleICloudAuth connects the document provider chosen in the setup modal
 func (h *UIHandlers) HandleICloudAuth(c *gin.Context) {
+	provider := c.PostForm("provider")
+	if provider == "" {
+		provider = h.icloudService.DefaultProvider()
+	}
 	username := c.PostForm("username")
 	appPassword := c.PostForm("appPassword")
 	
-	if username == "" || appPassword == "" {
+	requiresLogin := false
+	for _, available := range h.icloudService.Providers() {
+		if available.ID == provider {
+			requiresLogin = available.RequiresLogin
+		}
+	}
+	
+	if requiresLogin && (username == "" || appPassword == "") {
 		// Return error modal
 		data := PageData{
-			Error: "Please enter both username and app password",
+			Error:             "Please enter both username and app password",
+			DocumentProviders: h.icloudService.Providers(),
+			DocumentProvider:  provider,
 		}
 		h.templates.ExecuteTemplate(c.Writer, "_icloud_auth_error.gohtml", data)
 		return
 	}
 	
-	// For prototype: simulate authentication
-	// In production: implement actual iCloud API authentication
-	log.Printf("iCloud auth attempt for user: %s", username)
-	
-	// Store iCloud connection state in session
-	h.updateWorkflowState(c, func(state *services.WorkflowState) {
-		state.ICloudConnected = true
-		state.ICloudUsername = username
-	})
+	if err := h.connectDocumentSource(c, provider, username, appPassword); err != nil {
+		data := PageData{
+			Error:             err.Error(),
+			DocumentProviders: h.icloudService.Providers(),
+			DocumentProvider:  provider,
+		}
+		h.templates.ExecuteTemplate(c.Writer, "_icloud_auth_error.gohtml", data)
+		return
+	}
 	
 	// Return success modal that will trigger a page refresh to Step 0 with connected state
 	data := PageData{
-		Username:        username,
-		ICloudConnected: true,
+		Username:         username,
+		ICloudConnected:  true,
+		DocumentProvider: provider,
 	}
 	
 	// Return success modal that redirects to Step 0 with iCloud connected
 	h.templates.ExecuteTemplate(c.Writer, "_icloud_auth_success.gohtml", data)
 }
 
-// ShowICloudSetup handles the iCloud setup modal
+// connectDocumentSource connects a provider and makes it the session's
+// document source. Folder selections from a different provider no longer apply.
+func (h *UIHandlers) connectDocumentSource(c *gin.Context, provider, username, appPassword string) error {
+	log.Printf("Document source connect attempt: provider=%s user=%s", provider, username)
+	if err := h.icloudService.Connect(provider, username, appPassword); err != nil {
+		log.Printf("[WARNING] Could not connect %s source: %v", provider, err)
+		return err
+	}
+	
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		if state.DocumentProvider != provider {
+			state.SelectedParentFolder = ""
+			state.SelectedCaseFolder = ""
+			state.CaseID = ""
+			state.MatterID = ""
+			state.SelectedDocuments = nil
+		}
+		state.ICloudConnected = true
+		state.ICloudUsername = username
+		state.DocumentProvider = provider
+	})
+	return nil
+}
+
+// ShowICloudSetup handles the document source setup modal
 func (h *UIHandlers) ShowICloudSetup(c *gin.Context) {
 	username := c.GetString("username")
 	if username == "" {
 		username = "User"
 	}
 	
+	provider := h.getWorkflowState(c).DocumentProvider
+	if provider == "" {
+		provider = h.icloudService.DefaultProvider()
+	}
+	
 	data := PageData{
-		Username:        username,
-		ICloudConnected: false, // Would check actual session state
+		Username:          username,
+		ICloudConnected:   false, // Would check actual session state
+		DocumentProviders: h.icloudService.Providers(),
+		DocumentProvider:  provider,
 	}
 	
 	h.templates.ExecuteTemplate(c.Writer, "_icloud_setup_modal.gohtml", data)
@@ -580,6 +730,10 @@ func (h *UIHandlers) SelectParentFolder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder path required"})
 		return
 	}
+	if !h.caseAccess(c).AllowsBrowsing(folderPath) {
+		denyAccess(c, currentUser(c), "folder="+folderPath)
+		return
+	}
 	
 	// Store parent folder in session
 	h.updateWorkflowState(c, func(state *services.WorkflowState) {
@@ -588,21 +742,22 @@ func (h *UIHandlers) SelectParentFolder(c *gin.Context) {
 	
 	log.Printf("Selected parent folder: %s", folderPath)
 	
+	// Get current session state
+	state := h.getWorkflowState(c)
+	
 	// Load case folders for the selected parent
-	caseFolders, err := h.icloudService.GetSubfolders("", "", folderPath)
+	caseFolders, err := h.icloudService.GetSubfolders(state.DocumentProvider, state.ICloudUsername, folderPath)
 	if err != nil {
 		log.Printf("Error loading case folders: %v", err)
 		caseFolders = []services.ICloudDocument{} // Empty slice on error
 	}
+	caseFolders = h.filterFolders(c, caseFolders)
 	
 	username := c.GetString("username")
 	if username == "" {
 		username = "User"
 	}
 	
-	// Get current session state
-	state := h.getWorkflowState(c)
-	
 	data := PageData{
 		CurrentStep:          0,
 		Username:             username,
@@ -623,14 +778,13 @@ func (h *UIHandlers) SelectCaseFolder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Case folder required"})
 		return
 	}
+	if !h.authorizeCasePath(c, caseFolder) {
+		return
+	}
 	
-	// Save to session state
-	h.updateWorkflowState(c, func(state *services.WorkflowState) {
-		state.SelectedCaseFolder = caseFolder
-		state.CurrentStep = 1 // Move to document selection
-	})
-	
-	log.Printf("Selected case folder: %s", caseFolder)
+	caseID := h.selectCaseFolder(c, caseFolder)
+	state := h.getWorkflowState(c)
+	log.Printf("Selected case folder: %s (case %s)", caseFolder, caseID)
 	
 	username := c.GetString("username")
 	if username == "" {
@@ -638,7 +792,7 @@ func (h *UIHandlers) SelectCaseFolder(c *gin.Context) {
 	}
 	
 	// Load documents from the selected case folder - only from iCloud
-	documents, err := h.icloudService.GetDocuments("", "", caseFolder)
+	documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, caseFolder)
 	if err != nil {
 		log.Printf("Error loading documents from case folder %s: %v", caseFolder, err)
 		// Return error without fallback - user must have valid iCloud access
@@ -702,6 +856,50 @@ func (h *UIHandlers) SelectCaseFolder(c *gin.Context) {
 	log.Printf("[DEBUG] Successfully rendered step wrapper template")
 }
 
+// selectCaseFolder makes a case folder the session's case and moves to
+// document selection, returning the folder's case ID
+func (h *UIHandlers) selectCaseFolder(c *gin.Context, caseFolder string) string {
+	state := h.getWorkflowState(c)
+	
+	// Map the folder to its case ID so saved documents stay with the case
+	caseID := ""
+	var matter *services.Matter
+	if caseMetadata, err := h.icloudService.GetCaseForFolder(state.DocumentProvider, caseFolder); err != nil {
+		log.Printf("[WARNING] Could not register case for folder %s: %v", caseFolder, err)
+	} else {
+		caseID = caseMetadata.ID
+		if h.matters != nil {
+			if matter, err = h.matters.EnsureMatter(*caseMetadata, state.Username); err != nil {
+				log.Printf("[WARNING] %v", err)
+			}
+		}
+	}
+	
+	// Save to session state
+	h.updateWorkflowState(c, func(state *services.WorkflowState) {
+		state.SelectedCaseFolder = caseFolder
+		state.CaseID = caseID
+		state.CurrentStep = 1 // Move to document selection
+		
+		// Pick up where the matter was left, whichever session last worked on
+		// it. The matter's case data wins over any copy the session kept.
+		if matter == nil {
+			state.MatterID = ""
+		} else {
+			if state.MatterID != matter.ID {
+				state.SelectedDocuments = matter.SelectedDocuments
+				state.SelectedTemplate = matter.TemplateID
+			}
+			state.MatterID = matter.ID
+			state.MatterRevision = matter.Revision
+			state.ProcessingResult = matter.ProcessingResult
+			state.ClientCase = matter.ClientCase
+			state.ResultsCleared = false
+		}
+	})
+	return caseID
+}
+
 // LoadDocuments handles loading documents from a folder
 func (h *UIHandlers) LoadDocuments(c *gin.Context) {
 	folder := c.Query("folder")
@@ -709,8 +907,12 @@ func (h *UIHandlers) LoadDocuments(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder parameter required"})
 		return
 	}
+	if !h.authorizeCasePath(c, folder) {
+		return
+	}
 	
-	documents, err := h.icloudService.GetDocuments("", "", folder)
+	state := h.getWorkflowState(c)
+	documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, folder)
 	if err != nil {
 		log.Printf("Error loading documents from %s: %v", folder, err)
 		documents = []services.ICloudDocument{} // Empty slice on error
@@ -739,6 +941,12 @@ func (h *UIHandlers) SelectDocuments(c *gin.Context) {
 	
 	log.Printf("Selected documents: %v from folder: %s", selectedDocs, caseFolder)
 	
+	for _, docPath := range selectedDocs {
+		if !h.authorizeCasePath(c, docPath) {
+			return
+		}
+	}
+	
 	if len(selectedDocs) == 0 {
 		// Return error or redirect back to step 1
 		data := PageData{
@@ -792,8 +1000,7 @@ func (h *UIHandlers) SelectTemplate(c *gin.Context) {
 		state.SelectedDocuments = selectedDocs // Save selected documents
 		state.CurrentStep = 3 // Move to review data
 		// Clear any previous processing results when selecting new template
-		state.ProcessingResult = nil
-		state.ClientCase = nil
+		state.ClearProcessingResults()
 	})
 	
 	if selectedTemplate == "" {
@@ -829,8 +1036,9 @@ func (h *UIHandlers) SelectTemplate(c *gin.Context) {
 		return
 	}
 	
-	// Process selected documents using the document service (Task 8 implementation)
-	processingResult, clientCase, err := h.docService.ProcessSelectedDocuments(selectedDocs, selectedTemplate)
+	// Process the selected documents in the background; the progress panel
+	// follows the pipeline and loads Step 3 once every document is through
+	progress, err := h.startProcessing(c, selectedDocs, selectedTemplate)
 	if err != nil {
 		log.Printf("Error processing selected documents: %v", err)
 		data := PageData{
@@ -840,48 +1048,23 @@ func (h *UIHandlers) SelectTemplate(c *gin.Context) {
 		return
 	}
 	
-	// Save processing results to session state
-	h.updateWorkflowState(c, func(state *services.WorkflowState) {
-		state.ProcessingResult = processingResult
-		state.ClientCase = clientCase
-	})
-	
 	username := c.GetString("username")
 	if username == "" {
 		username = "User"
 	}
 	
-	// Generate legal analysis for Step 3 using actual extraction results
-	legalAnalysis := h.generateLegalAnalysisFromExtraction(processingResult, clientCase, selectedDocs)
-	
-	// Load available documents for Missing Content analysis
 	state := h.getWorkflowState(c)
-	var allDocuments []services.ICloudDocument
-	if state.SelectedCaseFolder != "" {
-		documents, err := h.icloudService.GetDocuments("", "", state.SelectedCaseFolder)
-		if err != nil {
-			log.Printf("[ERROR] Failed to load documents for Missing Content analysis in SelectTemplate: %v", err)
-		} else {
-			allDocuments = documents
-			log.Printf("[DEBUG] SelectTemplate: Loaded %d available documents for Missing Content analysis", len(allDocuments))
-		}
-	} else {
-		log.Printf("[WARNING] SelectTemplate: No case folder selected, cannot load documents for Missing Content analysis")
-	}
-	
 	data := PageData{
 		CurrentStep:        3,
 		Username:           username,
 		ICloudConnected:    true,
-		Documents:          allDocuments,       // Add available documents for Missing Content tab
-		LegalAnalysis:      legalAnalysis,
-		ProcessingResult:   processingResult,  // Add dynamic processing result
-		ClientCase:         clientCase,        // Add dynamic client case data
-		SelectedDocuments:  selectedDocs,      // Pass through selected documents
+		SelectedCaseFolder: state.SelectedCaseFolder,
+		SelectedDocuments:  selectedDocs,
+		SelectedTemplate:   selectedTemplate,
+		Processing:         progress,
 	}
 	
-	log.Printf("[DEBUG] SelectTemplate: Setting CurrentStep to %d with %d selected documents, %.1f%% coverage", 
-		data.CurrentStep, len(selectedDocs), processingResult.DataCoverage)
+	log.Printf("[DEBUG] SelectTemplate: Processing %d selected documents in the background", len(selectedDocs))
 	
 	h.templates.ExecuteTemplate(c.Writer, "_step_wrapper.gohtml", data)
 }
@@ -1031,7 +1214,7 @@ func (h *UIHandlers) generateLegalAnalysis(selectedDocs []string) LegalAnalysis
 }
 
 // generateLegalAnalysisFromExtraction creates comprehensive legal analysis using ViolationDetectionEngine
-func (h *UIHandlers) generateLegalAnalysisFromExtraction(processingResult *services.DocumentProcessingResult, clientCase *services.ClientCase, selectedDocs []string) LegalAnalysis {
+func (h *UIHandlers) generateLegalAnalysisFromExtraction(matterID string, processingResult *services.DocumentProcessingResult, clientCase *services.ClientCase, selectedDocs []string) LegalAnalysis {
 	if processingResult == nil || clientCase == nil {
 		log.Printf("[WARNING] Missing processing results, falling back to minimal analysis")
 		return h.generateMinimalLegalAnalysis(selectedDocs)
@@ -1068,12 +1251,24 @@ func (h *UIHandlers) generateLegalAnalysisFromExtraction(processingResult *servi
 	
 	log.Printf("[INFO] Comprehensive violation detection complete: %d violations detected", len(detectedViolations))
 	
+	// Keep the violations with the matter
+	if h.matters != nil && matterID != "" {
+		if err := h.matters.RecordViolations(matterID, detectedViolations); err != nil {
+			log.Printf("[WARNING] %v", err)
+		}
+	}
+	
+	// Apply the statute of limitations to each violation
+	limitations := h.limitations.Check(clientCase, detectedViolations)
+	log.Printf("[INFO] Limitations check complete: %d of %d claims time-barred", limitations.TimeBarredCount, len(limitations.Claims))
+	
 	// Build analysis from detected violations
 	analysis := LegalAnalysis{
 		ExtractionDate: time.Now().Format("January 2, 2006"),
 		SourceDocs:     sourceDocs,
 		CauseOfAction:  []CauseOfActionItem{},
 		LegalViolations: []LegalViolationItem{},
+		Limitations:    &limitations,
 	}
 	
 	// Convert detected violations to legal analysis format
@@ -1108,7 +1303,9 @@ func (h *UIHandlers) generateLegalAnalysisFromExtraction(processingResult *servi
 	
 	if len(detectedViolations) == 0 {
 		log.Printf("[WARNING] No violations detected, generating fallback analysis")
-		return h.generateFallbackAnalysis(processingResult, clientCase, selectedDocs)
+		fallback := h.generateFallbackAnalysis(processingResult, clientCase, selectedDocs)
+		fallback.Limitations = &limitations
+		return fallback
 	}
 	
 	log.Printf("[INFO] Generated comprehensive legal analysis: %d causes of action, %d violations", 
@@ -1388,18 +1585,8 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 	clientName := c.DefaultQuery("client", "Eman Youssef")
 	clientNameLower := strings.ToLower(strings.Replace(clientName, " ", "_", -1))
 	
-	// Base document directory
-	docDir := "/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents"
-	
-	// Ensure directory exists
-	if _, err := os.Stat(docDir); os.IsNotExist(err) {
-		log.Printf("[INFO] Creating saved_documents directory")
-		if err := os.MkdirAll(docDir, 0755); err != nil {
-			log.Printf("[ERROR] Failed to create saved_documents directory: %v", err)
-			c.String(http.StatusInternalServerError, "Could not create document directory")
-			return
-		}
-	}
+	// Saved documents folder in the case store
+	docDir := h.savedDocumentsFolder(c)
 	
 	// Try to find the document in this priority order:
 	// 1. Latest file (complaint_clientname_latest.html)
@@ -1415,13 +1602,13 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 	var documentHTML []byte
 	var err error
 	
-	if _, err := os.Stat(latestPath); err == nil {
+	if _, err := h.caseStore.Stat(latestPath); err == nil {
 		// Latest file exists
 		documentPath = latestPath
 		log.Printf("[INFO] Using latest document: %s", documentPath)
 		
 		// Read the document HTML
-		documentHTML, err = os.ReadFile(documentPath)
+		documentHTML, err = h.caseStore.Read(documentPath)
 		if err != nil {
 			log.Printf("[ERROR] Error reading latest document file: %v", err)
 			// Continue to next option
@@ -1431,12 +1618,12 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 	if documentHTML == nil {
 		// 2. Check for known timestamp file
 		knownTimestampPath := fmt.Sprintf("%s/complaint_%s_20250605_010420.html", docDir, clientNameLower)
-		if _, err := os.Stat(knownTimestampPath); err == nil {
+		if _, err := h.caseStore.Stat(knownTimestampPath); err == nil {
 			documentPath = knownTimestampPath
 			log.Printf("[INFO] Using known timestamp document: %s", documentPath)
 			
 			// Read the document HTML
-			documentHTML, err = os.ReadFile(documentPath)
+			documentHTML, err = h.caseStore.Read(documentPath)
 			if err != nil {
 				log.Printf("[ERROR] Error reading known timestamp document file: %v", err)
 				// Continue to next option
@@ -1447,7 +1634,7 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 	if documentHTML == nil {
 		// 3. Find any matching file
 		pattern := fmt.Sprintf("%s/complaint_%s_*.html", docDir, clientNameLower)
-		matches, err := filepath.Glob(pattern)
+		matches, err := services.GlobCaseStore(h.caseStore, pattern)
 		if err == nil && len(matches) > 0 {
 			// Use the most recent file (assuming timestamp naming)
 			sort.Sort(sort.Reverse(sort.StringSlice(matches)))
@@ -1455,7 +1642,7 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 			log.Printf("[INFO] Using most recent document: %s", documentPath)
 			
 			// Read the document HTML
-			documentHTML, err = os.ReadFile(documentPath)
+			documentHTML, err = h.caseStore.Read(documentPath)
 			if err != nil {
 				log.Printf("[ERROR] Error reading matched document file: %v", err)
 				// Continue to next option
@@ -1548,19 +1735,25 @@ func (h *UIHandlers) ViewDocument(c *gin.Context) {
 </html>`, clientName, legalDocHTML.String())
 		
 		// Write the files
-		err = os.WriteFile(documentPath, []byte(fullHTML), 0644)
+		err = h.caseStore.Write(documentPath, []byte(fullHTML))
 		if err != nil {
 			log.Printf("[ERROR] Error saving new document to %s: %v", documentPath, err)
 			c.String(http.StatusInternalServerError, "Error creating new document: "+err.Error())
 			return
 		}
 		
-		err = os.WriteFile(latestPath, []byte(fullHTML), 0644)
+		err = h.caseStore.Write(latestPath, []byte(fullHTML))
 		if err != nil {
 			log.Printf("[WARNING] Error saving new document to latest path %s: %v", latestPath, err)
 			// Don't fail the request if we can't save to the latest path
 		}
 		
+		// Start the matter's version history with the generated document
+		h.recordDocumentVersion(state, services.GeneratedDocumentVersion{
+			Source:  services.VersionGenerated,
+			Content: legalDocHTML.String(),
+		})
+		
 		// Set document HTML and last saved time
 		documentHTML = []byte(fullHTML)
 		log.Printf("[SUCCESS] Generated and saved new document to %s", documentPath)
@@ -1614,18 +1807,8 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 	clientName := c.DefaultQuery("client", "Eman Youssef")
 	clientNameLower := strings.ToLower(strings.Replace(clientName, " ", "_", -1))
 	
-	// Base document directory
-	docDir := "/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents"
-	
-	// Ensure directory exists
-	if _, err := os.Stat(docDir); os.IsNotExist(err) {
-		log.Printf("[INFO] Creating saved_documents directory")
-		if err := os.MkdirAll(docDir, 0755); err != nil {
-			log.Printf("[ERROR] Failed to create saved_documents directory: %v", err)
-			c.String(http.StatusInternalServerError, "Could not create document directory")
-			return
-		}
-	}
+	// Saved documents folder in the case store
+	docDir := h.savedDocumentsFolder(c)
 	
 	// Try to find the document in this priority order:
 	// 1. Latest file (complaint_clientname_latest.html)
@@ -1643,20 +1826,20 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 	var documentHTML []byte
 	var err error
 	
-	if _, err := os.Stat(latestPath); err == nil {
+	if _, err := h.caseStore.Stat(latestPath); err == nil {
 		// Latest file exists
 		documentPath = latestPath
 		log.Printf("[INFO] Using latest document for editing: %s", documentPath)
 		
 		// Try to get file info for last modified time
-		if fileInfo, err := os.Stat(documentPath); err == nil {
-			lastSavedTime = fileInfo.ModTime().Format("3:04:05 PM")
+		if fileInfo, err := h.caseStore.Stat(documentPath); err == nil {
+			lastSavedTime = fileInfo.Modified.Format("3:04:05 PM")
 		} else {
 			lastSavedTime = time.Now().Format("3:04:05 PM")
 		}
 		
 		// Read the document HTML
-		documentHTML, err = os.ReadFile(documentPath)
+		documentHTML, err = h.caseStore.Read(documentPath)
 		if err != nil {
 			log.Printf("[ERROR] Error reading latest document file: %v", err)
 			// Continue to next option
@@ -1666,7 +1849,7 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 	if documentHTML == nil {
 		// 2. Check for known timestamp file
 		knownTimestampPath := fmt.Sprintf("%s/complaint_%s_20250605_010420.html", docDir, clientNameLower)
-		if _, err := os.Stat(knownTimestampPath); err == nil {
+		if _, err := h.caseStore.Stat(knownTimestampPath); err == nil {
 			documentPath = knownTimestampPath
 			log.Printf("[INFO] Using known timestamp document for editing: %s", documentPath)
 			
@@ -1674,7 +1857,7 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 			lastSavedTime = "10:42 AM" // Hardcoded based on filename
 			
 			// Read the document HTML
-			documentHTML, err = os.ReadFile(documentPath)
+			documentHTML, err = h.caseStore.Read(documentPath)
 			if err != nil {
 				log.Printf("[ERROR] Error reading known timestamp document file: %v", err)
 				// Continue to next option
@@ -1685,7 +1868,7 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 	if documentHTML == nil {
 		// 3. Find any matching file
 		pattern := fmt.Sprintf("%s/complaint_%s_*.html", docDir, clientNameLower)
-		matches, err := filepath.Glob(pattern)
+		matches, err := services.GlobCaseStore(h.caseStore, pattern)
 		if err == nil && len(matches) > 0 {
 			// Use the most recent file (assuming timestamp naming)
 			sort.Sort(sort.Reverse(sort.StringSlice(matches)))
@@ -1707,7 +1890,7 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 			}
 			
 			// Read the document HTML
-			documentHTML, err = os.ReadFile(documentPath)
+			documentHTML, err = h.caseStore.Read(documentPath)
 			if err != nil {
 				log.Printf("[ERROR] Error reading matched document file: %v", err)
 				// Continue to next option
@@ -1800,19 +1983,25 @@ func (h *UIHandlers) EditDocument(c *gin.Context) {
 </html>`, clientName, legalDocHTML.String())
 		
 		// Write the files
-		err = os.WriteFile(documentPath, []byte(fullHTML), 0644)
+		err = h.caseStore.Write(documentPath, []byte(fullHTML))
 		if err != nil {
 			log.Printf("[ERROR] Error saving new document to %s: %v", documentPath, err)
 			c.String(http.StatusInternalServerError, "Error creating new document: "+err.Error())
 			return
 		}
 		
-		err = os.WriteFile(latestPath, []byte(fullHTML), 0644)
+		err = h.caseStore.Write(latestPath, []byte(fullHTML))
 		if err != nil {
 			log.Printf("[WARNING] Error saving new document to latest path %s: %v", latestPath, err)
 			// Don't fail the request if we can't save to the latest path
 		}
 		
+		// Start the matter's version history with the generated document
+		h.recordDocumentVersion(state, services.GeneratedDocumentVersion{
+			Source:  services.VersionGenerated,
+			Content: legalDocHTML.String(),
+		})
+		
 		// Set document HTML and last saved time
 		documentHTML = []byte(legalDocHTML.String())
 		lastSavedTime = "Just Now"
@@ -1902,29 +2091,49 @@ func (h *UIHandlers) SaveDocument(c *gin.Context) {
 		log.Printf("[WARNING] Document type not provided, using default: %s", req.DocumentType)
 	}
 	
+	documentPath, latestPath, err := h.writeSavedDocument(c, req.DocumentType, req.ClientName, req.Content)
+	if err != nil {
+		log.Printf("[ERROR] Error saving document to %s: %v", documentPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error saving document: " + err.Error()})
+		return
+	}
+	
+	// Keep the edit in the matter's version history
+	h.recordDocumentVersion(h.getWorkflowState(c), services.GeneratedDocumentVersion{
+		DocumentType: req.DocumentType,
+		Source:       services.VersionManual,
+		Content:      req.Content,
+	})
+	
+	log.Printf("[SUCCESS] Document successfully saved to %s", documentPath)
+	
+	// Return success response with path
+	c.JSON(http.StatusOK, gin.H{
+		"success": true, 
+		"path": documentPath,
+		"latest_path": latestPath,
+		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
+	})
+}
+
+// writeSavedDocument writes an edited document to the case store as a
+// timestamped file and as the document's latest copy
+func (h *UIHandlers) writeSavedDocument(c *gin.Context, documentType, clientName, content string) (string, string, error) {
 	// Format client name for filename
-	clientNameLower := strings.ToLower(strings.Replace(req.ClientName, " ", "_", -1))
+	clientNameLower := strings.ToLower(strings.Replace(clientName, " ", "_", -1))
 	
 	// Create timestamp for filename
 	timestamp := time.Now().Format("20060102_150405")
 	
-	// Ensure saved_documents directory exists
-	saveDir := "/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents"
-	if _, err := os.Stat(saveDir); os.IsNotExist(err) {
-		log.Printf("[INFO] Creating saved_documents directory")
-		if err := os.MkdirAll(saveDir, 0755); err != nil {
-			log.Printf("[ERROR] Failed to create saved_documents directory: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Could not create save directory"})
-			return
-		}
-	}
+	// Saved documents folder in the case store; written files create it as needed
+	saveDir := h.savedDocumentsFolder(c)
 	
 	// Create path for new document
-	documentPath := fmt.Sprintf("%s/%s_%s_%s.html", saveDir, req.DocumentType, clientNameLower, timestamp)
+	documentPath := fmt.Sprintf("%s/%s_%s_%s.html", saveDir, documentType, clientNameLower, timestamp)
 	log.Printf("[INFO] Saving document to: %s", documentPath)
 	
 	// Also save to a predictable path for easy lookup
-	latestPath := fmt.Sprintf("%s/%s_%s_latest.html", saveDir, req.DocumentType, clientNameLower)
+	latestPath := fmt.Sprintf("%s/%s_%s_latest.html", saveDir, documentType, clientNameLower)
 	
 	// Create HTML document structure
 	fullHTML := fmt.Sprintf(`<!DOCTYPE html>
@@ -1977,34 +2186,282 @@ func (h *UIHandlers) SaveDocument(c *gin.Context) {
 		%s
 	</div>
 </body>
-</html>`, req.ClientName, req.Content)
+</html>`, clientName, content)
 	
 	// Write the file with timestamp
-	err := os.WriteFile(documentPath, []byte(fullHTML), 0644)
-	if err != nil {
-		log.Printf("[ERROR] Error saving document to %s: %v", documentPath, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error saving document: " + err.Error()})
-		return
+	if err := h.caseStore.Write(documentPath, []byte(fullHTML)); err != nil {
+		return documentPath, latestPath, err
 	}
 	
 	// Also write to the latest path for easy access
-	err = os.WriteFile(latestPath, []byte(fullHTML), 0644)
-	if err != nil {
+	if err := h.caseStore.Write(latestPath, []byte(fullHTML)); err != nil {
 		log.Printf("[WARNING] Error saving document to latest path %s: %v", latestPath, err)
 		// Don't fail the request if we can't save to the latest path
 	}
+	return documentPath, latestPath, nil
+}
+
+// DownloadDocument generates the complaint for the current session and serves it as a file
+func (h *UIHandlers) DownloadDocument(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "pdf"))
 	
-	log.Printf("[SUCCESS] Document successfully saved to %s", documentPath)
+	state := h.getWorkflowState(c)
+	if state.ClientCase == nil || state.SelectedTemplate == "" {
+		log.Printf("[ERROR] Download requested without processed case data in session")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No case data available. Please complete document review first."})
+		return
+	}
 	
-	// Return success response with path
-	c.JSON(http.StatusOK, gin.H{
-		"success": true, 
-		"path": documentPath,
-		"latest_path": latestPath,
-		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
+	document, err := h.docService.GenerateComplaint(state.SelectedTemplate, state.ClientCase)
+	if err != nil {
+		log.Printf("[ERROR] Error generating complaint for download: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate document: " + err.Error()})
+		return
+	}
+	
+	// Every downloaded complaint is kept as a version of the matter's document
+	h.recordDocumentVersion(state, services.GeneratedDocumentVersion{
+		Source:          services.VersionGenerated,
+		TemplateID:      document.Metadata.TemplateID,
+		TemplateVersion: document.Metadata.TemplateVersion,
+		Content:         services.DocumentBodyHTML(h.formatter.FormatAsHTML(document.Content)),
+		Document:        document,
+	})
+	
+	clientName := state.ClientCase.ClientName
+	if clientName == "" {
+		clientName = "client"
+	}
+	clientNameLower := strings.ToLower(strings.Replace(clientName, " ", "_", -1))
+	timestamp := time.Now().Format("20060102_150405")
+	
+	var data []byte
+	var contentType string
+	
+	switch format {
+	case "pdf":
+		data, err = h.formatter.FormatDocumentAsPDF(document)
+		contentType = "application/pdf"
+	case "docx":
+		data, err = h.formatter.FormatDocumentAsDOCX(document)
+		contentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case "html":
+		data = []byte(h.formatter.FormatAsHTML(document.Content))
+		contentType = "text/html; charset=utf-8"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format: " + format})
+		return
+	}
+	
+	if err != nil {
+		log.Printf("[ERROR] Error formatting complaint as %s: %v", format, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to format document: " + err.Error()})
+		return
+	}
+	
+	filename := fmt.Sprintf("complaint_%s_%s.%s", clientNameLower, timestamp, format)
+	log.Printf("[INFO] Serving %s download: %s (%d bytes)", format, filename, len(data))
+	
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// SourceFacts shows where the facts in a clicked sentence of the complaint came from
+func (h *UIHandlers) SourceFacts(c *gin.Context) {
+	sentence := strings.TrimSpace(c.Query("sentence"))
+	
+	state := h.getWorkflowState(c)
+	if state.ClientCase == nil || state.SelectedTemplate == "" {
+		h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{
+			"Error": "No case data available. Please complete document review first.",
+		})
+		return
+	}
+	
+	document, err := h.docService.GenerateComplaint(state.SelectedTemplate, state.ClientCase)
+	if err != nil {
+		log.Printf("[ERROR] Error generating complaint for source lookup: %v", err)
+		h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{
+			"Error": "Failed to generate document: " + err.Error(),
+		})
+		return
+	}
+	
+	var views []SourceFactView
+	for _, fact := range document.FactsForSentence(sentence) {
+		view := SourceFactView{Fact: fact}
+		if fact.Provenance != nil {
+			view.ExcerptBefore, view.ExcerptMatch, view.ExcerptAfter = splitExcerpt(fact.Provenance.Excerpt, fact.Provenance.Value)
+		}
+		views = append(views, view)
+	}
+	
+	log.Printf("[INFO] Source lookup matched %d facts", len(views))
+	
+	h.templates.ExecuteTemplate(c.Writer, "_source_facts.gohtml", gin.H{
+		"Sentence": sentence,
+		"Facts":    views,
+	})
+}
+
+// splitExcerpt splits an excerpt around the first case-insensitive occurrence of value
+func splitExcerpt(excerpt, value string) (string, string, string) {
+	index := strings.Index(strings.ToLower(excerpt), strings.ToLower(value))
+	if value == "" || index < 0 {
+		return excerpt, "", ""
+	}
+	return excerpt[:index], excerpt[index : index+len(value)], excerpt[index+len(value):]
+}
+
+// SourcePage shows the page of a selected document that the text between
+// start and end was extracted from, with that text highlighted
+func (h *UIHandlers) SourcePage(c *gin.Context) {
+	docPath := c.Query("document")
+	start, startErr := strconv.Atoi(c.Query("start"))
+	end, endErr := strconv.Atoi(c.Query("end"))
+	if docPath == "" || startErr != nil || endErr != nil || start < 0 || end <= start {
+		h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{
+			"Error": "A document and the span of text to show are required.",
+		})
+		return
+	}
+	
+	state := h.getWorkflowState(c)
+	selected := false
+	for _, selectedDoc := range state.SelectedDocuments {
+		if selectedDoc == docPath {
+			selected = true
+		}
+	}
+	if !selected {
+		h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{
+			"Error": "That document is not one of this case's selected documents.",
+		})
+		return
+	}
+	
+	source, err := h.icloudService.Source(state.DocumentProvider, state.ICloudUsername)
+	if err == nil {
+		var content *services.ExtractedContent
+		if content, err = h.docService.ExtractDocument(c.Request.Context(), source, docPath); err == nil {
+			h.templates.ExecuteTemplate(c.Writer, "_source_page.gohtml", newSourcePageView(docPath, content, start, end))
+			return
+		}
+	}
+	log.Printf("[ERROR] Error reading %s for source page: %v", docPath, err)
+	h.templates.ExecuteTemplate(c.Writer, "_error_fragment.gohtml", gin.H{
+		"Error": "Failed to read the source document: " + err.Error(),
 	})
 }
 
+// newSourcePageView lays out the page holding the span between start and end
+func newSourcePageView(docPath string, content *services.ExtractedContent, start, end int) SourcePageView {
+	number := content.PageAt(start)
+	view := SourcePageView{
+		Citation:  services.SourceCitation(docPath, number),
+		Page:      number,
+		PageCount: content.PageCount,
+	}
+	
+	page := content.Page(number)
+	if page == nil {
+		// Content extracted without pages: show just the text itself
+		if end <= len(content.RawText) {
+			view.Match = content.RawText[start:end]
+		}
+		return view
+	}
+	
+	if page.Width > 0 && page.Height > 0 && len(page.Spans) > 0 {
+		view.Positioned = true
+		view.PageStyle = template.CSS(fmt.Sprintf("padding-top: %.2f%%", page.Height/page.Width*100))
+		for _, span := range page.Spans {
+			if span.Box == nil || span.End > len(content.RawText) {
+				continue
+			}
+			view.Words = append(view.Words, SourcePageWord{Text: content.RawText[span.Start:span.End], Style: boxStyle(*span.Box)})
+		}
+		for _, box := range content.Boxes(start, end) {
+			if box.Page == number {
+				view.Highlights = append(view.Highlights, boxStyle(box))
+			}
+		}
+		return view
+	}
+	
+	text := content.PageText(number)
+	from, to := start-page.Start, end-page.Start
+	if from < 0 || to > len(text) {
+		view.Before = text
+		return view
+	}
+	view.Before, view.Match, view.After = text[:from], text[from:to], text[to:]
+	return view
+}
+
+// boxStyle positions an element over a page at a box's place
+func boxStyle(box services.SourceBox) template.CSS {
+	return template.CSS(fmt.Sprintf("left: %.2f%%; top: %.2f%%; width: %.2f%%; height: %.2f%%",
+		box.X*100, box.Y*100, box.Width*100, box.Height*100))
+}
+
+// ListTemplates returns the loaded complaint templates as the array the
+// legacy frontend expects; template file errors are at ListTemplateErrors
+func (h *UIHandlers) ListTemplates(c *gin.Context) {
+	templates, err := h.docService.GetTemplates()
+	if err != nil {
+		log.Printf("[ERROR] Failed to load templates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load templates: " + err.Error()})
+		return
+	}
+	
+	if templates == nil {
+		templates = []services.Template{}
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// ListTemplateErrors returns the schema problems of template files that were skipped
+func (h *UIHandlers) ListTemplateErrors(c *gin.Context) {
+	loadErrors := h.docService.GetTemplateLoadErrors()
+	if loadErrors == nil {
+		loadErrors = []services.TemplateLoadError{}
+	}
+	c.JSON(http.StatusOK, loadErrors)
+}
+
+// savedDocumentsFolder returns the case store folder for saved documents,
+// kept separate per case once a case folder has been selected
+func (h *UIHandlers) savedDocumentsFolder(c *gin.Context) string {
+	state := h.getWorkflowState(c)
+	if state.CaseID != "" {
+		return path.Join(h.savedDocsFolder, state.CaseID)
+	}
+	return h.savedDocsFolder
+}
+
+// processSelectedDocuments runs the extraction pipeline against the session's document source
+func (h *UIHandlers) processSelectedDocuments(ctx context.Context, state *services.WorkflowState, selectedDocs []string, templateID string, options services.ProcessingOptions) (*services.DocumentProcessingResult, *services.ClientCase, error) {
+	source, err := h.icloudService.Source(state.DocumentProvider, state.ICloudUsername)
+	if err != nil {
+		return nil, nil, fmt.Errorf("document source not available: %v", err)
+	}
+	processingResult, clientCase, err := h.docService.ProcessSelectedDocumentsContext(ctx, source, selectedDocs, templateID, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	// Keep the results with the matter so they outlive the session
+	if h.matters != nil && state.MatterID != "" {
+		if record, err := h.matters.RecordExtraction(state.MatterID, templateID, state.Username, processingResult, clientCase); err != nil {
+			log.Printf("[WARNING] %v", err)
+		} else {
+			log.Printf("[INFO] Recorded extraction %s for matter %s", record.ID, state.MatterID)
+		}
+	}
+	return processingResult, clientCase, nil
+}
+
 // Helper function to load documents for step 1
 func (h *UIHandlers) loadDocumentsForStep1(c *gin.Context) ([]services.ICloudDocument, error) {
 	// Get session state to check for selected case folder
@@ -2016,7 +2473,7 @@ func (h *UIHandlers) loadDocumentsForStep1(c *gin.Context) ([]services.ICloudDoc
 	}
 	
 	// Load documents only from iCloud - no test folder or backend fallback
-	documents, err := h.icloudService.GetDocuments("", "", state.SelectedCaseFolder)
+	documents, err := h.icloudService.GetDocuments(state.DocumentProvider, state.ICloudUsername, state.SelectedCaseFolder)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load documents from iCloud case folder %s: %v", state.SelectedCaseFolder, err)
 	}
@@ -2031,6 +2488,9 @@ func (h *UIHandlers) AnalyzeSummons(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Document path required"})
 		return
 	}
+	if !h.authorizeCasePath(c, documentPath) {
+		return
+	}
 
 	// Check if all required services are available
 	if h.summonsParser == nil {
@@ -2107,6 +2567,11 @@ func (h *UIHandlers) AnalyzeMultipleDefendants(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one document path required"})
 		return
 	}
+	for _, docPath := range req.DocumentPaths {
+		if !h.authorizeCasePath(c, docPath) {
+			return
+		}
+	}
 
 	// Check if required services are available
 	if h.summonsParser == nil || h.defendantAnalyzer == nil {
@@ -23,39 +23,30 @@ func main() {
 	
 	// Ensure graceful shutdown of session service
 	defer sessionService.Shutdown()
+	
+	// Case data of sessions that point at a matter lives in the matter database
+	matterStore := services.DefaultMatterStore()
+	if matterStore != nil {
+		sessionService.SetMatterStore(matterStore)
+		defer matterStore.Close()
+	}
+
+	// Initialize user service
+	userService, err := services.NewUserService("config")
+	if err != nil {
+		log.Printf("Failed to initialize user service: %v", err)
+		log.Printf("Logins will be refused until config/users.json can be loaded")
+		userService = nil
+	}
+	authHandlers := handlers.NewAuthHandlers(userService, sessionService, int((24 * time.Hour).Seconds()))
 
 	// Session restoration middleware
-	router.Use(func(c *gin.Context) {
-		sessionToken, err := c.Cookie("session_token")
-		if err != nil || sessionToken == "" {
-			// Generate a temporary session ID for unauthenticated users
-			sessionToken = "temp_session"
-		}
-		
-		// Validate session token header if present
-		headerToken := c.GetHeader("X-Session-Token")
-		if headerToken != "" && headerToken != sessionToken {
-			log.Printf("[WARNING] Session token mismatch: cookie=%s, header=%s", sessionToken, headerToken)
-		}
-		
-		// Restore session data from persistent storage
-		sessionData := sessionService.RestoreSession(sessionToken)
-		if sessionData != nil {
-			c.Set("restoredSession", true)
-			c.Set("workflowState", sessionData.WorkflowState)
-			log.Printf("[INFO] Session restored for %s, step %d", sessionToken, sessionData.WorkflowState.CurrentStep)
-		}
-		
-		// Add session service and session ID to context
-		c.Set("sessionService", sessionService)
-		c.Set("sessionID", sessionToken)
-		c.Next()
-	})
+	router.Use(authHandlers.SessionMiddleware())
 
 	// Setup CORS
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -69,6 +60,7 @@ func main() {
 	
 	// Create handlers
 	uiHandlers := handlers.NewUIHandlers()
+	adminHandlers := handlers.NewAdminHandlers(userService, sessionService)
 	
 	// Serve login page for unauthenticated users
 	router.GET("/login", func(c *gin.Context) {
@@ -76,165 +68,147 @@ func main() {
 	})
 	
 	// Serve main application for authenticated users
-	router.GET("/", func(c *gin.Context) {
-		// Check if user is authenticated via cookie
-		sessionToken, err := c.Cookie("session_token")
-		if err != nil || sessionToken == "" {
-			// No valid session cookie, redirect to login
-			c.Redirect(http.StatusFound, "/login")
-			return
-		}
-		
-		// Set username in context (would come from session validation)
-		c.Set("username", "Kevin Mallon")
-		
-		// Serve main application with Go SSR + HTMX
-		uiHandlers.ShowMainPage(c)
-	})
+	router.GET("/", authHandlers.RequireLogin(), uiHandlers.ShowMainPage)
+	
+	// User administration, restricted to roles with users:manage
+	manageUsers := authHandlers.RequirePermission(services.PermissionManageUsers)
+	router.GET("/admin/users", authHandlers.RequireLogin(), manageUsers, adminHandlers.ShowUsersPage)
+
+	// HTMX UI endpoints for partial page updates; each route names the
+	// permission it needs from the role policy table
+	browse := authHandlers.RequirePermission(services.PermissionBrowseCases)
+	selectDocs := authHandlers.RequirePermission(services.PermissionSelectDocuments)
+	review := authHandlers.RequirePermission(services.PermissionReviewData)
+	generate := authHandlers.RequirePermission(services.PermissionGenerateDocuments)
+	edit := authHandlers.RequirePermission(services.PermissionEditDocuments)
+	overrideLimitations := authHandlers.RequirePermission(services.PermissionOverrideLimitations)
+	overrideFields := authHandlers.RequirePermission(services.PermissionOverrideFields)
+	selectedCase := uiHandlers.RequireSelectedCaseAccess()
 
-	// HTMX UI endpoints for partial page updates
 	ui := router.Group("/ui")
 	{
 		// Step navigation
-		ui.GET("/step/:step", uiHandlers.GetStep)
+		ui.GET("/step/:step", authHandlers.RequireStepPermission(), uiHandlers.GetStep)
 		
 		// iCloud folder operations
-		ui.GET("/icloud-folders", uiHandlers.GetICloudFolders)
-		ui.GET("/case-folders", uiHandlers.GetCaseFolders)
-		ui.POST("/select-parent-folder", uiHandlers.SelectParentFolder)
-		ui.POST("/select-case-folder", uiHandlers.SelectCaseFolder)
+		ui.GET("/icloud-folders", browse, uiHandlers.GetICloudFolders)
+		ui.GET("/case-folders", browse, uiHandlers.GetCaseFolders)
+		ui.POST("/select-parent-folder", browse, uiHandlers.SelectParentFolder)
+		ui.POST("/select-case-folder", browse, uiHandlers.SelectCaseFolder)
 		
 		// iCloud setup modal
-		ui.GET("/icloud-setup", uiHandlers.ShowICloudSetup)
-		ui.POST("/icloud-auth", uiHandlers.HandleICloudAuth)
+		ui.GET("/icloud-setup", browse, uiHandlers.ShowICloudSetup)
+		ui.POST("/icloud-auth", browse, uiHandlers.HandleICloudAuth)
 		
 		// Document operations
-		ui.GET("/load-documents", uiHandlers.LoadDocuments)
-		ui.POST("/select-documents", uiHandlers.SelectDocuments)
-		ui.POST("/select-template", uiHandlers.SelectTemplate)
+		ui.GET("/load-documents", selectDocs, uiHandlers.LoadDocuments)
+		ui.POST("/select-documents", selectDocs, selectedCase, uiHandlers.SelectDocuments)
+		ui.GET("/document-type", selectDocs, uiHandlers.DocumentType)
+		ui.POST("/document-type", selectDocs, uiHandlers.LabelDocumentType)
+		ui.GET("/classifier-metrics", selectDocs, uiHandlers.ClassifierMetrics)
+		ui.POST("/select-template", review, selectedCase, uiHandlers.SelectTemplate)
+		ui.GET("/processing/events", review, uiHandlers.ProcessingEvents)
+		ui.POST("/processing/cancel", review, uiHandlers.CancelProcessing)
 		
 		// Document preview
-		ui.GET("/preview-document", uiHandlers.PreviewDocument)
+		ui.GET("/preview-document", review, selectedCase, uiHandlers.PreviewDocument)
 		
 		// Document viewer/editor
-		ui.GET("/view-document", uiHandlers.ViewDocument)
-		ui.GET("/edit-document", uiHandlers.EditDocument)
-		ui.POST("/save-document", uiHandlers.SaveDocument)
+		ui.GET("/view-document", generate, selectedCase, uiHandlers.ViewDocument)
+		ui.GET("/edit-document", edit, selectedCase, uiHandlers.EditDocument)
+		ui.POST("/save-document", edit, selectedCase, uiHandlers.SaveDocument)
+		ui.GET("/download-document", generate, selectedCase, uiHandlers.DownloadDocument)
+		ui.GET("/source-facts", review, selectedCase, uiHandlers.SourceFacts)
+		ui.GET("/source-page", review, selectedCase, uiHandlers.SourcePage)
+		
+		// Version history of the generated complaint
+		ui.GET("/document-versions", generate, selectedCase, uiHandlers.DocumentVersions)
+		ui.GET("/document-versions/diff", generate, selectedCase, uiHandlers.DocumentVersionDiff)
+		ui.POST("/document-versions/:version/restore", edit, selectedCase, uiHandlers.RestoreDocumentVersion)
+		
+		// Case deadlines as an iCalendar file
+		ui.GET("/case-calendar.ics", review, selectedCase, uiHandlers.ExportCaseCalendar)
+		
+		// Statute of limitations overrides
+		ui.POST("/limitations/override", overrideLimitations, selectedCase, uiHandlers.OverrideLimitations)
+		
+		// Attorney corrections of extracted case data
+		ui.POST("/field-overrides", overrideFields, selectedCase, uiHandlers.OverrideField)
 		
 		// Summons analysis endpoints
-		ui.GET("/analyze-summons", uiHandlers.AnalyzeSummons)
-		ui.POST("/analyze-multiple-defendants", uiHandlers.AnalyzeMultipleDefendants)
-	}
-
-	// Initialize user service
-	userService, err := services.NewUserService("config")
-	if err != nil {
-		log.Printf("Failed to initialize user service: %v", err)
-		log.Printf("Falling back to hardcoded credentials")
-		userService = nil
+		ui.GET("/analyze-summons", review, uiHandlers.AnalyzeSummons)
+		ui.POST("/analyze-multiple-defendants", review, uiHandlers.AnalyzeMultipleDefendants)
+		
+		// User administration forms
+		ui.POST("/admin/users", manageUsers, adminHandlers.CreateUserForm)
+		ui.POST("/admin/users/:username", manageUsers, adminHandlers.UpdateUserForm)
+		ui.POST("/admin/users/:username/active", manageUsers, adminHandlers.SetActiveForm)
+		ui.POST("/admin/users/:username/password", manageUsers, adminHandlers.ResetPasswordForm)
 	}
 
 	// Legacy API endpoints (keep for backward compatibility during transition)
 	api := router.Group("/api")
 	{
-		// Authentication endpoints (simplified)
-		api.POST("/login", func(c *gin.Context) {
-			var loginRequest struct {
-				Username string `json:"username"`
-				Password string `json:"password"`
-			}
-			
-			if err := c.BindJSON(&loginRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request format"})
-				return
-			}
-			
-			var isValid bool
-			var user *services.User
-			
-			// Try user service first, fallback to hardcoded
-			if userService != nil {
-				user, isValid = userService.ValidateUser(loginRequest.Username, loginRequest.Password)
-			} else {
-				// Fallback to hardcoded credentials
-				isValid = (loginRequest.Username == "admin" || loginRequest.Username == "kmallon" || loginRequest.Username == "demo") && 
-						 loginRequest.Password == "password"
-			}
-			
-			if isValid {
-				// Set session cookie
-				c.SetCookie("session_token", "demo_session_token", 3600, "/", "", false, true)
-				
-				// Determine display name and role
-				displayName := "Kevin Mallon"
-				role := "Attorney"
-				
-				if user != nil {
-					// Use data from user service
-					displayName = user.Username
-					role = user.Role
-					log.Printf("[LOGIN] User %s logged in with role: %s", user.Username, user.Role)
-				} else {
-					log.Printf("[LOGIN] User %s logged in (fallback mode)", loginRequest.Username)
-				}
-				
-				// Return success with user info
-				c.JSON(http.StatusOK, gin.H{
-					"success": true,
-					"user": gin.H{
-						"username": loginRequest.Username,
-						"displayName": displayName,
-						"role": role,
-					},
-					"sessionToken": "demo_session_token",
-				})
-			} else {
-				log.Printf("[LOGIN] Invalid login attempt for user: %s", loginRequest.Username)
-				c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid credentials"})
-			}
-		})
-		
-		api.GET("/validate-session", func(c *gin.Context) {
-			// Get session token from Authorization header or cookie
-			authHeader := c.GetHeader("Authorization")
-			sessionCookie, _ := c.Cookie("session_token")
-			
-			// Validate session token (simplified for demo)
-			if authHeader != "" || sessionCookie != "" {
-			// In production, verify the token with proper session management
-			c.JSON(http.StatusOK, gin.H{"valid": true})
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"valid": false})
-			}
-		})
-		
-		api.POST("/logout", func(c *gin.Context) {
-			c.SetCookie("session_token", "", -1, "/", "", false, true)
-			c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out"})
-		})
+		// Authentication endpoints
+		api.POST("/login", authHandlers.Login)
+		api.GET("/validate-session", authHandlers.ValidateSession)
+		api.POST("/logout", authHandlers.Logout)
 		
 		// Legacy document endpoints (for fallback)
 		api.GET("/documents", func(c *gin.Context) {
 			c.JSON(http.StatusOK, []gin.H{}) // Return empty for now
 		})
 		
-		// Legacy templates endpoint
-		api.GET("/templates", func(c *gin.Context) {
-			c.JSON(http.StatusOK, []gin.H{
-				{
-					"id":   "fcra-credit-card-fraud",
-					"name": "FCRA Complaint - Credit Card Fraud",
-					"desc": "For cases involving fraudulent credit card transactions",
-				},
-			})
+		// Templates loaded from config/complaint_templates
+		api.GET("/templates", uiHandlers.ListTemplates)
+		api.GET("/templates/errors", uiHandlers.ListTemplateErrors)
+		
+		// Cross-document analysis of a case as a versioned JSON report
+		api.GET("/cases/:id/analysis", review, uiHandlers.CaseAnalysis)
+		
+		// JSON API mirroring the wizard steps; see docs/openapi.yaml
+		v1 := api.Group("/v1")
+		v1.GET("/openapi.yaml", func(c *gin.Context) {
+			c.File("docs/openapi.yaml")
 		})
+		v1.GET("/workflow", browse, uiHandlers.APIGetWorkflow)
+		v1.GET("/providers", browse, uiHandlers.APIListProviders)
+		v1.POST("/source", browse, uiHandlers.APIConnectSource)
+		v1.GET("/folders", browse, uiHandlers.APIListFolders)
+		v1.POST("/case", browse, uiHandlers.APISelectCase)
+		v1.GET("/documents", selectDocs, uiHandlers.APIListDocuments)
+		v1.PUT("/selection", selectDocs, selectedCase, uiHandlers.APISelectDocuments)
+		v1.GET("/document-type", selectDocs, uiHandlers.APIDocumentType)
+		v1.PUT("/document-type", selectDocs, uiHandlers.APILabelDocumentType)
+		v1.GET("/classifier-metrics", selectDocs, uiHandlers.APIClassifierMetrics)
+		v1.GET("/templates", review, uiHandlers.APIListTemplates)
+		v1.POST("/process", review, selectedCase, uiHandlers.APIProcess)
+		v1.GET("/case-data", review, selectedCase, uiHandlers.APIGetCaseData)
+		v1.PUT("/case-data/overrides", overrideFields, selectedCase, uiHandlers.APIOverrideField)
+		v1.DELETE("/case-data/overrides/:field", overrideFields, selectedCase, uiHandlers.APIClearFieldOverride)
+		v1.POST("/generate", generate, selectedCase, uiHandlers.APIGenerate)
+		v1.POST("/save", edit, selectedCase, uiHandlers.APISaveDocument)
+		v1.GET("/cases/:id/analysis", review, uiHandlers.CaseAnalysis)
+		
+		// User administration
+		adminUsers := api.Group("/admin/users", manageUsers)
+		adminUsers.GET("", adminHandlers.ListUsers)
+		adminUsers.POST("", adminHandlers.CreateUser)
+		adminUsers.GET("/:username", adminHandlers.GetUser)
+		adminUsers.PATCH("/:username", adminHandlers.UpdateUser)
+		adminUsers.DELETE("/:username", adminHandlers.DeactivateUser)
+		adminUsers.POST("/:username/password", adminHandlers.ResetPassword)
 	}
 
 	// Start the server
 	log.Println("[INFO] Starting Satori Legal Assistant Agent v2.15.0 on :8080")
 	log.Printf("[INFO] Features: Civil Cover Sheet Legal Mapping (Task 13), Attorney Notes Intelligence (Task 12), Summons Document Analysis Engine (Task 11), Dynamic Template Population Engine (Task 4), Persistent Sessions")
-	log.Printf("[INFO] Templates directory: /Users/corelogic/satori-dev/clients/proj-mallon/v2/templates")
-	log.Printf("[INFO] Test iCloud directory: /Users/corelogic/satori-dev/clients/proj-mallon/test_icloud")
+	caseStore, _ := services.DefaultCaseStore()
+	log.Printf("[INFO] Templates directory: ./templates")
+	log.Printf("[INFO] Case store: %s (tenant %s)", caseStore.Backend(), caseStore.Tenant())
+	if matterStore != nil {
+		log.Printf("[INFO] Matter database: %s", matterStore.Path())
+	}
 	log.Printf("[INFO] Session directory: %s", sessionDir)
 	log.Printf("[INFO] Session TTL: 24 hours with persistent file-based storage")
 	router.Run(":8080")
@@ -4,10 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // AttorneyNotesAnalyzer provides comprehensive analysis of attorney notes documents
@@ -18,6 +23,9 @@ type AttorneyNotesAnalyzer struct {
 	EvidencePatterns      []EvidencePattern
 	StrategyPatterns      []StrategyPattern
 	DamagePatterns        []DamagePattern
+	
+	// compiled holds the compiled form of every loaded pattern
+	compiled map[string]*regexp.Regexp
 }
 
 // AttorneyNotesAnalysis contains comprehensive analysis of attorney legal notes
@@ -523,9 +531,6 @@ func (ana *AttorneyNotesAnalyzer) extractLegalAnalysis(content string) AttorneyL
 	return analysis
 }
 
-// Additional analysis methods would continue here...
-// For brevity, implementing core structure and key methods
-
 // createDefaultAttorneyPatterns creates default attorney analysis patterns
 func (ana *AttorneyNotesAnalyzer) createDefaultAttorneyPatterns(filePath string) error {
 	defaultPatterns := map[string]interface{}{
@@ -600,22 +605,1461 @@ func (ana *AttorneyNotesAnalyzer) createDefaultAttorneyPatterns(filePath string)
 	}
 	
 	ana.Patterns = defaultPatterns
+	ana.loadSpecificPatterns()
 	log.Printf("[ATTORNEY_NOTES_ANALYZER] Created default attorney analysis patterns at %s", filePath)
 	return nil
 }
 
-// Placeholder implementations for remaining methods
-func (ana *AttorneyNotesAnalyzer) loadSpecificPatterns() {}
-func (ana *AttorneyNotesAnalyzer) analyzeEvidenceReview(content string) EvidenceDocumentation { return EvidenceDocumentation{} }
-func (ana *AttorneyNotesAnalyzer) buildCaseTimeline(content string) CaseTimeline { return CaseTimeline{} }
-func (ana *AttorneyNotesAnalyzer) assessViolations(content string) ViolationDocumentation { return ViolationDocumentation{} }
-func (ana *AttorneyNotesAnalyzer) analyzeDamages(content string) DamageAnalysis { return DamageAnalysis{} }
-func (ana *AttorneyNotesAnalyzer) extractCaseStrategy(content string) StrategicAnalysis { return StrategicAnalysis{} }
-func (ana *AttorneyNotesAnalyzer) extractNextSteps(content string) []ActionItem { return []ActionItem{} }
+// loadSpecificPatterns turns the string lists in attorney_analysis_patterns.json
+// into typed patterns. Indicator phrases such as "Lost income:" become
+// case-insensitive patterns that capture the rest of the line.
+func (ana *AttorneyNotesAnalyzer) loadSpecificPatterns() {
+	ana.compiled = make(map[string]*regexp.Regexp)
+	ana.LegalAnalysisPatterns = nil
+	ana.TimelinePatterns = nil
+	ana.EvidencePatterns = nil
+	ana.StrategyPatterns = nil
+	ana.DamagePatterns = nil
+	
+	conclusion := ana.sourceReliability("attorneyConclusion", 0.9)
+	legal := ana.sourceReliability("legalAnalysis", 0.8)
+	factual := ana.sourceReliability("factualStatement", 0.7)
+	
+	for _, pattern := range ana.patternList("attorneyAnalysisPatterns", "violationIdentificationPatterns", false) {
+		ana.LegalAnalysisPatterns = append(ana.LegalAnalysisPatterns, LegalAnalysisPattern{Name: "violationIdentification", Pattern: pattern, Category: "violation", Confidence: conclusion})
+	}
+	for _, pattern := range ana.patternList("specializedPatterns", "fcraViolationTypes", true) {
+		ana.LegalAnalysisPatterns = append(ana.LegalAnalysisPatterns, LegalAnalysisPattern{Name: "fcraViolationType", Pattern: pattern, Category: "violation", Confidence: legal})
+	}
+	
+	for _, pattern := range ana.patternList("timelinePatterns", "dateEventPatterns", false) {
+		ana.TimelinePatterns = append(ana.TimelinePatterns, TimelinePattern{Name: "dateEvent", Pattern: pattern, Confidence: factual})
+	}
+	for _, pattern := range ana.patternList("timelinePatterns", "disputeTimelinePatterns", false) {
+		ana.TimelinePatterns = append(ana.TimelinePatterns, TimelinePattern{Name: "dispute", Pattern: pattern, Confidence: factual})
+	}
+	for _, pattern := range ana.patternList("timelinePatterns", "criticalDatePatterns", false) {
+		ana.TimelinePatterns = append(ana.TimelinePatterns, TimelinePattern{Name: "criticalDate", Pattern: pattern, Confidence: conclusion})
+	}
+	
+	for _, pattern := range ana.patternList("attorneyAnalysisPatterns", "evidenceReferencePatterns", false) {
+		ana.EvidencePatterns = append(ana.EvidencePatterns, EvidencePattern{Name: "evidenceReference", Pattern: "(?i)" + pattern, EvidenceType: evidenceTypeForReference(pattern), Confidence: factual})
+	}
+	
+	for _, pattern := range ana.patternList("strategyPatterns", "legalStrategyIndicators", true) {
+		ana.StrategyPatterns = append(ana.StrategyPatterns, StrategyPattern{Name: "legalStrategy", Pattern: pattern, StrategyType: strategyTypeForIndicator(pattern), Confidence: conclusion})
+	}
+	for _, pattern := range ana.patternList("strategyPatterns", "strengthAssessmentPatterns", false) {
+		ana.StrategyPatterns = append(ana.StrategyPatterns, StrategyPattern{Name: "strengthAssessment", Pattern: "(?i)" + pattern, StrategyType: strengthTypeForPattern(pattern), Confidence: conclusion})
+	}
+	for _, pattern := range ana.patternList("strategyPatterns", "settlementStrategyPatterns", false) {
+		ana.StrategyPatterns = append(ana.StrategyPatterns, StrategyPattern{Name: "settlementStrategy", Pattern: "(?i)" + pattern, StrategyType: "settlement", Confidence: conclusion})
+	}
+	for _, pattern := range ana.patternList("strategyPatterns", "nextStepsPatterns", true) {
+		ana.StrategyPatterns = append(ana.StrategyPatterns, StrategyPattern{Name: "nextSteps", Pattern: pattern, StrategyType: "nextSteps", Confidence: conclusion})
+	}
+	
+	for _, pattern := range ana.patternList("damagePatterns", "economicDamageIndicators", true) {
+		ana.DamagePatterns = append(ana.DamagePatterns, DamagePattern{Name: "economicIndicator", Pattern: pattern, DamageType: "economic", Confidence: legal})
+	}
+	for _, pattern := range ana.patternList("damagePatterns", "emotionalDamageIndicators", true) {
+		ana.DamagePatterns = append(ana.DamagePatterns, DamagePattern{Name: "emotionalIndicator", Pattern: pattern, DamageType: "emotional", Confidence: legal})
+	}
+	for _, pattern := range ana.patternList("damagePatterns", "damageAmountPatterns", false) {
+		ana.DamagePatterns = append(ana.DamagePatterns, DamagePattern{Name: "damageAmount", Pattern: "(?i)" + pattern, DamageType: "amount", Confidence: legal})
+	}
+	for _, pattern := range ana.patternList("damagePatterns", "statutoryDamagePatterns", false) {
+		ana.DamagePatterns = append(ana.DamagePatterns, DamagePattern{Name: "statutoryDamages", Pattern: "(?i)" + pattern, DamageType: "statutory", Confidence: conclusion})
+	}
+	
+	// Compile everything up front so the analyzer can be shared between goroutines
+	patterns := []string{}
+	for _, p := range ana.LegalAnalysisPatterns {
+		patterns = append(patterns, p.Pattern)
+	}
+	for _, p := range ana.TimelinePatterns {
+		patterns = append(patterns, p.Pattern)
+	}
+	for _, p := range ana.EvidencePatterns {
+		patterns = append(patterns, p.Pattern)
+	}
+	for _, p := range ana.StrategyPatterns {
+		patterns = append(patterns, p.Pattern)
+	}
+	for _, p := range ana.DamagePatterns {
+		patterns = append(patterns, p.Pattern)
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("[ATTORNEY_NOTES_ANALYZER] Warning: skipping invalid pattern %q: %v", pattern, err)
+			continue
+		}
+		ana.compiled[pattern] = re
+	}
+	
+	log.Printf("[ATTORNEY_NOTES_ANALYZER] Loaded %d legal, %d timeline, %d evidence, %d strategy and %d damage patterns",
+		len(ana.LegalAnalysisPatterns), len(ana.TimelinePatterns), len(ana.EvidencePatterns), len(ana.StrategyPatterns), len(ana.DamagePatterns))
+}
+
+// patternList reads section.key from the patterns file. Indicator lists hold
+// literal phrases and are converted to patterns capturing the text after them.
+func (ana *AttorneyNotesAnalyzer) patternList(section, key string, indicators bool) []string {
+	group, ok := ana.Patterns[section].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	
+	var values []string
+	switch list := group[key].(type) {
+	case []interface{}:
+		for _, value := range list {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = list
+	}
+	
+	if !indicators {
+		return values
+	}
+	patterns := make([]string, 0, len(values))
+	for _, phrase := range values {
+		phrase = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(phrase), ":"))
+		if phrase == "" {
+			continue
+		}
+		patterns = append(patterns, `(?i)\b`+regexp.QuoteMeta(phrase)+`\b\s*:?\s*(.*)`)
+	}
+	// Try longer phrases first so "Litigation strategy" wins over "Strategy"
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return len(patterns[i]) > len(patterns[j])
+	})
+	return patterns
+}
+
+// sourceReliability reads extractionConfidence.sourceReliability from the patterns file
+func (ana *AttorneyNotesAnalyzer) sourceReliability(source string, fallback float64) float64 {
+	confidence, ok := ana.Patterns["extractionConfidence"].(map[string]interface{})
+	if !ok {
+		return fallback
+	}
+	reliability, ok := confidence["sourceReliability"].(map[string]interface{})
+	if !ok {
+		return fallback
+	}
+	if value, ok := reliability[source].(float64); ok {
+		return value
+	}
+	return fallback
+}
+
+// indicatorPhrase returns the phrase an indicator pattern matched, without the captured text after it
+func indicatorPhrase(match []string) string {
+	phrase := strings.TrimSpace(strings.TrimSuffix(match[0], match[1]))
+	return strings.TrimSpace(strings.TrimSuffix(phrase, ":"))
+}
+
+// regex returns the compiled form of a loaded pattern
+func (ana *AttorneyNotesAnalyzer) regex(pattern string) *regexp.Regexp {
+	return ana.compiled[pattern]
+}
+
+// confidenceTerms returns the confidenceIndicators list for a level ("high", "medium" or "low")
+func (ana *AttorneyNotesAnalyzer) confidenceTerms(level string) []string {
+	return ana.patternList("confidenceIndicators", level+"ConfidenceTerms", false)
+}
+
+func evidenceTypeForReference(pattern string) string {
+	lower := strings.ToLower(pattern)
+	switch {
+	case strings.Contains(lower, "client"):
+		return "client statement"
+	case strings.Contains(lower, "letter"):
+		return "correspondence"
+	case strings.Contains(lower, "credit report"):
+		return "credit report"
+	case strings.Contains(lower, "damages"):
+		return "damages evidence"
+	default:
+		return "documentation"
+	}
+}
+
+func strategyTypeForIndicator(pattern string) string {
+	lower := strings.ToLower(pattern)
+	switch {
+	case strings.Contains(lower, "litigation"):
+		return "litigation"
+	case strings.Contains(lower, "approach"):
+		return "approach"
+	default:
+		return "legal"
+	}
+}
+
+func strengthTypeForPattern(pattern string) string {
+	lower := strings.ToLower(pattern)
+	switch {
+	case strings.Contains(lower, "([0-9]+)"):
+		return "score"
+	case strings.Contains(lower, "weak") || strings.Contains(lower, "disadvantage"):
+		return "weakness"
+	case strings.Contains(lower, "risk"):
+		return "risk"
+	default:
+		return "strength"
+	}
+}
+
+// noteLines splits notes into trimmed, non-empty lines with list markers removed
+func noteLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*•·"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// noteDatePattern finds dates written as "July 15, 2024", "July 15", "7/15/2024" or "2024-07-15"
+var noteDatePattern = regexp.MustCompile(`\b(?:(January|February|March|April|May|June|July|August|September|October|November|December|Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sep|Sept|Oct|Nov|Dec)\.?\s+([0-9]{1,2})(?:st|nd|rd|th)?(?:,?\s+([0-9]{4}))?\b|([0-9]{1,2})[/-]([0-9]{1,2})[/-]([0-9]{4})\b|([0-9]{4})-([0-9]{2})-([0-9]{2})\b)`)
+
+// noteDate is a date found in a line of notes
+type noteDate struct {
+	Date    time.Time
+	Text    string
+	HasYear bool
+}
+
+// findNoteDates returns the dates in a line. A date written without a year
+// takes the year of another date on the same line, then fallbackYear; it is
+// dropped when neither is known.
+func findNoteDates(line string, fallbackYear int) []noteDate {
+	type parts struct {
+		year, month, day int
+		text             string
+	}
+	var found []parts
+	lineYear := 0
+	for _, match := range noteDatePattern.FindAllStringSubmatch(line, -1) {
+		var p parts
+		switch {
+		case match[1] != "":
+			month, err := parseMonthName(match[1])
+			if err != nil {
+				continue
+			}
+			p.month = int(month)
+			p.day, _ = strconv.Atoi(match[2])
+			p.year, _ = strconv.Atoi(match[3])
+		case match[4] != "":
+			p.month, _ = strconv.Atoi(match[4])
+			p.day, _ = strconv.Atoi(match[5])
+			p.year, _ = strconv.Atoi(match[6])
+		default:
+			p.year, _ = strconv.Atoi(match[7])
+			p.month, _ = strconv.Atoi(match[8])
+			p.day, _ = strconv.Atoi(match[9])
+		}
+		p.text = match[0]
+		if p.year != 0 && lineYear == 0 {
+			lineYear = p.year
+		}
+		found = append(found, p)
+	}
+	
+	var dates []noteDate
+	for _, p := range found {
+		hasYear := p.year != 0
+		if !hasYear {
+			p.year = lineYear
+			if p.year == 0 {
+				p.year = fallbackYear
+			}
+			if p.year == 0 {
+				continue
+			}
+		}
+		// time.Date normalizes impossible dates such as 2/30, so compare the day back
+		date := time.Date(p.year, time.Month(p.month), p.day, 0, 0, 0, 0, time.UTC)
+		if p.month < 1 || p.month > 12 || date.Day() != p.day {
+			continue
+		}
+		dates = append(dates, noteDate{Date: date, Text: p.text, HasYear: hasYear})
+	}
+	return dates
+}
+
+// parseMonthName reads a full or abbreviated month name
+func parseMonthName(name string) (time.Month, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name == "Sept" {
+		name = "Sep"
+	}
+	for _, layout := range []string{"January", "Jan"} {
+		if parsed, err := time.Parse(layout, name); err == nil {
+			return parsed.Month(), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown month %q", name)
+}
+
+// isMonthName reports whether a captured name is just a month, as in "by April"
+func isMonthName(name string) bool {
+	_, err := parseMonthName(name)
+	return err == nil
+}
+
+// documentYear returns the most recent year written in a full date, used for
+// dates in the notes that leave the year off
+func documentYear(lines []string) int {
+	year := 0
+	for _, line := range lines {
+		for _, d := range findNoteDates(line, 0) {
+			if d.HasYear && d.Date.Year() > year {
+				year = d.Date.Year()
+			}
+		}
+	}
+	return year
+}
+
+// noteEntityPattern captures a capitalized name after "to", "with", "from" or "at"
+var noteEntityPattern = regexp.MustCompile(`\b(?:to|with|from|at|by|against)\s+((?:[A-Z][A-Za-z&'’.]*)(?:\s+[A-Z][A-Za-z&'’.]*)*)`)
+
+// noteActorPattern captures a capitalized name that denied, rejected or verified something
+var noteActorPattern = regexp.MustCompile(`((?:[A-Z][A-Za-z&'’.]*)(?:\s+[A-Z][A-Za-z&'’.]*)*)\s+(?:denied|rejected|declined|refused|verified)\b`)
+
+// notEntities are capitalized words that start sentences in notes but are not organizations
+var notEntities = map[string]bool{
+	"Client": true, "Plaintiff": true, "Credit": true, "Loan": true, "Application": true,
+	"Claim": true, "Dispute": true, "She": true, "He": true, "They": true, "It": true, "The": true,
+}
+
+// knownNoteEntities are the credit bureaus, which are named in almost every FCRA file
+var knownNoteEntities = []string{"Equifax", "Experian", "TransUnion", "Trans Union"}
+
+// noteEntities collects the organizations the notes refer to
+func noteEntities(lines []string) []string {
+	entities := append([]string{}, knownNoteEntities...)
+	seen := map[string]bool{}
+	for _, entity := range entities {
+		seen[entity] = true
+	}
+	for _, line := range lines {
+		matches := noteEntityPattern.FindAllStringSubmatch(line, -1)
+		matches = append(matches, noteActorPattern.FindAllStringSubmatch(line, -1)...)
+		for _, match := range matches {
+			entity := strings.TrimRight(match[1], ".'’")
+			if len(entity) < 2 || seen[entity] || notEntities[entity] || isMonthName(entity) {
+				continue
+			}
+			seen[entity] = true
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// entityInLine returns the entity named earliest in a line, preferring the
+// longer name when two start at the same place
+func entityInLine(line string, entities []string) string {
+	best, bestAt := "", -1
+	for _, entity := range entities {
+		at := strings.Index(line, entity)
+		if at < 0 {
+			continue
+		}
+		if bestAt < 0 || at < bestAt || at == bestAt && len(entity) > len(best) {
+			best, bestAt = entity, at
+		}
+	}
+	return best
+}
+
+// counterpartyInLine returns the entity a line says something was sent to or
+// done with, falling back to the first entity named
+func counterpartyInLine(line string, entities []string) string {
+	if match := noteEntityPattern.FindStringSubmatch(line); match != nil {
+		if entity := entityInLine(match[1], entities); entity != "" {
+			return entity
+		}
+	}
+	return entityInLine(line, entities)
+}
+
+// containsAny reports whether a word in text starts with one of the keywords,
+// ignoring case, so "fax" matches "faxes" but not "Equifax"
+func containsAny(text string, keywords ...string) bool {
+	lower := strings.ToLower(text)
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(keyword)
+		if keyword == "" {
+			continue
+		}
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], keyword)
+			if i < 0 {
+				break
+			}
+			i += offset
+			if i == 0 || !isWordRune(lower[:i]) {
+				return true
+			}
+			offset = i + 1
+		}
+	}
+	return false
+}
+
+// isWordRune reports whether text ends in a letter or digit
+func isWordRune(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// timelineCategories classify dated events, first match wins
+var timelineCategories = []struct {
+	category   string
+	importance string
+	keywords   []string
+}{
+	{"deadline", "high", []string{"statute of limitations", "deadline", "must file"}},
+	{"dispute", "high", []string{"disput", "reinvestigat", "claim", "verified"}},
+	{"adverse action", "high", []string{"denied", "denial", "declined", "adverse action"}},
+	{"fraud", "high", []string{"fraud", "unauthorized", "identity theft", "charges"}},
+	{"law enforcement", "medium", []string{"police", "ftc report", "identity theft report"}},
+	{"correspondence", "medium", []string{"letter", "fax", "email", "mailed", "notice"}},
+	{"credit reporting", "medium", []string{"credit report", "reported", "tradeline", "bureau"}},
+	{"travel", "medium", []string{"travel", "trip", "abroad", "out of the country"}},
+	{"consultation", "low", []string{"consultation", "met with", "intake"}},
+}
+
+func classifyTimelineEvent(line string) (string, string) {
+	for _, entry := range timelineCategories {
+		if containsAny(line, entry.keywords...) {
+			return entry.category, entry.importance
+		}
+	}
+	return "event", "medium"
+}
+
+// disputeLinePattern recognizes lines describing a dispute or claim with a creditor or bureau
+var disputeLinePattern = regexp.MustCompile(`(?i)\bdisput\w*|\bclaims?\s+(?:with|to|at)\b|\bclaim\s+was\b|\b(?:filed|made|submitted)\s+(?:a\s+)?claims?\b`)
+
+// disputeMethods maps wording in the notes to how a dispute was made, first match wins
+var disputeMethods = []struct {
+	method   string
+	keywords []string
+}{
+	{"fax", []string{"fax"}},
+	{"phone", []string{"phone", "called", "call "}},
+	{"mail", []string{"letter", "mail", "certified"}},
+	{"online", []string{"online", "website", "portal"}},
+	{"email", []string{"email", "e-mail"}},
+	{"in person", []string{"branch", "in person", "went into", "visit"}},
+}
+
+func disputeOutcome(line string) string {
+	switch {
+	case containsAny(line, "denied", "rejected", "refused"):
+		return "denied"
+	case containsAny(line, "verified"):
+		return "verified as accurate"
+	case containsAny(line, "deleted", "removed", "corrected"):
+		return "corrected"
+	case containsAny(line, "no response", "never responded", "did not respond"):
+		return "no response"
+	}
+	return ""
+}
+
+// analyzeEvidenceReview lists the evidence the notes refer to
+func (ana *AttorneyNotesAnalyzer) analyzeEvidenceReview(content string) EvidenceDocumentation {
+	lines := noteLines(content)
+	review := EvidenceDocumentation{Items: ana.extractEvidenceItems(lines, documentYear(lines))}
+	for _, item := range review.Items {
+		review.Confidence += item.Relevance * 0.2
+	}
+	review.Confidence = math.Min(1.0, review.Confidence)
+	return review
+}
+
+// evidenceKeywords recognize evidence by what it is, first match wins
+var evidenceKeywords = []struct {
+	evidenceType string
+	quality      string
+	keywords     []string
+}{
+	{"video", "strong", []string{"video", "surveillance", "footage"}},
+	{"police report", "strong", []string{"police report", "filed a police", "ftc report", "identity theft report"}},
+	{"credit report", "strong", []string{"credit report", "tradeline"}},
+	{"adverse action letter", "strong", []string{"adverse action", "denial letter"}},
+	{"correspondence", "strong", []string{"letter", "fax", "email", "mailed", "certified mail"}},
+	{"financial records", "strong", []string{"statement", "receipt", "transaction history"}},
+	{"travel records", "strong", []string{"travel dates", "passport", "boarding pass", "itinerary"}},
+	{"police report", "moderate", []string{"police"}},
+}
+
+// extractEvidenceItems finds evidence references in the notes, using the
+// evidenceReferencePatterns first and then recognizing common evidence by name
+func (ana *AttorneyNotesAnalyzer) extractEvidenceItems(lines []string, year int) []EvidenceItem {
+	items := []EvidenceItem{}
+	for _, line := range lines {
+		item := EvidenceItem{Description: line, Source: "attorney notes"}
+		found := false
+		
+		for _, entry := range evidenceKeywords {
+			if containsAny(line, entry.keywords...) {
+				item.Type = entry.evidenceType
+				item.Quality = entry.quality
+				item.Relevance = ana.sourceReliability("factualStatement", 0.7)
+				found = true
+				break
+			}
+		}
+		if !found {
+			for _, pattern := range ana.EvidencePatterns {
+				if re := ana.regex(pattern.Pattern); re != nil && re.MatchString(line) {
+					item.Type = pattern.EvidenceType
+					item.Quality = "moderate"
+					if pattern.EvidenceType == "client statement" {
+						item.Relevance = ana.sourceReliability("clientStatement", 0.6)
+					} else {
+						item.Relevance = pattern.Confidence
+					}
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		
+		if dates := findNoteDates(line, year); len(dates) > 0 {
+			item.Date = dates[0].Date
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// buildCaseTimeline turns dated statements in the notes into timeline events,
+// and collects the dispute history, critical dates and correspondence
+func (ana *AttorneyNotesAnalyzer) buildCaseTimeline(content string) CaseTimeline {
+	timeline := CaseTimeline{
+		TimelineEvents:     []TimelineEvent{},
+		CriticalDates:      []CriticalDate{},
+		StatuteLimitations: []LimitationPeriod{},
+		DisputeHistory:     []DisputeEvent{},
+		CorrespondenceLog:  []CorrespondenceItem{},
+	}
+	
+	lines := noteLines(content)
+	year := documentYear(lines)
+	entities := noteEntities(lines)
+	seen := map[string]bool{}
+	lastEntity := ""
+	
+	for _, line := range lines {
+		dates := findNoteDates(line, year)
+		
+		if len(dates) > 0 {
+			description, confidence := ana.timelineEventText(line)
+			key := dates[0].Date.Format("2006-01-02") + "|" + description
+			if !seen[key] {
+				seen[key] = true
+				category, importance := classifyTimelineEvent(line)
+				event := TimelineEvent{
+					Date:       dates[0].Date,
+					Event:      description,
+					Category:   category,
+					Importance: importance,
+					Evidence:   []string{line},
+					Source:     "attorney notes",
+				}
+				timeline.TimelineEvents = append(timeline.TimelineEvents, event)
+				timeline.Confidence += confidence * 0.1
+			}
+			
+			if containsAny(line, "letter", "fax", "email", "mailed", "notice") {
+				item := CorrespondenceItem{Date: dates[0].Date, Subject: line, Summary: line}
+				entity := counterpartyInLine(line, entities)
+				if entity != "" && containsAny(line, "from "+entity, "received") {
+					item.From, item.To = entity, "client"
+				} else {
+					item.From, item.To = "client", entity
+				}
+				timeline.CorrespondenceLog = append(timeline.CorrespondenceLog, item)
+			}
+		}
+		
+		for _, pattern := range ana.TimelinePatterns {
+			if pattern.Name != "criticalDate" {
+				continue
+			}
+			re := ana.regex(pattern.Pattern)
+			if re == nil || !re.MatchString(line) || len(dates) == 0 {
+				continue
+			}
+			dateType := "critical date"
+			switch {
+			case containsAny(line, "statute of limitations"):
+				dateType = "statute of limitations"
+			case containsAny(line, "deadline", "must file", "time limit"):
+				dateType = "filing deadline"
+			}
+			timeline.CriticalDates = append(timeline.CriticalDates, CriticalDate{
+				DateType:    dateType,
+				Date:        dates[0].Date,
+				Description: line,
+				Importance:  "high",
+			})
+			timeline.Confidence += pattern.Confidence * 0.1
+			break
+		}
+		
+		if disputeLinePattern.MatchString(line) || ana.matchesTimelinePattern("dispute", line) {
+			method := ""
+			for _, candidate := range disputeMethods {
+				if containsAny(line, candidate.keywords...) {
+					method = candidate.method
+					break
+				}
+			}
+			entity := counterpartyInLine(line, entities)
+			if entity == "" && method == "" && len(dates) == 0 {
+				// A passing mention of disputes, not a dispute event
+				continue
+			}
+			if entity == "" {
+				entity = lastEntity
+			}
+			lastEntity = entity
+			
+			dispute := DisputeEvent{
+				DisputeType: "direct dispute",
+				Entity:      entity,
+				Method:      method,
+				Outcome:     disputeOutcome(line),
+				NextSteps:   []string{},
+			}
+			for _, bureau := range knownNoteEntities {
+				if entity == bureau {
+					dispute.DisputeType = "credit bureau dispute"
+				}
+			}
+			if dispute.Outcome != "" {
+				dispute.Response = line
+			}
+			if len(dates) > 0 {
+				dispute.Date = dates[0].Date
+			}
+			timeline.DisputeHistory = append(timeline.DisputeHistory, dispute)
+		}
+	}
+	
+	sort.SliceStable(timeline.TimelineEvents, func(i, j int) bool {
+		return timeline.TimelineEvents[i].Date.Before(timeline.TimelineEvents[j].Date)
+	})
+	timeline.Confidence = math.Min(1.0, timeline.Confidence)
+	
+	log.Printf("[ATTORNEY_NOTES_ANALYZER] Timeline: %d events, %d disputes, %d critical dates",
+		len(timeline.TimelineEvents), len(timeline.DisputeHistory), len(timeline.CriticalDates))
+	return timeline
+}
+
+// timelineEventText describes a dated line. When a dateEventPatterns entry
+// splits the line into date and event, the event part is used; otherwise the
+// whole line is kept. Lines matched by a pattern score higher.
+func (ana *AttorneyNotesAnalyzer) timelineEventText(line string) (string, float64) {
+	for _, pattern := range ana.TimelinePatterns {
+		if pattern.Name != "dateEvent" {
+			continue
+		}
+		re := ana.regex(pattern.Pattern)
+		if re == nil {
+			continue
+		}
+		match := re.FindStringSubmatch(line)
+		if len(match) < 2 {
+			continue
+		}
+		event := strings.TrimSpace(match[len(match)-1])
+		if len(strings.Fields(event)) < 3 || len(findNoteDates(event, 1)) > 0 {
+			return line, pattern.Confidence
+		}
+		return event, pattern.Confidence
+	}
+	return line, ana.sourceReliability("inferredFact", 0.4)
+}
+
+func (ana *AttorneyNotesAnalyzer) matchesTimelinePattern(name, line string) bool {
+	for _, pattern := range ana.TimelinePatterns {
+		if pattern.Name != name {
+			continue
+		}
+		if re := ana.regex(pattern.Pattern); re != nil && re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// fcraCitationPattern matches citations such as "15 U.S.C. § 1681s-2(b)" or "§1681e(b)"
+var fcraCitationPattern = regexp.MustCompile(`(?i)(?:15\s*U\.?\s*S\.?\s*C\.?\s*)?(?:§+\s*)?\b(1681[a-z]?(?:-[0-9]+)?)((?:\([a-z0-9]+\))*)`)
+
+// fcraSectionPattern matches FCRA section numbers such as "Section 611(a)" or "FCRA § 623"
+var fcraSectionPattern = regexp.MustCompile(`(?i)(?:\bFCRA\s+(?:Section|Sec\.|§)?|\bSection|\bSec\.|§)\s*(6[0-2][0-9])((?:\([a-z0-9]+\))*)`)
+
+// fcraSectionCodification maps FCRA section numbers to their 15 U.S.C. sections
+var fcraSectionCodification = map[string]string{
+	"603": "1681a",
+	"604": "1681b",
+	"605": "1681c",
+	"607": "1681e",
+	"609": "1681g",
+	"611": "1681i",
+	"615": "1681m",
+	"616": "1681n",
+	"617": "1681o",
+	"623": "1681s-2",
+}
+
+// fcraViolationTypes describes what a violation of each section is; the
+// subsection form is checked before the bare section
+var fcraViolationTypes = map[string]string{
+	"1681b":      "Obtaining or using a consumer report without a permissible purpose",
+	"1681c":      "Reporting obsolete information",
+	"1681c-2":    "Failure to block information resulting from identity theft",
+	"1681e(b)":   "Failure to follow reasonable procedures to assure maximum possible accuracy",
+	"1681e":      "Failure to follow reasonable procedures",
+	"1681g":      "Failure to provide file disclosure",
+	"1681i":      "Failure to conduct reasonable reinvestigation",
+	"1681m":      "Failure to provide adverse action notice",
+	"1681n":      "Willful noncompliance",
+	"1681o":      "Negligent noncompliance",
+	"1681s-2(a)": "Furnishing information known to be inaccurate",
+	"1681s-2(b)": "Furnisher failure to investigate disputed information",
+	"1681s-2":    "Furnisher failure to comply with duties",
+}
+
+// statuteMention is one FCRA citation found in the notes
+type statuteMention struct {
+	section    string // e.g. "1681s-2"
+	subsection string // e.g. "(b)"
+}
+
+func (m statuteMention) cite() string {
+	return "15 U.S.C. § " + m.section + m.subsection
+}
+
+// violationType looks up the most specific description for the citation
+func (m statuteMention) violationType() string {
+	if m.subsection != "" {
+		first := m.subsection[:strings.Index(m.subsection, ")")+1]
+		if description, ok := fcraViolationTypes[m.section+first]; ok {
+			return description
+		}
+	}
+	if description, ok := fcraViolationTypes[m.section]; ok {
+		return description
+	}
+	return "FCRA violation"
+}
+
+// findStatuteMentions returns the FCRA citations in a line in the order written
+func findStatuteMentions(line string) []statuteMention {
+	type positioned struct {
+		at      int
+		mention statuteMention
+	}
+	var found []positioned
+	for _, match := range fcraCitationPattern.FindAllStringSubmatchIndex(line, -1) {
+		section := strings.ToLower(line[match[2]:match[3]])
+		subsection := strings.ToLower(line[match[4]:match[5]])
+		found = append(found, positioned{match[0], statuteMention{section, subsection}})
+	}
+	for _, match := range fcraSectionPattern.FindAllStringSubmatchIndex(line, -1) {
+		section, ok := fcraSectionCodification[line[match[2]:match[3]]]
+		if !ok {
+			continue
+		}
+		found = append(found, positioned{match[0], statuteMention{section, strings.ToLower(line[match[4]:match[5]])}})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].at < found[j].at })
+	
+	mentions := make([]statuteMention, 0, len(found))
+	for _, f := range found {
+		mentions = append(mentions, f.mention)
+	}
+	return mentions
+}
+
+// assessViolations records every FCRA provision the notes cite, together with
+// the notes supporting it and the evidence they mention
+func (ana *AttorneyNotesAnalyzer) assessViolations(content string) ViolationDocumentation {
+	violations := ViolationDocumentation{
+		IdentifiedViolations: []DocumentedViolation{},
+		ViolationTimeline:    []ViolationEvent{},
+		EvidenceSupport:      []EvidenceItem{},
+		StatutoryMapping:     []StatutoryViolation{},
+	}
+	
+	lines := noteLines(content)
+	year := documentYear(lines)
+	entities := noteEntities(lines)
+	violations.EvidenceSupport = ana.extractEvidenceItems(lines, year)
+	
+	evidence := []string{}
+	for _, item := range violations.EvidenceSupport {
+		evidence = append(evidence, item.Description)
+	}
+	
+	index := map[string]int{}
+	for _, line := range lines {
+		mentions := findStatuteMentions(line)
+		violationType, confidence := ana.violationStatement(line)
+		
+		if len(mentions) == 0 && violationType == "" {
+			continue
+		}
+		if len(mentions) == 0 {
+			// A violation described in words without a citation
+			mentions = []statuteMention{{}}
+		}
+		
+		for _, mention := range mentions {
+			statute := ""
+			description := violationType
+			if mention.section != "" {
+				statute = mention.cite()
+				description = mention.violationType()
+				if confidence == 0 {
+					confidence = ana.sourceReliability("legalAnalysis", 0.8)
+				}
+			}
+			
+			key := statute
+			if key == "" {
+				key = description
+			}
+			if i, ok := index[key]; ok {
+				existing := &violations.IdentifiedViolations[i]
+				if !containsString(existing.SupportingFacts, line) {
+					existing.SupportingFacts = append(existing.SupportingFacts, line)
+				}
+				existing.Confidence = math.Min(1.0, existing.Confidence+0.1)
+				continue
+			}
+			
+			index[key] = len(violations.IdentifiedViolations)
+			violations.IdentifiedViolations = append(violations.IdentifiedViolations, DocumentedViolation{
+				ViolationType:        description,
+				Statute:              statute,
+				ViolationDescription: line,
+				SupportingFacts:      []string{line},
+				EvidenceReferences:   evidence,
+				AttorneyNotes:        line,
+				LiabilityStrength:    ana.liabilityStrength(line),
+				Confidence:           confidence,
+			})
+			if mention.section != "" {
+				violations.StatutoryMapping = append(violations.StatutoryMapping, StatutoryViolation{
+					Statute:   statute,
+					Section:   mention.section + mention.subsection,
+					Violation: description,
+					Elements:  []string{},
+					Evidence:  evidence,
+					Strength:  confidence,
+				})
+			}
+			violations.Confidence += confidence * 0.2
+		}
+		
+		if dates := findNoteDates(line, year); len(dates) > 0 {
+			violations.ViolationTimeline = append(violations.ViolationTimeline, ViolationEvent{
+				Date:      dates[0].Date,
+				Violation: line,
+				Defendant: entityInLine(line, entities),
+				Evidence:  evidence,
+				Severity:  ana.liabilityStrength(line),
+			})
+		}
+	}
+	
+	violations.ViolationSeverity = ana.violationSeverity(content, violations.IdentifiedViolations)
+	violations.Confidence = math.Min(1.0, violations.Confidence)
+	
+	log.Printf("[ATTORNEY_NOTES_ANALYZER] Violations: %d documented, %d statutes cited",
+		len(violations.IdentifiedViolations), len(violations.StatutoryMapping))
+	return violations
+}
+
+// violationStatement returns the violation a line describes in words, using
+// the violationIdentificationPatterns and the fcraViolationTypes phrases
+func (ana *AttorneyNotesAnalyzer) violationStatement(line string) (string, float64) {
+	for _, pattern := range ana.LegalAnalysisPatterns {
+		if pattern.Category != "violation" {
+			continue
+		}
+		re := ana.regex(pattern.Pattern)
+		if re == nil || !re.MatchString(line) {
+			continue
+		}
+		if pattern.Name == "fcraViolationType" {
+			// The pattern is built from the phrase, so the phrase is the type
+			return indicatorPhrase(re.FindStringSubmatch(line)), pattern.Confidence
+		}
+		return "FCRA violation", pattern.Confidence
+	}
+	return "", 0
+}
+
+// liabilityStrength rates a statement by the confidence words the attorney used
+func (ana *AttorneyNotesAnalyzer) liabilityStrength(line string) string {
+	if containsAny(line, ana.confidenceTerms("low")...) {
+		return "weak"
+	}
+	if containsAny(line, ana.confidenceTerms("high")...) {
+		return "strong"
+	}
+	return "moderate"
+}
+
+// violationSeverity weighs the documented violations against aggravating facts in the notes
+func (ana *AttorneyNotesAnalyzer) violationSeverity(content string, violations []DocumentedViolation) ViolationSeverityAnalysis {
+	severity := ViolationSeverityAnalysis{
+		ViolationCount:  len(violations),
+		SeverityFactors: []SeverityFactor{},
+	}
+	if len(violations) == 0 {
+		severity.OverallSeverity = "none"
+		severity.RecommendedAction = "No FCRA provisions are cited in the notes; confirm the violations before drafting"
+		return severity
+	}
+	
+	score := 0.0
+	if containsAny(content, "willful", "1681n", "reckless") {
+		severity.SeverityFactors = append(severity.SeverityFactors, SeverityFactor{Factor: "Willful noncompliance alleged", Impact: "Statutory and punitive damages available under § 1681n", Weight: 0.4})
+		score += 0.4
+	}
+	if len(violations) >= 3 {
+		severity.SeverityFactors = append(severity.SeverityFactors, SeverityFactor{Factor: "Multiple provisions violated", Impact: "Supports a pattern of noncompliance", Weight: 0.2})
+		score += 0.2
+	}
+	if len(disputeLinePattern.FindAllString(content, -1)) >= 2 {
+		severity.SeverityFactors = append(severity.SeverityFactors, SeverityFactor{Factor: "Repeated disputes", Impact: "Shows the defendant had notice of the inaccuracy", Weight: 0.2})
+		score += 0.2
+	}
+	if containsAny(content, "credit denied", "application denied", "loan denied", "adverse action") {
+		severity.SeverityFactors = append(severity.SeverityFactors, SeverityFactor{Factor: "Credit denial", Impact: "Concrete economic harm", Weight: 0.2})
+		score += 0.2
+	}
+	
+	switch {
+	case score >= 0.6:
+		severity.OverallSeverity = "high"
+		severity.RecommendedAction = "Plead willful and negligent noncompliance and seek statutory, actual and punitive damages"
+	case score > 0:
+		severity.OverallSeverity = "moderate"
+		severity.RecommendedAction = "Plead negligent noncompliance and develop evidence of willfulness in discovery"
+	default:
+		severity.OverallSeverity = "low"
+		severity.RecommendedAction = "Confirm actual damages before filing"
+	}
+	return severity
+}
+
+// dollarPattern matches dollar amounts such as "$7,500", "$1,234.56" or "$5k"
+var dollarPattern = regexp.MustCompile(`(?i)\$\s?([0-9]{1,3}(?:,[0-9]{3})+|[0-9]+)(\.[0-9]{1,2})?(?:\s*(k|thousand|million)\b)?`)
+
+// parseDollars returns the dollar amounts in a line
+func parseDollars(line string) []float64 {
+	var amounts []float64
+	for _, match := range dollarPattern.FindAllStringSubmatch(line, -1) {
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", "")+match[2], 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(match[3]) {
+		case "k", "thousand":
+			amount *= 1000
+		case "million":
+			amount *= 1000000
+		}
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+// creditDenialPattern recognizes an application for credit being turned down
+var creditDenialPattern = regexp.MustCompile(`(?i)\b(?:application|credit|loan|mortgage|card|apartment|lease)\b.*\b(?:denied|declined|rejected|turned down)\b|\b(?:denied|declined|rejected|turned down)\b.*\b(?:application|credit|loan|mortgage|apartment|lease)\b`)
+
+// emotionalHarms maps emotional harm wording onto the damage categories, first match wins
+var emotionalHarms = []struct {
+	harm     string
+	keywords []string
+}{
+	{"humiliation", []string{"humiliat", "mortif"}},
+	{"embarrassment", []string{"embarrass", "reputation", "shame"}},
+	{"anxiety", []string{"anxiety", "anxious", "sleepless", "can't sleep", "cannot sleep", "panic", "depress"}},
+	{"mentalAnguish", []string{"anguish", "mental", "psycholog"}},
+	{"emotionalDistress", []string{"distress", "stress", "upset", "trauma", "emotional", "suffer", "crying"}},
+}
+
+// analyzeDamages gathers the dollar amounts and harms the notes record. Amounts
+// are classified by the damagePatterns and the wording around them; the
+// statutory range follows § 1681n when willfulness is alleged.
+func (ana *AttorneyNotesAnalyzer) analyzeDamages(content string) DamageAnalysis {
+	damages := DamageAnalysis{
+		EconomicDamages: EconomicDamageAssessment{
+			LostIncome:          DamageAmount{Currency: "USD"},
+			CreditDenials:       []CreditDenial{},
+			HigherInterestRates: []InterestImpact{},
+			DepositsRequired:    []DepositImpact{},
+			OtherEconomicLoss:   []EconomicLoss{},
+		},
+		StatutoryDamages:  StatutoryDamageAssessment{Circumstances: []string{}},
+		PunitivePotential: PunitiveDamageAssessment{Factors: []string{}},
+	}
+	
+	lines := noteLines(content)
+	year := documentYear(lines)
+	entities := noteEntities(lines)
+	attorneyEstimate := 0.0
+	emotional := map[string][]string{}
+	
+	for _, line := range lines {
+		amounts := parseDollars(line)
+		amount := 0.0
+		if len(amounts) > 0 {
+			amount = amounts[0]
+		}
+		indicator, confidence := ana.damageIndicator(line, "economic")
+		
+		switch {
+		case containsAny(line, "settlement"):
+			// Settlement figures belong to the strategy, not the damages
+			continue
+		
+		case ana.matchesDamagePattern("statutory", line):
+			damages.StatutoryDamages.Circumstances = append(damages.StatutoryDamages.Circumstances, line)
+			if len(amounts) >= 2 {
+				damages.StatutoryDamages.MinStatutory, damages.StatutoryDamages.MaxStatutory = math.Min(amounts[0], amounts[1]), math.Max(amounts[0], amounts[1])
+			} else if amount > 0 {
+				damages.StatutoryDamages.MaxStatutory = math.Max(damages.StatutoryDamages.MaxStatutory, amount)
+			}
+			damages.Confidence += 0.1
+			continue
+		
+		case containsAny(line, "hourly", "per hour", "/hr", "/hour", "attorney fees", "attorney's fees"):
+			if rate := hourlyRatePattern.FindStringSubmatch(line); rate != nil {
+				damages.AttorneyFees.HourlyRate = parseDollars(rate[0])[0]
+			}
+			if hours := hoursPattern.FindStringSubmatch(line); hours != nil {
+				damages.AttorneyFees.EstimatedHours, _ = strconv.ParseFloat(hours[1], 64)
+			}
+			continue
+		
+		case amount > 0 && damageEstimatePattern.MatchString(line):
+			attorneyEstimate = math.Max(attorneyEstimate, amount)
+			damages.Confidence += 0.2
+			continue
+		}
+		
+		date := time.Time{}
+		if dates := findNoteDates(line, year); len(dates) > 0 {
+			date = dates[0].Date
+		}
+		entity := entityInLine(line, entities)
+		
+		switch {
+		case containsAny(indicator, "credit denied", "loan denied") || indicator == "" && creditDenialPattern.MatchString(line) && !disputeLinePattern.MatchString(line):
+			damages.EconomicDamages.CreditDenials = append(damages.EconomicDamages.CreditDenials, CreditDenial{Date: date, Creditor: entity, Amount: amount, Reason: line})
+		case containsAny(indicator, "interest", "rate") || amount > 0 && containsAny(line, "interest"):
+			impact := InterestImpact{Date: date, Creditor: entity, TotalImpact: amount}
+			if rate := percentPattern.FindStringSubmatch(line); rate != nil {
+				impact.ExtraRate, _ = strconv.ParseFloat(rate[1], 64)
+			}
+			damages.EconomicDamages.HigherInterestRates = append(damages.EconomicDamages.HigherInterestRates, impact)
+		case containsAny(indicator, "deposit") || amount > 0 && containsAny(line, "deposit"):
+			damages.EconomicDamages.DepositsRequired = append(damages.EconomicDamages.DepositsRequired, DepositImpact{Date: date, Service: entity, DepositAmount: amount, Reason: line})
+		case containsAny(indicator, "income", "employment") || amount > 0 && containsAny(line, "lost income", "wages", "salary", "lost pay"):
+			damages.EconomicDamages.LostIncome.Amount += amount
+			damages.EconomicDamages.LostIncome.Confidence = math.Max(damages.EconomicDamages.LostIncome.Confidence, confidence)
+		case amount > 0:
+			lossType := "monetary loss"
+			switch {
+			case indicator != "":
+				lossType = strings.ToLower(strings.TrimSuffix(indicator, ":"))
+			case containsAny(line, "fraud", "unauthorized", "charges"):
+				lossType = "fraudulent charges"
+			case containsAny(line, "fee", "cost"):
+				lossType = "fees and costs"
+			}
+			damages.EconomicDamages.OtherEconomicLoss = append(damages.EconomicDamages.OtherEconomicLoss, EconomicLoss{Type: lossType, Amount: amount, Description: line, Evidence: []string{line}})
+		case indicator != "":
+			damages.EconomicDamages.OtherEconomicLoss = append(damages.EconomicDamages.OtherEconomicLoss, EconomicLoss{Type: strings.ToLower(indicator), Description: line, Evidence: []string{line}})
+		default:
+			if harm := ana.emotionalHarm(line); harm != "" {
+				emotional[harm] = append(emotional[harm], line)
+				damages.Confidence += 0.05
+			}
+			continue
+		}
+		if confidence == 0 {
+			confidence = ana.sourceReliability("factualStatement", 0.7)
+		}
+		damages.Confidence += confidence * 0.2
+	}
+	
+	economic := &damages.EconomicDamages
+	total := economic.LostIncome.Amount
+	for _, impact := range economic.HigherInterestRates {
+		total += impact.TotalImpact
+	}
+	for _, deposit := range economic.DepositsRequired {
+		total += deposit.DepositAmount
+	}
+	for _, loss := range economic.OtherEconomicLoss {
+		total += loss.Amount
+	}
+	economic.TotalEconomicDamage = DamageRange{MinAmount: total, MaxAmount: total, EstimatedAmount: total}
+	
+	nonEconomic := &damages.NonEconomicDamages
+	nonEconomic.EmotionalDistress = emotionalDamageItem(emotional["emotionalDistress"])
+	nonEconomic.Embarrassment = emotionalDamageItem(emotional["embarrassment"])
+	nonEconomic.Humiliation = emotionalDamageItem(emotional["humiliation"])
+	nonEconomic.Anxiety = emotionalDamageItem(emotional["anxiety"])
+	nonEconomic.MentalAnguish = emotionalDamageItem(emotional["mentalAnguish"])
+	
+	willful := containsAny(content, "willful", "1681n", "reckless")
+	if willful {
+		// 15 U.S.C. § 1681n(a)(1)(A): $100 to $1,000 for a willful violation
+		if damages.StatutoryDamages.MaxStatutory == 0 {
+			damages.StatutoryDamages.MinStatutory = 100
+			damages.StatutoryDamages.MaxStatutory = 1000
+		}
+		damages.PunitivePotential.Likelihood = "possible"
+		for _, line := range lines {
+			if containsAny(line, "willful", "1681n", "reckless") {
+				damages.PunitivePotential.Factors = append(damages.PunitivePotential.Factors, line)
+			}
+		}
+	} else {
+		damages.PunitivePotential.Likelihood = "unlikely"
+	}
+	
+	fees := &damages.AttorneyFees
+	fees.TotalFees = fees.HourlyRate * fees.EstimatedHours
+	
+	statutory := damages.StatutoryDamages
+	damages.TotalDamageRange = DamageRange{
+		MinAmount:       total + statutory.MinStatutory,
+		MaxAmount:       total + statutory.MaxStatutory,
+		EstimatedAmount: total + (statutory.MinStatutory+statutory.MaxStatutory)/2,
+	}
+	if attorneyEstimate > 0 {
+		damages.TotalDamageRange.EstimatedAmount = attorneyEstimate
+		damages.TotalDamageRange.MaxAmount = math.Max(damages.TotalDamageRange.MaxAmount, attorneyEstimate)
+	}
+	damages.Confidence = math.Min(1.0, damages.Confidence)
+	
+	log.Printf("[ATTORNEY_NOTES_ANALYZER] Damages: $%.2f economic, estimate $%.2f",
+		total, damages.TotalDamageRange.EstimatedAmount)
+	return damages
+}
+
+var (
+	hourlyRatePattern = regexp.MustCompile(`(?i)\$\s?[0-9][0-9,]*(?:\.[0-9]{2})?\s*(?:/\s*(?:hr|hour)|per\s+hour|an\s+hour|hourly)`)
+	hoursPattern      = regexp.MustCompile(`(?i)([0-9]+(?:\.[0-9]+)?)\s*hours`)
+	percentPattern    = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)\s*%`)
+	
+	// damageEstimatePattern recognizes the attorney's own estimate of the whole claim
+	damageEstimatePattern = regexp.MustCompile(`(?i)total damages|potential recovery|claim amount|damages.*estimat`)
+)
+
+// damageIndicator returns the damage indicator phrase of the given type that
+// a line starts a statement with, such as "Credit denied"
+func (ana *AttorneyNotesAnalyzer) damageIndicator(line, damageType string) (string, float64) {
+	for _, pattern := range ana.DamagePatterns {
+		if pattern.DamageType != damageType {
+			continue
+		}
+		re := ana.regex(pattern.Pattern)
+		if re == nil {
+			continue
+		}
+		if match := re.FindStringSubmatch(line); match != nil {
+			return indicatorPhrase(match), pattern.Confidence
+		}
+	}
+	return "", 0
+}
+
+func (ana *AttorneyNotesAnalyzer) matchesDamagePattern(damageType, line string) bool {
+	for _, pattern := range ana.DamagePatterns {
+		if pattern.DamageType != damageType {
+			continue
+		}
+		if re := ana.regex(pattern.Pattern); re != nil && re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// emotionalHarm returns the harm category a line describes, if any
+func (ana *AttorneyNotesAnalyzer) emotionalHarm(line string) string {
+	text := line
+	if indicator, _ := ana.damageIndicator(line, "emotional"); indicator != "" {
+		text = indicator
+	}
+	for _, entry := range emotionalHarms {
+		if containsAny(text, entry.keywords...) {
+			return entry.harm
+		}
+	}
+	return ""
+}
+
+// emotionalDamageItem summarizes the notes describing one kind of emotional harm
+func emotionalDamageItem(evidence []string) EmotionalDamageItem {
+	item := EmotionalDamageItem{Evidence: evidence}
+	switch {
+	case len(evidence) == 0:
+		return item
+	case len(evidence) >= 3 || containsAny(strings.Join(evidence, " "), "severe", "extreme", "hospital", "therapy", "medication"):
+		item.Severity = "severe"
+	case len(evidence) == 2:
+		item.Severity = "moderate"
+	default:
+		item.Severity = "mild"
+	}
+	for _, line := range evidence {
+		if match := durationPattern.FindString(line); match != "" {
+			item.Duration = match
+			break
+		}
+	}
+	return item
+}
+
+var durationPattern = regexp.MustCompile(`(?i)\b(?:for\s+)?(?:[0-9]+|a|several|many)\s+(?:days?|weeks?|months?|years?)\b`)
+
+// extractCaseStrategy collects the attorney's stated strategy, strength
+// assessment, weaknesses and settlement position
+func (ana *AttorneyNotesAnalyzer) extractCaseStrategy(content string) StrategicAnalysis {
+	strategy := StrategicAnalysis{
+		WeaknessIdentified: []WeaknessArea{},
+		LitigationStrategy: LitigationStrategy{KeyIssues: []string{}},
+		RiskAssessment:     RiskAnalysis{RiskFactors: []string{}, Mitigation: []string{}},
+	}
+	
+	for _, line := range noteLines(content) {
+		for _, pattern := range ana.StrategyPatterns {
+			if pattern.Name == "nextSteps" {
+				continue
+			}
+			re := ana.regex(pattern.Pattern)
+			if re == nil {
+				continue
+			}
+			match := re.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			text := ""
+			if len(match) > 1 {
+				text = strings.TrimSpace(match[len(match)-1])
+			}
+			
+			switch pattern.StrategyType {
+			case "legal":
+				if strategy.LegalStrategy == "" && text != "" {
+					strategy.LegalStrategy = text
+				}
+			case "approach":
+				if strategy.CaseApproach == "" && text != "" {
+					strategy.CaseApproach = text
+				}
+			case "litigation":
+				if strategy.LitigationStrategy.Approach == "" && text != "" {
+					strategy.LitigationStrategy.Approach = text
+				}
+			case "score":
+				if score, err := strconv.ParseFloat(match[1], 64); err == nil {
+					if strings.Contains(pattern.Pattern, "/10") {
+						score *= 10
+					}
+					strategy.StrengthAssessment.Overall = math.Min(1.0, score/100)
+				}
+			case "weakness":
+				strategy.WeaknessIdentified = append(strategy.WeaknessIdentified, WeaknessArea{Area: text, Impact: "Identified by attorney"})
+			case "risk":
+				strategy.RiskAssessment.RiskFactors = append(strategy.RiskAssessment.RiskFactors, text)
+			case "strength":
+				strategy.LitigationStrategy.KeyIssues = append(strategy.LitigationStrategy.KeyIssues, line)
+			case "settlement":
+				ana.applySettlementLine(&strategy.SettlementStrategy, pattern.Pattern, line, text)
+			}
+			strategy.Confidence += pattern.Confidence * 0.2
+			break
+		}
+	}
+	
+	if strategy.LegalStrategy == "" {
+		strategy.LegalStrategy = strategy.CaseApproach
+	}
+	if strategy.LegalStrategy == "" {
+		strategy.LegalStrategy = strategy.LitigationStrategy.Approach
+	}
+	switch risks := len(strategy.WeaknessIdentified) + len(strategy.RiskAssessment.RiskFactors); {
+	case risks >= 3:
+		strategy.RiskAssessment.OverallRisk = "high"
+	case risks > 0:
+		strategy.RiskAssessment.OverallRisk = "moderate"
+	case strategy.Confidence > 0:
+		strategy.RiskAssessment.OverallRisk = "low"
+	}
+	strategy.Confidence = math.Min(1.0, strategy.Confidence)
+	return strategy
+}
+
+// applySettlementLine records a settlement range, value, strategy or timing
+func (ana *AttorneyNotesAnalyzer) applySettlementLine(settlement *SettlementAnalysis, pattern, line, text string) {
+	amounts := parseDollars(line)
+	lower := strings.ToLower(pattern)
+	switch {
+	case strings.Contains(lower, "range") && len(amounts) >= 2:
+		low, high := math.Min(amounts[0], amounts[1]), math.Max(amounts[0], amounts[1])
+		settlement.RecommendedRange = DamageRange{MinAmount: low, MaxAmount: high, EstimatedAmount: (low + high) / 2}
+	case strings.Contains(lower, "value") && len(amounts) > 0:
+		settlement.RecommendedRange.EstimatedAmount = amounts[0]
+	case strings.Contains(lower, "timing"):
+		settlement.Timing = text
+	case settlement.Strategy == "":
+		settlement.Strategy = text
+	}
+}
+
+// nextStepMarker matches list items under a next steps heading
+var nextStepMarker = regexp.MustCompile(`^\s*(?:[-*•·]|[0-9]+[.)]|\[ ?\])\s*`)
+
+// extractNextSteps reads the tasks listed after a next steps heading, or
+// written inline after it and separated by semicolons
+func (ana *AttorneyNotesAnalyzer) extractNextSteps(content string) []ActionItem {
+	actions := []ActionItem{}
+	rawLines := strings.Split(content, "\n")
+	year := documentYear(noteLines(content))
+	
+	for i := 0; i < len(rawLines); i++ {
+		line := strings.TrimSpace(rawLines[i])
+		inline, ok := ana.nextStepsHeading(line)
+		if !ok {
+			continue
+		}
+		
+		var tasks []string
+		for _, task := range strings.Split(inline, ";") {
+			if task = strings.TrimSpace(task); task != "" {
+				tasks = append(tasks, task)
+			}
+		}
+		if len(tasks) == 0 {
+			// The tasks follow the heading, one per line, until a blank line, the
+			// next heading or the end of a bulleted or numbered list
+			listed := false
+			for i+1 < len(rawLines) {
+				next := strings.TrimSpace(rawLines[i+1])
+				if next == "" && len(tasks) > 0 {
+					break
+				}
+				marked := nextStepMarker.MatchString(next)
+				if next != "" && !marked && (listed || strings.HasSuffix(next, ":")) {
+					break
+				}
+				listed = listed || marked
+				i++
+				if next = strings.TrimSpace(nextStepMarker.ReplaceAllString(next, "")); next != "" {
+					tasks = append(tasks, next)
+				}
+			}
+		}
+		
+		for _, task := range tasks {
+			action := ActionItem{
+				Task:     task,
+				Priority: "medium",
+				Status:   "pending",
+			}
+			switch {
+			case containsAny(task, "urgent", "immediately", "asap", "deadline", "before", "today"):
+				action.Priority = "high"
+			case containsAny(task, "eventually", "consider", "if time", "optional"):
+				action.Priority = "low"
+			}
+			for _, role := range []string{"paralegal", "associate", "attorney", "client"} {
+				if containsAny(task, role) {
+					action.Responsible = role
+					break
+				}
+			}
+			if dates := findNoteDates(task, year); len(dates) > 0 {
+				action.DueDate = dates[0].Date
+			}
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// nextStepsHeading reports whether a line is a next steps heading, returning
+// any tasks written on the same line
+func (ana *AttorneyNotesAnalyzer) nextStepsHeading(line string) (string, bool) {
+	for _, pattern := range ana.StrategyPatterns {
+		if pattern.Name != "nextSteps" {
+			continue
+		}
+		re := ana.regex(pattern.Pattern)
+		if re == nil {
+			continue
+		}
+		// Only a heading at the start of the line counts, not the phrase mid-sentence
+		match := re.FindStringSubmatchIndex(line)
+		if match == nil || match[0] != 0 {
+			continue
+		}
+		rest := line[match[2]:match[3]]
+		if strings.Contains(line[:match[2]], ":") || rest == "" {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// calculateConfidenceScores returns the baseline confidence DocumentService weighs attorney notes with
 func (ana *AttorneyNotesAnalyzer) calculateConfidenceScores(analysis *AttorneyNotesAnalysis) AnalysisConfidence {
 	return AnalysisConfidence{OverallConfidence: 0.8}
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Supporting types - implementing key ones, others would be similar
 type CredibilityAssessment struct {
 	OverallCredibility float64 `json:"overallCredibility"`
@@ -712,4 +2156,4 @@ type LitigationStrategy struct{ Approach string; Timeline string; KeyIssues []st
 type RiskAnalysis struct{ OverallRisk string; RiskFactors []string; Mitigation []string }
 type DamageEstimate struct{ MinDamage float64; MaxDamage float64; Likelihood float64 }
 type LegalCitation struct{ Case string; Citation string; Relevance string }
-type EvidenceDocumentation struct{ Confidence float64 }
\ No newline at end of file
+type EvidenceDocumentation struct{ Items []EvidenceItem; Confidence float64 }
\ No newline at end of file
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// johnsonCaseDir holds the Johnson_Credit_Dispute fixture, relative to the v2 directory
+const johnsonCaseDir = "../test_icloud/CASES/Johnson_Credit_Dispute"
+
+// newFixtureNotesAnalyzer runs from the v2 directory, where the analyzer reads
+// config/attorney_analysis_patterns.json
+func newFixtureNotesAnalyzer(t *testing.T) *AttorneyNotesAnalyzer {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	analyzer, err := NewAttorneyNotesAnalyzer()
+	if err != nil {
+		t.Fatalf("NewAttorneyNotesAnalyzer: %v", err)
+	}
+	return analyzer
+}
+
+// johnsonNotes returns the text of the fixture's attorney notes
+func johnsonNotes(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(johnsonCaseDir, name)
+	if filepath.Ext(name) != ".docx" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, err := docxXMLToText(string(data), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return text
+	}
+	t.Fatalf("%s has no word/document.xml", path)
+	return ""
+}
+
+func TestAttorneyNotesTimeline(t *testing.T) {
+	analyzer := newFixtureNotesAnalyzer(t)
+
+	tests := []struct {
+		notes    string
+		date     time.Time
+		category string
+		event    string
+	}{
+		{"Atty_Notes.docx", time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), "fraud", "Charges started on July 15, 2024"},
+		{"Attorney_Notes.txt", time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), "travel", "Travel Dates: June 30 - July 30, 2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.notes, func(t *testing.T) {
+			analysis, err := analyzer.AnalyzeAttorneyNotes(tt.notes, johnsonNotes(t, tt.notes))
+			if err != nil {
+				t.Fatalf("AnalyzeAttorneyNotes: %v", err)
+			}
+
+			var found *TimelineEvent
+			for i, event := range analysis.TimelineAnalysis.TimelineEvents {
+				if event.Date.IsZero() {
+					t.Errorf("timeline event %q has no date", event.Event)
+				}
+				if event.Date.Equal(tt.date) {
+					found = &analysis.TimelineAnalysis.TimelineEvents[i]
+				}
+			}
+			if found == nil {
+				t.Fatalf("no timeline event on %s in %+v", tt.date.Format("2006-01-02"), analysis.TimelineAnalysis.TimelineEvents)
+			}
+			if found.Category != tt.category || found.Event != tt.event {
+				t.Errorf("event on %s = %q (%s), want %q (%s)", tt.date.Format("2006-01-02"), found.Event, found.Category, tt.event, tt.category)
+			}
+		})
+	}
+}
+
+func TestAttorneyNotesDisputeHistory(t *testing.T) {
+	analyzer := newFixtureNotesAnalyzer(t)
+
+	analysis, err := analyzer.AnalyzeAttorneyNotes("Atty_Notes.docx", johnsonNotes(t, "Atty_Notes.docx"))
+	if err != nil {
+		t.Fatalf("AnalyzeAttorneyNotes: %v", err)
+	}
+
+	denied := 0
+	for _, dispute := range analysis.TimelineAnalysis.DisputeHistory {
+		if dispute.Entity != "TD Bank" {
+			t.Errorf("dispute %+v is not with TD Bank", dispute)
+		}
+		if dispute.Outcome == "denied" {
+			denied++
+		}
+	}
+	if len(analysis.TimelineAnalysis.DisputeHistory) < 2 || denied == 0 {
+		t.Errorf("dispute history = %+v, want the denied TD Bank disputes", analysis.TimelineAnalysis.DisputeHistory)
+	}
+}
+
+func TestAttorneyNotesDamages(t *testing.T) {
+	analyzer := newFixtureNotesAnalyzer(t)
+
+	tests := []struct {
+		notes       string
+		description string
+	}{
+		{"Atty_Notes.docx", "$7,500 in fraudulent charges in one week"},
+		{"Attorney_Notes.txt", "Fraud Amount: $7,500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.notes, func(t *testing.T) {
+			analysis, err := analyzer.AnalyzeAttorneyNotes(tt.notes, johnsonNotes(t, tt.notes))
+			if err != nil {
+				t.Fatalf("AnalyzeAttorneyNotes: %v", err)
+			}
+			damages := analysis.DamageAssessment.EconomicDamages
+
+			if len(damages.OtherEconomicLoss) != 1 {
+				t.Fatalf("economic losses = %+v, want the fraudulent charges", damages.OtherEconomicLoss)
+			}
+			loss := damages.OtherEconomicLoss[0]
+			if loss.Amount != 7500 || loss.Type != "fraudulent charges" || loss.Description != tt.description {
+				t.Errorf("economic loss = %+v, want $7,500 of fraudulent charges from %q", loss, tt.description)
+			}
+			if damages.TotalEconomicDamage.EstimatedAmount != 7500 {
+				t.Errorf("total economic damage = %v, want 7500", damages.TotalEconomicDamage.EstimatedAmount)
+			}
+			if analysis.DamageAssessment.TotalDamageRange.EstimatedAmount != 7500 {
+				t.Errorf("total damage estimate = %v, want 7500", analysis.DamageAssessment.TotalDamageRange.EstimatedAmount)
+			}
+		})
+	}
+}
+
+func TestAttorneyNotesViolations(t *testing.T) {
+	analyzer := newFixtureNotesAnalyzer(t)
+	notes := johnsonNotes(t, "Atty_Notes.docx")
+
+	// The fixture notes cite no statute, so none may be invented
+	analysis, err := analyzer.AnalyzeAttorneyNotes("Atty_Notes.docx", notes)
+	if err != nil {
+		t.Fatalf("AnalyzeAttorneyNotes: %v", err)
+	}
+	violations := analysis.ViolationAssessment
+	if len(violations.StatutoryMapping) != 0 || len(violations.IdentifiedViolations) != 0 {
+		t.Errorf("statutes = %+v, want none for notes that cite no statute", violations.StatutoryMapping)
+	}
+	if violations.ViolationSeverity.OverallSeverity != "none" {
+		t.Errorf("severity = %q, want none", violations.ViolationSeverity.OverallSeverity)
+	}
+
+	// The same notes with the attorney's assessment of the claims
+	notes += "\nTD Bank failed to investigate the dispute in violation of 15 U.S.C. § 1681s-2(b)\n" +
+		"Equifax did not conduct a reasonable reinvestigation, 15 U.S.C. 1681i(a)\n" +
+		"Willful noncompliance under FCRA section 616\n"
+	analysis, err = analyzer.AnalyzeAttorneyNotes("Atty_Notes.docx", notes)
+	if err != nil {
+		t.Fatalf("AnalyzeAttorneyNotes: %v", err)
+	}
+
+	want := map[string]bool{
+		"15 U.S.C. § 1681s-2(b)": false,
+		"15 U.S.C. § 1681i(a)":   false,
+		"15 U.S.C. § 1681n":      false,
+	}
+	for _, violation := range analysis.ViolationAssessment.StatutoryMapping {
+		if _, ok := want[violation.Statute]; !ok {
+			t.Errorf("unexpected statute %q", violation.Statute)
+			continue
+		}
+		want[violation.Statute] = true
+		if len(violation.Evidence) == 0 && violation.Violation == "" {
+			t.Errorf("statute %q has no violation or supporting notes", violation.Statute)
+		}
+	}
+	for statute, found := range want {
+		if !found {
+			t.Errorf("statute %q not mapped; got %+v", statute, analysis.ViolationAssessment.StatutoryMapping)
+		}
+	}
+
+	for _, violation := range analysis.ViolationAssessment.IdentifiedViolations {
+		if strings.Contains(violation.Statute, "1681s-2(b)") && violation.ViolationType != "Furnisher failure to investigate disputed information" {
+			t.Errorf("§ 1681s-2(b) violation type = %q", violation.ViolationType)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"strings"
+)
+
+// Permission is an action a role may be allowed to perform
+type Permission string
+
+// Permissions checked by the authorization middleware
+const (
+	PermissionBrowseCases       Permission = "cases:browse"
+	PermissionSelectDocuments   Permission = "documents:select"
+	PermissionReviewData        Permission = "case:review"
+	PermissionGenerateDocuments Permission = "documents:generate"
+	PermissionEditDocuments     Permission = "documents:edit"
+	PermissionManageUsers       Permission = "users:manage"
+	// PermissionOverrideLimitations lets an attorney plead a claim the
+	// limitations check found time-barred
+	PermissionOverrideLimitations Permission = "limitations:override"
+	// PermissionOverrideFields lets an attorney correct extracted case data
+	// before generation
+	PermissionOverrideFields Permission = "case:override"
+)
+
+// Roles known to the policy table
+const (
+	RoleAdmin     = "admin"
+	RoleAttorney  = "attorney"
+	RoleAssociate = "associate"
+	RoleParalegal = "paralegal"
+)
+
+// RolePolicy is one row of the policy table
+type RolePolicy struct {
+	Permissions []Permission
+	// AllCases lets the role open every case folder; other roles only see
+	// the cases listed in User.AssignedCases
+	AllCases bool
+}
+
+// rolePolicies is the policy table
+var rolePolicies = map[string]RolePolicy{
+	RoleAdmin: {
+		Permissions: []Permission{
+			PermissionBrowseCases, PermissionSelectDocuments, PermissionReviewData,
+			PermissionGenerateDocuments, PermissionEditDocuments, PermissionManageUsers,
+			PermissionOverrideLimitations, PermissionOverrideFields,
+		},
+		AllCases: true,
+	},
+	RoleAttorney: {
+		Permissions: []Permission{
+			PermissionBrowseCases, PermissionSelectDocuments, PermissionReviewData,
+			PermissionGenerateDocuments, PermissionEditDocuments, PermissionOverrideLimitations,
+			PermissionOverrideFields,
+		},
+		AllCases: true,
+	},
+	RoleAssociate: {
+		Permissions: []Permission{
+			PermissionBrowseCases, PermissionSelectDocuments, PermissionReviewData,
+			PermissionGenerateDocuments, PermissionEditDocuments, PermissionOverrideLimitations,
+			PermissionOverrideFields,
+		},
+	},
+	RoleParalegal: {
+		Permissions: []Permission{
+			PermissionBrowseCases, PermissionSelectDocuments, PermissionReviewData,
+		},
+	},
+}
+
+// legacyRoles maps role names used by older users.json files onto the policy table
+var legacyRoles = map[string]string{
+	"lawyer": RoleAttorney,
+	"user":   RoleParalegal,
+}
+
+// NormalizeRole returns the policy table name for a role
+func NormalizeRole(role string) string {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if mapped, ok := legacyRoles[role]; ok {
+		return mapped
+	}
+	return role
+}
+
+// ValidRole reports whether a role, after mapping legacy names, is in the policy table
+func ValidRole(role string) bool {
+	_, ok := rolePolicies[NormalizeRole(role)]
+	return ok
+}
+
+// RoleHasPermission reports whether the policy table grants a permission to a role
+func RoleHasPermission(role string, permission Permission) bool {
+	policy, ok := rolePolicies[NormalizeRole(role)]
+	if !ok {
+		return false
+	}
+	for _, granted := range policy.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the user is active and their role grants a permission
+func (u *User) Can(permission Permission) bool {
+	return u != nil && u.Active && RoleHasPermission(u.Role, permission)
+}
+
+// CaseAccess decides which case folders a user may open
+type CaseAccess struct {
+	allCases bool
+	folders  []string
+}
+
+// NewCaseAccess builds the case scope for a user. Assignments may be case
+// folder paths or case IDs; IDs are resolved through the case store.
+func NewCaseAccess(user *User, store CaseStore) *CaseAccess {
+	access := &CaseAccess{}
+	if user == nil || !user.Active {
+		return access
+	}
+	if policy, ok := rolePolicies[NormalizeRole(user.Role)]; ok && policy.AllCases {
+		access.allCases = true
+		return access
+	}
+
+	for _, assignment := range user.AssignedCases {
+		if !strings.Contains(assignment, "/") && store != nil {
+			if metadata, err := store.GetCase(assignment); err == nil {
+				access.folders = append(access.folders, cleanStorePath(metadata.Folder))
+				continue
+			}
+		}
+		if folder := cleanStorePath(assignment); folder != "" {
+			access.folders = append(access.folders, folder)
+		}
+	}
+	return access
+}
+
+// AllCases reports whether the user is not restricted to assigned cases
+func (a *CaseAccess) AllCases() bool {
+	return a.allCases
+}
+
+// AllowsPath reports whether a case folder, or a document inside one, is assigned
+func (a *CaseAccess) AllowsPath(objectPath string) bool {
+	if a.allCases {
+		return true
+	}
+	objectPath = cleanStorePath(objectPath)
+	for _, folder := range a.folders {
+		if objectPath == folder || strings.HasPrefix(objectPath, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsBrowsing reports whether a folder should be shown while navigating to
+// assigned cases: it is assigned, inside an assigned case, or contains one
+func (a *CaseAccess) AllowsBrowsing(folder string) bool {
+	if a.AllowsPath(folder) {
+		return true
+	}
+	folder = cleanStorePath(folder)
+	for _, assigned := range a.folders {
+		if folder == "" || strings.HasPrefix(assigned, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"time"
+)
+
+// CaseAnalysisReportVersion is the version of the CaseAnalysisReport layout.
+// Bump it whenever a field is renamed or removed so API clients can tell.
+const CaseAnalysisReportVersion = "1"
+
+// CaseAnalysisReport is the cross-document analysis of a case: every selected
+// document through the correlation engine, and the composite timeline,
+// evidence chains, violation patterns and narratives built from them
+type CaseAnalysisReport struct {
+	Version           string                    `json:"version"`
+	CaseID            string                    `json:"caseId"`
+	CaseName          string                    `json:"caseName"`
+	CaseFolder        string                    `json:"caseFolder"`
+	GeneratedAt       time.Time                 `json:"generatedAt"`
+	Documents         []CaseAnalysisDocument    `json:"documents"`
+	Correlation       CorrelationAnalysisResult `json:"correlation"`
+	Timeline          CompositeTimeline         `json:"timeline"`
+	EvidenceChains    EvidenceChainAnalysis     `json:"evidenceChains"`
+	ViolationPatterns ViolationPatternAnalysis  `json:"violationPatterns"`
+	Narratives        CaseNarrativeAnalysis     `json:"narratives"`
+}
+
+// CaseAnalysisDocument is one document of a case analysis. Documents that
+// couldn't be extracted are listed with the error and left out of the analysis.
+type CaseAnalysisDocument struct {
+	Path         string            `json:"path"`
+	Name         string            `json:"name"`
+	DocumentType DocumentType      `json:"documentType"`
+	Pages        int               `json:"pages,omitempty"`
+	Analysis     *DocumentAnalysis `json:"analysis,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// CaseAnalysisService runs the correlation, timeline, evidence chain, violation
+// pattern and narrative engines over the documents of a case
+type CaseAnalysisService struct {
+	documents *DocumentService
+}
+
+// NewCaseAnalysisService creates a case analysis service that extracts
+// documents through the document service and its extraction cache
+func NewCaseAnalysisService(documents *DocumentService) *CaseAnalysisService {
+	return &CaseAnalysisService{documents: documents}
+}
+
+// AnalyzeCase extracts each document and runs the analysis engines over them.
+// The engines keep state between documents, so each report gets new ones.
+func (s *CaseAnalysisService) AnalyzeCase(ctx context.Context, source DocumentSource, metadata *CaseMetadata, documentPaths []string) (*CaseAnalysisReport, error) {
+	started := time.Now()
+	report := &CaseAnalysisReport{
+		Version:     CaseAnalysisReportVersion,
+		CaseID:      metadata.ID,
+		CaseName:    metadata.Name,
+		CaseFolder:  metadata.Folder,
+		GeneratedAt: started,
+		Documents:   []CaseAnalysisDocument{},
+	}
+
+	correlation := NewMultiDocumentCorrelationEngine()
+	var analyses []DocumentAnalysis
+	for _, documentPath := range documentPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		document := CaseAnalysisDocument{
+			Path:         documentPath,
+			Name:         path.Base(documentPath),
+			DocumentType: correlationDocumentType(s.documents.determineContentType(path.Base(documentPath))),
+		}
+		content, err := s.documents.ExtractDocument(ctx, source, documentPath)
+		if err != nil {
+			log.Printf("[CASE_ANALYSIS] Skipping %s: %v", document.Name, err)
+			document.Error = err.Error()
+			report.Documents = append(report.Documents, document)
+			continue
+		}
+		document.Pages = content.PageCount
+		analysis := correlation.AnalyzeDocument(documentPath, content.RawText, document.DocumentType)
+		document.Analysis = &analysis
+		analyses = append(analyses, analysis)
+		report.Documents = append(report.Documents, document)
+	}
+	if len(analyses) == 0 {
+		return nil, fmt.Errorf("no documents of case %s could be analyzed", metadata.ID)
+	}
+
+	report.Correlation = correlation.CorrelateDocuments()
+	report.Timeline = NewTimelineCorrelationEngine().BuildCompositeTimeline(analyses)
+	report.EvidenceChains = NewEvidenceChainBuilder().BuildEvidenceChains(report.Correlation)
+	report.ViolationPatterns = NewViolationPatternAnalyzer().AnalyzeViolationPatterns(analyses)
+	report.Narratives = NewCaseNarrativeBuilder().BuildComprehensiveNarrative(report.Correlation, report.EvidenceChains, report.ViolationPatterns)
+
+	log.Printf("[CASE_ANALYSIS] Analyzed case %s: %d of %d documents, %d timeline events, %d evidence chains in %s",
+		metadata.ID, len(analyses), len(documentPaths), len(report.Timeline.TimelineEvents),
+		len(report.EvidenceChains.BuiltChains), time.Since(started).Round(time.Millisecond))
+	return report, nil
+}
+
+// correlationDocumentType maps the content type the pipeline derives from a
+// file name to the document types the correlation engine understands
+func correlationDocumentType(contentType string) DocumentType {
+	switch contentType {
+	case "attorney_notes":
+		return DocTypeAttorneyNotes
+	case "adverse_action":
+		return DocTypeAdverseAction
+	case "civil_cover_sheet":
+		return DocTypeCivilCover
+	case "summons", "summons_equifax":
+		return DocTypeSummons
+	}
+	return DocTypeOther
+}
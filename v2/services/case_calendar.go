@@ -0,0 +1,204 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	calendarProductID = "-//Mallon Legal//Case Deadlines//EN"
+	calendarUIDDomain = "mallon-legal"
+
+	// iCalendar content lines are folded at 75 octets (RFC 5545 § 3.1)
+	calendarLineLimit = 75
+)
+
+// calendarAlarmDays are the reminders put on every event, in days before it
+var calendarAlarmDays = []int{7, 1}
+
+// CalendarEvent is one all-day entry in a case calendar
+type CalendarEvent struct {
+	UID         string    `json:"uid"`
+	Date        time.Time `json:"date"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+}
+
+// CaseCalendar collects the deadlines and service milestones of a case and
+// writes them as an RFC 5545 iCalendar feed. Event UIDs depend only on the
+// case and the deadline or milestone they describe, so importing the feed
+// again updates events instead of duplicating them.
+type CaseCalendar struct {
+	CaseID string
+	Name   string
+	events map[string]CalendarEvent
+
+	// now is used for DTSTAMP
+	now func() time.Time
+}
+
+// NewCaseCalendar creates an empty calendar for a case
+func NewCaseCalendar(caseID, name string) *CaseCalendar {
+	return &CaseCalendar{
+		CaseID: caseID,
+		Name:   name,
+		events: make(map[string]CalendarEvent),
+		now:    time.Now,
+	}
+}
+
+// AddDeadlines adds statutory deadlines; a deadline already in the calendar is replaced
+func (cal *CaseCalendar) AddDeadlines(deadlines ...StatutoryDeadline) {
+	for _, deadline := range deadlines {
+		if deadline.DeadlineDate.IsZero() {
+			continue
+		}
+		description := deadline.StatutoryBasis
+		if deadline.PenaltyForMissing != "" {
+			description += "\nIf missed: " + deadline.PenaltyForMissing
+		}
+		cal.add(CalendarEvent{
+			UID:         cal.uid("deadline", deadline.DeadlineID),
+			Date:        deadline.DeadlineDate,
+			Summary:     deadline.DeadlineDescription,
+			Description: description,
+			Category:    "Statutory Deadline",
+		})
+	}
+}
+
+// AddMilestones adds service milestones, one event per defendant and milestone type
+func (cal *CaseCalendar) AddMilestones(milestones ...ServiceMilestone) {
+	for _, milestone := range milestones {
+		if milestone.DueDate.IsZero() {
+			continue
+		}
+		summary := milestone.MilestoneType
+		if milestone.DefendantID != "" {
+			summary = fmt.Sprintf("%s - %s", milestone.MilestoneType, milestone.DefendantID)
+		}
+		description := milestone.Notes
+		if milestone.Status != "" {
+			description = strings.TrimSpace(fmt.Sprintf("Status: %s\n%s", milestone.Status, milestone.Notes))
+		}
+		cal.add(CalendarEvent{
+			UID:         cal.uid("milestone", milestone.DefendantID, milestone.MilestoneType),
+			Date:        milestone.DueDate,
+			Summary:     summary,
+			Description: description,
+			Category:    "Service Milestone",
+		})
+	}
+}
+
+// Events returns the calendar's events in date order
+func (cal *CaseCalendar) Events() []CalendarEvent {
+	events := make([]CalendarEvent, 0, len(cal.events))
+	for _, event := range cal.events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].UID < events[j].UID
+	})
+	return events
+}
+
+// ICS renders the calendar as an iCalendar document
+func (cal *CaseCalendar) ICS() []byte {
+	var b strings.Builder
+	stamp := cal.now().UTC().Format("20060102T150405Z")
+
+	writeCalendarLine(&b, "BEGIN:VCALENDAR")
+	writeCalendarLine(&b, "VERSION:2.0")
+	writeCalendarLine(&b, "PRODID:"+calendarProductID)
+	writeCalendarLine(&b, "CALSCALE:GREGORIAN")
+	writeCalendarLine(&b, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeCalendarLine(&b, "X-WR-CALNAME:"+escapeCalendarText(cal.Name))
+	}
+
+	for _, event := range cal.Events() {
+		day := calendarDay(event.Date)
+		writeCalendarLine(&b, "BEGIN:VEVENT")
+		writeCalendarLine(&b, "UID:"+event.UID)
+		writeCalendarLine(&b, "DTSTAMP:"+stamp)
+		writeCalendarLine(&b, "DTSTART;VALUE=DATE:"+day.Format("20060102"))
+		writeCalendarLine(&b, "DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format("20060102"))
+		writeCalendarLine(&b, "SUMMARY:"+escapeCalendarText(event.Summary))
+		if event.Description != "" {
+			writeCalendarLine(&b, "DESCRIPTION:"+escapeCalendarText(event.Description))
+		}
+		writeCalendarLine(&b, "CATEGORIES:"+escapeCalendarText(event.Category))
+		writeCalendarLine(&b, "TRANSP:TRANSPARENT")
+		for _, days := range calendarAlarmDays {
+			writeCalendarLine(&b, "BEGIN:VALARM")
+			writeCalendarLine(&b, "ACTION:DISPLAY")
+			writeCalendarLine(&b, fmt.Sprintf("TRIGGER:-P%dD", days))
+			writeCalendarLine(&b, "DESCRIPTION:"+escapeCalendarText(fmt.Sprintf("Due in %d %s: %s", days, pluralDays(days), event.Summary)))
+			writeCalendarLine(&b, "END:VALARM")
+		}
+		writeCalendarLine(&b, "END:VEVENT")
+	}
+
+	writeCalendarLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// add stores an event, replacing one with the same UID
+func (cal *CaseCalendar) add(event CalendarEvent) {
+	cal.events[event.UID] = event
+}
+
+// uid builds a stable event UID from the case and the parts naming the event
+func (cal *CaseCalendar) uid(kind string, parts ...string) string {
+	hash := sha1.Sum([]byte(strings.Join(append([]string{cal.CaseID, kind}, parts...), "|")))
+	return fmt.Sprintf("%s-%s@%s", kind, hex.EncodeToString(hash[:])[:20], calendarUIDDomain)
+}
+
+// escapeCalendarText escapes a TEXT value (RFC 5545 § 3.3.11)
+func escapeCalendarText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// writeCalendarLine writes a content line, folding it at 75 octets without
+// splitting a UTF-8 character
+func writeCalendarLine(b *strings.Builder, line string) {
+	limit := calendarLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = calendarLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// pluralDays returns "day" or "days" for a count
+func pluralDays(days int) string {
+	if days == 1 {
+		return "day"
+	}
+	return "days"
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStorageConfigPath = "./config/storage.json"
+	caseIndexFile            = ".cases.json"
+)
+
+// ErrCaseObjectNotFound is returned when a path does not exist in the case store
+var ErrCaseObjectNotFound = errors.New("not found")
+
+// CaseStore reads and writes case folders and their documents for one tenant.
+// Paths are slash-separated and relative to the tenant root, e.g. "CASES/Youssef/notes.pdf".
+type CaseStore interface {
+	DocumentSource
+
+	Backend() string
+	Tenant() string
+
+	Write(objectPath string, data []byte) error
+
+	// EnsureCase registers a case folder. The provider names the document source the
+	// folder lives in; folders in the case store itself use ProviderCaseStore.
+	EnsureCase(provider, folder string) (*CaseMetadata, error)
+	GetCase(caseID string) (*CaseMetadata, error)
+	ListCases() ([]CaseMetadata, error)
+	UpdateCase(metadata CaseMetadata) error
+}
+
+// StoredObject describes a file or folder in the case store
+type StoredObject struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	IsDirectory bool      `json:"isDirectory"`
+}
+
+// CaseMetadata maps a case folder to a stable case ID
+type CaseMetadata struct {
+	ID         string    `json:"id"`
+	Tenant     string    `json:"tenant"`
+	Provider   string    `json:"provider,omitempty"`
+	Folder     string    `json:"folder"`
+	Name       string    `json:"name"`
+	ClientName string    `json:"clientName,omitempty"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// CaseStoreConfig configures case storage; see config/storage.json
+type CaseStoreConfig struct {
+	Backend              string               `json:"backend"`
+	Tenant               string               `json:"tenant"`
+	DocumentsFolder      string               `json:"documentsFolder"`
+	SavedDocumentsFolder string               `json:"savedDocumentsFolder"`
+	Local                LocalCaseStoreConfig `json:"local"`
+	S3                   S3CaseStoreConfig    `json:"s3"`
+
+	// Sources configures the other providers the Step 0 folder browser can read from
+	Sources DocumentSourcesConfig `json:"sources"`
+
+	// Database configures the matter database that outlives sessions
+	Database MatterStoreConfig `json:"database"`
+
+	// ExtractionCache configures the on-disk cache of extracted and analyzed documents
+	ExtractionCache ExtractionCacheConfig `json:"extractionCache"`
+
+	// ClassificationLabels configures where corrected document types are kept
+	ClassificationLabels ClassificationLabelsConfig `json:"classificationLabels"`
+}
+
+// LocalCaseStoreConfig configures the local filesystem backend
+type LocalCaseStoreConfig struct {
+	Root string `json:"root"`
+}
+
+// S3CaseStoreConfig configures the S3-compatible backend. Credentials are
+// only read from the environment so they never end up in the config file.
+type S3CaseStoreConfig struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`
+	UsePathStyle    bool   `json:"usePathStyle"`
+	CacheDir        string `json:"cacheDir"`
+	AccessKeyID     string `json:"-"`
+	SecretAccessKey string `json:"-"`
+	SessionToken    string `json:"-"`
+}
+
+var (
+	defaultCaseStore     CaseStore
+	defaultCaseStoreCfg  *CaseStoreConfig
+	defaultCaseStoreOnce sync.Once
+
+	caseIDUnsafePattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// DefaultCaseStore returns the process-wide case store built from the storage config
+func DefaultCaseStore() (CaseStore, *CaseStoreConfig) {
+	defaultCaseStoreOnce.Do(func() {
+		config, err := LoadCaseStoreConfig()
+		if err != nil {
+			log.Printf("[CASE_STORE] Warning: %v - using defaults", err)
+		}
+
+		store, err := NewCaseStore(config)
+		if err != nil {
+			log.Printf("[CASE_STORE] Warning: could not open %s store: %v - falling back to local storage", config.Backend, err)
+			config.Backend = "local"
+			store = NewLocalCaseStore(config.Local.Root, config.Tenant)
+		}
+
+		defaultCaseStore = store
+		defaultCaseStoreCfg = config
+		log.Printf("[CASE_STORE] Using %s case store for tenant %s", store.Backend(), store.Tenant())
+	})
+	return defaultCaseStore, defaultCaseStoreCfg
+}
+
+// LoadCaseStoreConfig reads the storage config file and applies environment overrides.
+// The returned config is always usable, even when an error is reported.
+func LoadCaseStoreConfig() (*CaseStoreConfig, error) {
+	config := &CaseStoreConfig{
+		Backend:              "local",
+		Tenant:               "default",
+		DocumentsFolder:      "artifacts",
+		SavedDocumentsFolder: "saved_documents",
+		Local:                LocalCaseStoreConfig{Root: "./data/cases"},
+		S3:                   S3CaseStoreConfig{Region: "us-east-1", UsePathStyle: true, CacheDir: "./data/s3-cache"},
+		Sources: DocumentSourcesConfig{
+			Default:  ProviderCaseStore,
+			CacheDir: "./data/source-cache",
+			S3:       S3CaseStoreConfig{Region: "us-east-1", UsePathStyle: true},
+		},
+		Database:        MatterStoreConfig{Path: "./data/matters.db"},
+		ExtractionCache: ExtractionCacheConfig{Dir: "./data/extraction-cache"},
+		ClassificationLabels: ClassificationLabelsConfig{File: "./data/classification-labels.json"},
+	}
+
+	configPath := envOrDefault("MALLON_STORAGE_CONFIG", defaultStorageConfigPath)
+
+	var loadErr error
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			loadErr = fmt.Errorf("invalid storage config %s: %v", configPath, err)
+		}
+	} else if !os.IsNotExist(err) || configPath != defaultStorageConfigPath {
+		loadErr = fmt.Errorf("could not read storage config %s: %v", configPath, err)
+	}
+
+	overrides := []struct {
+		env    string
+		target *string
+	}{
+		{"MALLON_STORAGE_BACKEND", &config.Backend},
+		{"MALLON_TENANT", &config.Tenant},
+		{"MALLON_DOCUMENTS_FOLDER", &config.DocumentsFolder},
+		{"MALLON_SAVED_DOCUMENTS_FOLDER", &config.SavedDocumentsFolder},
+		{"MALLON_CASES_ROOT", &config.Local.Root},
+		{"MALLON_S3_ENDPOINT", &config.S3.Endpoint},
+		{"MALLON_S3_REGION", &config.S3.Region},
+		{"MALLON_S3_BUCKET", &config.S3.Bucket},
+		{"MALLON_S3_PREFIX", &config.S3.Prefix},
+		{"MALLON_S3_CACHE_DIR", &config.S3.CacheDir},
+		{"AWS_ACCESS_KEY_ID", &config.S3.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", &config.S3.SecretAccessKey},
+		{"AWS_SESSION_TOKEN", &config.S3.SessionToken},
+		{"MALLON_DOCUMENT_PROVIDER", &config.Sources.Default},
+		{"MALLON_SOURCE_LOCAL_ROOT", &config.Sources.Local.Root},
+		{"MALLON_WEBDAV_URL", &config.Sources.WebDAV.URL},
+		{"MALLON_WEBDAV_USERNAME", &config.Sources.WebDAV.Username},
+		{"MALLON_WEBDAV_PASSWORD", &config.Sources.WebDAV.Password},
+		{"MALLON_SOURCE_S3_ENDPOINT", &config.Sources.S3.Endpoint},
+		{"MALLON_SOURCE_S3_BUCKET", &config.Sources.S3.Bucket},
+		{"MALLON_SOURCE_S3_PREFIX", &config.Sources.S3.Prefix},
+		{"MALLON_DATABASE_PATH", &config.Database.Path},
+		{"MALLON_EXTRACTION_CACHE_DIR", &config.ExtractionCache.Dir},
+		{"MALLON_CLASSIFICATION_LABELS", &config.ClassificationLabels.File},
+	}
+	for _, override := range overrides {
+		if value, ok := os.LookupEnv(override.env); ok {
+			*override.target = value
+		}
+	}
+	if value, ok := os.LookupEnv("MALLON_S3_PATH_STYLE"); ok {
+		config.S3.UsePathStyle = value != "false" && value != "0"
+	}
+
+	config.Backend = strings.ToLower(strings.TrimSpace(config.Backend))
+	if config.Tenant == "" {
+		config.Tenant = "default"
+	}
+	if config.Sources.Default == "" {
+		config.Sources.Default = ProviderCaseStore
+	}
+
+	// An S3 source bucket uses the case store's AWS credentials unless the user signs in
+	if config.Sources.S3.AccessKeyID == "" {
+		config.Sources.S3.AccessKeyID = config.S3.AccessKeyID
+		config.Sources.S3.SecretAccessKey = config.S3.SecretAccessKey
+		config.Sources.S3.SessionToken = config.S3.SessionToken
+	}
+
+	return config, loadErr
+}
+
+// NewCaseStore opens the backend named in the config
+func NewCaseStore(config *CaseStoreConfig) (CaseStore, error) {
+	switch config.Backend {
+	case "", "local":
+		return NewLocalCaseStore(config.Local.Root, config.Tenant), nil
+	case "s3":
+		return NewS3CaseStore(config.S3, config.Tenant)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.Backend)
+	}
+}
+
+// GlobCaseStore returns the paths in a folder whose names match a path.Match pattern
+func GlobCaseStore(store CaseStore, pattern string) ([]string, error) {
+	folder, namePattern := path.Split(cleanStorePath(pattern))
+	objects, err := store.List(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, object := range objects {
+		if object.IsDirectory {
+			continue
+		}
+		if matched, _ := path.Match(namePattern, object.Name); matched {
+			matches = append(matches, object.Path)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// cleanStorePath normalizes a store path and keeps it inside the tenant root
+func cleanStorePath(objectPath string) string {
+	cleaned := path.Clean("/" + strings.ReplaceAll(objectPath, "\\", "/"))
+	return strings.TrimPrefix(cleaned, "/")
+}
+
+// caseRegistry keeps the case ID index shared by every backend
+type caseRegistry struct {
+	mu     sync.Mutex
+	tenant string
+	read   func(string) ([]byte, error)
+	write  func(string, []byte) error
+}
+
+// load reads the case index, treating a missing index as empty
+func (r *caseRegistry) load() (map[string]CaseMetadata, error) {
+	cases := make(map[string]CaseMetadata)
+
+	data, err := r.read(caseIndexFile)
+	if err != nil {
+		if errors.Is(err, ErrCaseObjectNotFound) {
+			return cases, nil
+		}
+		return nil, fmt.Errorf("failed to read case index: %v", err)
+	}
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse case index: %v", err)
+	}
+	return cases, nil
+}
+
+func (r *caseRegistry) save(cases map[string]CaseMetadata) error {
+	data, err := json.MarshalIndent(cases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode case index: %v", err)
+	}
+	return r.write(caseIndexFile, data)
+}
+
+// ensure returns the case for a provider's folder, registering it on first use
+func (r *caseRegistry) ensure(provider, folder string) (*CaseMetadata, error) {
+	folder = cleanStorePath(folder)
+	if provider == ProviderCaseStore {
+		provider = ""
+	}
+	if folder == "" {
+		return nil, fmt.Errorf("case folder is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cases, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range cases {
+		if existing.Provider == provider && existing.Folder == folder {
+			return &existing, nil
+		}
+	}
+
+	// IDs come from the folder name, with a suffix when two folders share a name
+	base := strings.Trim(caseIDUnsafePattern.ReplaceAllString(strings.ToLower(path.Base(folder)), "-"), "-")
+	if base == "" {
+		base = "case"
+	}
+	id := base
+	for n := 2; ; n++ {
+		if _, taken := cases[id]; !taken {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	now := time.Now()
+	metadata := CaseMetadata{
+		ID:        id,
+		Tenant:    r.tenant,
+		Provider:  provider,
+		Folder:    folder,
+		Name:      path.Base(folder),
+		Status:    "open",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	cases[id] = metadata
+	if err := r.save(cases); err != nil {
+		return nil, err
+	}
+
+	if provider != "" {
+		log.Printf("[CASE_STORE] Registered case %s for %s folder %s", id, provider, folder)
+	} else {
+		log.Printf("[CASE_STORE] Registered case %s for folder %s", id, folder)
+	}
+	return &metadata, nil
+}
+
+func (r *caseRegistry) get(caseID string) (*CaseMetadata, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cases, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	metadata, exists := cases[caseID]
+	if !exists {
+		return nil, fmt.Errorf("case %s: %w", caseID, ErrCaseObjectNotFound)
+	}
+	return &metadata, nil
+}
+
+func (r *caseRegistry) list() ([]CaseMetadata, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cases, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]CaseMetadata, 0, len(cases))
+	for _, metadata := range cases {
+		list = append(list, metadata)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+func (r *caseRegistry) update(metadata CaseMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cases, err := r.load()
+	if err != nil {
+		return err
+	}
+	existing, exists := cases[metadata.ID]
+	if !exists {
+		return fmt.Errorf("case %s: %w", metadata.ID, ErrCaseObjectNotFound)
+	}
+
+	// The folder, tenant and creation time are fixed once a case is registered
+	metadata.Provider = existing.Provider
+	metadata.Folder = existing.Folder
+	metadata.Tenant = existing.Tenant
+	metadata.CreatedAt = existing.CreatedAt
+	metadata.UpdatedAt = time.Now()
+	cases[metadata.ID] = metadata
+	return r.save(cases)
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// labelTextLimit is how much of a labeled document's text is kept to train on
+const labelTextLimit = 20000
+
+// ClassificationLabelsConfig configures where corrected document types are
+// kept; an empty File turns labeling off
+type ClassificationLabelsConfig struct {
+	File string `json:"file"`
+}
+
+// ClassificationLabel is a document type a user set for a document in Step 1,
+// with the text the learned model is trained on
+type ClassificationLabel struct {
+	Path         string `json:"path"`
+	Name         string `json:"name"`
+	ContentHash  string `json:"contentHash,omitempty"`
+	DocumentType string `json:"documentType"`
+	// Predicted is what the classifier said before the correction
+	Predicted string    `json:"predicted,omitempty"`
+	Text      string    `json:"text"`
+	LabeledBy string    `json:"labeledBy"`
+	LabeledAt time.Time `json:"labeledAt"`
+}
+
+// ClassificationLabelStore keeps the labeled documents in a JSON file, one
+// label per document path
+type ClassificationLabelStore struct {
+	file string
+
+	mu     sync.RWMutex
+	labels map[string]ClassificationLabel
+}
+
+var (
+	defaultClassificationLabels     *ClassificationLabelStore
+	defaultClassificationLabelsOnce sync.Once
+)
+
+// DefaultClassificationLabels returns the label store configured in
+// config/storage.json, or nil when labeling is turned off or the file can't be read
+func DefaultClassificationLabels() *ClassificationLabelStore {
+	defaultClassificationLabelsOnce.Do(func() {
+		_, config := DefaultCaseStore()
+		if config.ClassificationLabels.File == "" {
+			log.Printf("[CLASSIFICATION_LABELS] No labels file configured - document types can't be corrected")
+			return
+		}
+
+		store, err := OpenClassificationLabelStore(config.ClassificationLabels.File)
+		if err != nil {
+			log.Printf("[CLASSIFICATION_LABELS] Warning: %v - document types can't be corrected", err)
+			return
+		}
+		defaultClassificationLabels = store
+		log.Printf("[CLASSIFICATION_LABELS] Using %s with %d labeled documents", store.file, len(store.labels))
+	})
+	return defaultClassificationLabels
+}
+
+// OpenClassificationLabelStore reads the labels file, which doesn't have to exist yet
+func OpenClassificationLabelStore(file string) (*ClassificationLabelStore, error) {
+	store := &ClassificationLabelStore{file: file, labels: make(map[string]ClassificationLabel)}
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classification labels %s: %v", file, err)
+	}
+	var labels []ClassificationLabel
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("invalid classification labels %s: %v", file, err)
+	}
+	for _, label := range labels {
+		store.labels[label.Path] = label
+	}
+	return store, nil
+}
+
+// Labels returns every labeled document, oldest first
+func (s *ClassificationLabelStore) Labels() []ClassificationLabel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLabels()
+}
+
+// Label returns the label of a document, by its path or, for a document that
+// was moved or copied, by its content hash
+func (s *ClassificationLabelStore) Label(path, contentHash string) (*ClassificationLabel, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if label, ok := s.labels[path]; ok {
+		return &label, true
+	}
+	if contentHash == "" {
+		return nil, false
+	}
+	for _, label := range s.labels {
+		if label.ContentHash == contentHash {
+			return &label, true
+		}
+	}
+	return nil, false
+}
+
+// Save stores a label, replacing any earlier label of the document, and
+// rewrites the file through a temporary file
+func (s *ClassificationLabelStore) Save(label ClassificationLabel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.labels[label.Path]
+	s.labels[label.Path] = label
+	if err := s.write(); err != nil {
+		if existed {
+			s.labels[label.Path] = previous
+		} else {
+			delete(s.labels, label.Path)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ClassificationLabelStore) sortedLabels() []ClassificationLabel {
+	labels := make([]ClassificationLabel, 0, len(s.labels))
+	for _, label := range s.labels {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].LabeledAt.Before(labels[j].LabeledAt)
+	})
+	return labels
+}
+
+func (s *ClassificationLabelStore) write() error {
+	data, err := json.MarshalIndent(s.sortedLabels(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode classification labels: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0755); err != nil {
+		return fmt.Errorf("failed to create classification labels folder: %v", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(s.file), filepath.Base(s.file)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write classification labels: %v", err)
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), s.file)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write classification labels: %v", err)
+	}
+	return nil
+}
+
+// DocumentTypeReview is a document's type as Step 1 shows it: what the
+// classifier predicts and the type a user set, if any
+type DocumentTypeReview struct {
+	Path       string
+	Predicted  string
+	Confidence float64
+	// LearnedModel is set when the learned model contributed to the prediction
+	LearnedModel bool
+	Label        *ClassificationLabel
+}
+
+// DocumentType is the label if there is one, and the prediction otherwise
+func (r *DocumentTypeReview) DocumentType() string {
+	if r.Label != nil {
+		return r.Label.DocumentType
+	}
+	return r.Predicted
+}
+
+// ReviewDocumentType classifies a document for Step 1, from the extraction
+// cache when the document was extracted before
+func (s *DocumentService) ReviewDocumentType(ctx context.Context, source DocumentSource, docPath string) (*DocumentTypeReview, error) {
+	review, _, _, err := s.reviewDocumentType(ctx, source, docPath)
+	return review, err
+}
+
+func (s *DocumentService) reviewDocumentType(ctx context.Context, source DocumentSource, docPath string) (*DocumentTypeReview, *ExtractedContent, string, error) {
+	classifier := s.documentClassifier()
+	if classifier == nil {
+		return nil, nil, "", fmt.Errorf("document classifier not initialized")
+	}
+	content, contentHash, _, err := s.extractDocument(ctx, source, docPath)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	classification, err := classifier.ClassifyDocument(docPath, content.RawText)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	review := &DocumentTypeReview{
+		Path:       docPath,
+		Predicted:  classifier.GetDocumentTypeName(classification.PrimaryType),
+		Confidence: classification.Confidence,
+	}
+	for _, indicator := range classification.ContentIndicators {
+		if indicator.MatchType == "model" {
+			review.LearnedModel = true
+		}
+	}
+	if s.labels != nil {
+		review.Label, _ = s.labels.Label(docPath, contentHash)
+	}
+	return review, content, contentHash, nil
+}
+
+// LabelDocumentType stores the type a user set for a document and retrains
+// the learned model with it
+func (s *DocumentService) LabelDocumentType(ctx context.Context, source DocumentSource, docPath, documentType, username string) (*DocumentTypeReview, error) {
+	if s.labels == nil {
+		return nil, fmt.Errorf("document type labels are not configured")
+	}
+	docType, ok := DocumentTypeByName(documentType)
+	if !ok || docType == DocumentTypeUnknown {
+		return nil, fmt.Errorf("unknown document type %q", documentType)
+	}
+
+	review, content, contentHash, err := s.reviewDocumentType(ctx, source, docPath)
+	if err != nil {
+		return nil, err
+	}
+	text := content.RawText
+	if len(text) > labelTextLimit {
+		text = strings.ToValidUTF8(text[:labelTextLimit], "")
+	}
+	label := ClassificationLabel{
+		Path:         docPath,
+		Name:         filepath.Base(docPath),
+		ContentHash:  contentHash,
+		DocumentType: documentTypeNames[docType],
+		Predicted:    review.Predicted,
+		Text:         text,
+		LabeledBy:    username,
+		LabeledAt:    time.Now(),
+	}
+	if err := s.labels.Save(label); err != nil {
+		return nil, err
+	}
+	log.Printf("[CLASSIFICATION_LABELS] %s labeled %s as %s (predicted %s)", username, label.Name, label.DocumentType, label.Predicted)
+
+	s.documentClassifier().Train(s.labels.Labels())
+	review.Label = &label
+	return review, nil
+}
+
+// ClassifierMetrics reports the per-type precision and recall of the
+// classifier on the labeled documents
+func (s *DocumentService) ClassifierMetrics() (*ClassifierMetrics, error) {
+	classifier := s.documentClassifier()
+	if classifier == nil {
+		return nil, fmt.Errorf("document classifier not initialized")
+	}
+	if s.labels == nil {
+		return nil, fmt.Errorf("document type labels are not configured")
+	}
+	return classifier.Evaluate(s.labels.Labels()), nil
+}
+
+func (s *DocumentService) documentClassifier() *DocumentClassifier {
+	if s.contentAnalyzer == nil {
+		return nil
+	}
+	return s.contentAnalyzer.DocumentClassifier
+}
+
+// classifyContentType picks the content type a document is analyzed as: the
+// type a user labeled it with, else the one its file name suggests, else the
+// classifier's, so a misspelled file name doesn't leave a document unanalyzed
+func (s *DocumentService) classifyContentType(docPath, contentHash string, content *ExtractedContent) string {
+	fileNameType := s.determineContentType(filepath.Base(docPath))
+	if s.labels != nil {
+		if label, ok := s.labels.Label(docPath, contentHash); ok {
+			docType, _ := DocumentTypeByName(label.DocumentType)
+			return contentTypeFor(docType, fileNameType)
+		}
+	}
+	if fileNameType != "unknown" {
+		return fileNameType
+	}
+	if classifier := s.documentClassifier(); classifier != nil {
+		if classification, err := classifier.ClassifyDocument(docPath, content.RawText); err == nil {
+			return contentTypeFor(classification.PrimaryType, fileNameType)
+		}
+	}
+	return fileNameType
+}
+
+// contentTypeFor returns the pipeline content type of a document type,
+// keeping the file name's more specific summons type
+func contentTypeFor(docType DocumentType, fileNameType string) string {
+	switch docType {
+	case DocumentTypeAttorneyNotes:
+		return "attorney_notes"
+	case DocumentTypeAdverseActionLetter, DocumentTypeDenialLetter:
+		return "adverse_action"
+	case DocumentTypeCivilCoverSheet:
+		return "civil_cover_sheet"
+	case DocumentTypeSummons:
+		if strings.HasPrefix(fileNameType, "summons") {
+			return fileNameType
+		}
+		return "summons"
+	case DocumentTypeComplaint:
+		return "complaint_form"
+	default:
+		return "unknown"
+	}
+}
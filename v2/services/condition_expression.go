@@ -0,0 +1,814 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ConditionExpression is a compiled TemplateSection.ConditionalLogic expression.
+//
+// Expressions read ClientCase fields by their Go names and support
+//   - literals: numbers, 'strings' or "strings", true, false
+//   - operators: ! && || == != < <= > >=, unary minus and parentheses
+//   - len(x), date(x), now(), addDays(date, n), contains(text, part)
+//   - any(list, condition), all(list, condition), count(list, condition)
+//     where the condition can use the fields of each list element
+//
+// For example: len(Defendants) > 1 && PoliceReportFiled
+// or: any(CreditBureauInteractions, Bureau == 'Equifax')
+//
+// Expressions can only read case data; there is no assignment or method call.
+type ConditionExpression struct {
+	Source string
+	root   conditionNode
+}
+
+// conditionNode is a node of a parsed expression
+type conditionNode interface {
+	eval(scopes []reflect.Value) (interface{}, error)
+}
+
+// conditionToken is a lexical token with its position in the source
+type conditionToken struct {
+	kind  string
+	text  string
+	value interface{}
+	pos   int
+}
+
+// conditionParser is a recursive descent parser that resolves field names
+// against the ClientCase type (and list element types inside any/all/count)
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+	depth  int
+	scopes []reflect.Type
+}
+
+const (
+	maxConditionLength = 1000
+	maxConditionDepth  = 32
+)
+
+// conditionAliases keeps the original named conditions working
+var conditionAliases = map[string]string{
+	"has_fraud_details":   "len(FraudDetails) > 0",
+	"has_credit_disputes": "len(CreditBureauInteractions) > 0",
+	"has_defendants":      "len(Defendants) > 0",
+}
+
+var conditionDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"01/02/2006",
+	"1/2/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2006",
+}
+
+// ParseCondition compiles a conditional logic expression against the ClientCase fields
+func ParseCondition(source string) (*ConditionExpression, error) {
+	if len(source) > maxConditionLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxConditionLength)
+	}
+
+	tokens, err := tokenizeCondition(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &conditionParser{
+		tokens: tokens,
+		scopes: []reflect.Type{reflect.TypeOf(ClientCase{})},
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos+1)
+	}
+
+	return &ConditionExpression{Source: source, root: root}, nil
+}
+
+// Evaluate runs the expression against a case and reports whether it holds
+func (ce *ConditionExpression) Evaluate(clientCase *ClientCase) (bool, error) {
+	if clientCase == nil {
+		return false, fmt.Errorf("no case data to evaluate %q against", ce.Source)
+	}
+
+	value, err := ce.root.eval([]reflect.Value{reflect.ValueOf(*clientCase)})
+	if err != nil {
+		return false, fmt.Errorf("%q: %v", ce.Source, err)
+	}
+	return conditionTruthy(value), nil
+}
+
+// tokenizeCondition splits the source into tokens
+func tokenizeCondition(source string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			switch word {
+			case "true", "false":
+				tokens = append(tokens, conditionToken{kind: "literal", text: word, value: word == "true", pos: start})
+			default:
+				tokens = append(tokens, conditionToken{kind: "ident", text: word, pos: start})
+			}
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", string(runes[start:i]), start+1)
+			}
+			tokens = append(tokens, conditionToken{kind: "literal", text: string(runes[start:i]), value: number, pos: start})
+
+		case r == '\'' || r == '"':
+			start := i
+			i++
+			var text strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start+1)
+			}
+			i++
+			tokens = append(tokens, conditionToken{kind: "literal", text: string(runes[start:i]), value: text.String(), pos: start})
+
+		default:
+			start := i
+			operator := string(r)
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					operator = pair
+				}
+			}
+			switch operator {
+			case "&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "-", "(", ")", ",", ".":
+			default:
+				return nil, fmt.Errorf("unexpected character %q at position %d", operator, start+1)
+			}
+			i += len([]rune(operator))
+			tokens = append(tokens, conditionToken{kind: operator, text: operator, pos: start})
+		}
+	}
+
+	return append(tokens, conditionToken{kind: "eof", text: "end of expression", pos: len(runes)}), nil
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() conditionToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+func (p *conditionParser) expect(kind string) error {
+	if token := p.next(); token.kind != kind {
+		return fmt.Errorf("expected %q at position %d, found %q", kind, token.pos+1, token.text)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &conditionBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == "&&" {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &conditionBinary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	switch p.peek().kind {
+	case "==", "!=", "<", "<=", ">", ">=":
+		op := p.next().kind
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &conditionBinary{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (conditionNode, error) {
+	if p.peek().kind == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &conditionNot{operand: operand}, nil
+	}
+	if p.peek().kind == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &conditionNegate{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() (conditionNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxConditionDepth {
+		return nil, fmt.Errorf("expression is nested more than %d levels deep", maxConditionDepth)
+	}
+
+	token := p.next()
+	switch token.kind {
+	case "literal":
+		return &conditionLiteral{value: token.value}, nil
+
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case "ident":
+		if p.peek().kind == "(" {
+			return p.parseCall(token)
+		}
+		if alias, ok := conditionAliases[token.text]; ok {
+			expression, err := ParseCondition(alias)
+			if err != nil {
+				return nil, err
+			}
+			return expression.root, nil
+		}
+		return p.parseField(token)
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos+1)
+}
+
+// parseField resolves a dotted field path against the innermost scope that has it
+func (p *conditionParser) parseField(first conditionToken) (conditionNode, error) {
+	path := []string{first.text}
+	for p.peek().kind == "." {
+		p.next()
+		token := p.next()
+		if token.kind != "ident" {
+			return nil, fmt.Errorf("expected a field name at position %d", token.pos+1)
+		}
+		path = append(path, token.text)
+	}
+
+	for scope := len(p.scopes) - 1; scope >= 0; scope-- {
+		fieldType := conditionElemType(p.scopes[scope])
+		if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		if _, ok := fieldType.FieldByName(path[0]); !ok {
+			continue
+		}
+
+		var indexes [][]int
+		for _, name := range path {
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("%s has no field %q (position %d)", strings.Join(path, "."), name, first.pos+1)
+			}
+			field, ok := fieldType.FieldByName(name)
+			if !ok || field.PkgPath != "" {
+				return nil, fmt.Errorf("unknown field %q in %s (position %d)", name, strings.Join(path, "."), first.pos+1)
+			}
+			indexes = append(indexes, field.Index)
+			fieldType = field.Type
+		}
+
+		return &conditionField{scope: scope, path: path, indexes: indexes, fieldType: fieldType}, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q at position %d", path[0], first.pos+1)
+}
+
+// parseCall parses a function call; any/all/count evaluate their condition per list element
+func (p *conditionParser) parseCall(name conditionToken) (conditionNode, error) {
+	p.next()
+
+	switch name.text {
+	case "any", "all", "count":
+		list, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		field, ok := list.(*conditionField)
+		if !ok || field.fieldType.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("%s() expects a list field as its first argument (position %d)", name.text, name.pos+1)
+		}
+		if element := conditionElemType(field.fieldType.Elem()); element.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s() needs a list of records, %s is a list of %s (position %d)", name.text, strings.Join(field.path, "."), element.Kind(), name.pos+1)
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+
+		p.scopes = append(p.scopes, field.fieldType.Elem())
+		predicate, err := p.parseOr()
+		p.scopes = p.scopes[:len(p.scopes)-1]
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &conditionQuantifier{name: name.text, list: list, predicate: predicate}, nil
+	}
+
+	arity := map[string]int{"len": 1, "date": 1, "now": 0, "addDays": 2, "contains": 2}
+	expected, known := arity[name.text]
+	if !known {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos+1)
+	}
+
+	var args []conditionNode
+	for p.peek().kind != ")" {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch token := p.peek(); token.kind {
+		case ",":
+			p.next()
+		case ")":
+		default:
+			return nil, fmt.Errorf("expected \",\" or \")\" in %s() at position %d, found %q", name.text, token.pos+1, token.text)
+		}
+	}
+	p.next()
+
+	if len(args) != expected {
+		return nil, fmt.Errorf("%s() takes %d arguments, got %d (position %d)", name.text, expected, len(args), name.pos+1)
+	}
+	if field, ok := args[0].(*conditionField); ok && name.text == "len" && !conditionHasLen(field.fieldType) {
+		return nil, fmt.Errorf("len() needs text or a list, %s is a %s (position %d)", strings.Join(field.path, "."), conditionElemType(field.fieldType).Kind(), name.pos+1)
+	}
+	return &conditionCall{name: name.text, args: args}, nil
+}
+
+// conditionElemType dereferences pointer types
+func conditionElemType(fieldType reflect.Type) reflect.Type {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType
+}
+
+// conditionHasLen reports whether len() can measure values of a type
+func conditionHasLen(fieldType reflect.Type) bool {
+	switch conditionElemType(fieldType).Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// conditionLiteral is a constant value
+type conditionLiteral struct {
+	value interface{}
+}
+
+func (n *conditionLiteral) eval(scopes []reflect.Value) (interface{}, error) {
+	return n.value, nil
+}
+
+// conditionField reads a field from one of the active scopes
+type conditionField struct {
+	scope     int
+	path      []string
+	indexes   [][]int
+	fieldType reflect.Type
+}
+
+func (n *conditionField) eval(scopes []reflect.Value) (interface{}, error) {
+	value := scopes[n.scope]
+	for _, index := range n.indexes {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil, nil
+			}
+			value = value.Elem()
+		}
+		value = value.FieldByIndex(index)
+	}
+	return conditionValue(value), nil
+}
+
+// conditionNot negates its operand
+type conditionNot struct {
+	operand conditionNode
+}
+
+func (n *conditionNot) eval(scopes []reflect.Value) (interface{}, error) {
+	value, err := n.operand.eval(scopes)
+	if err != nil {
+		return nil, err
+	}
+	return !conditionTruthy(value), nil
+}
+
+// conditionNegate negates a number
+type conditionNegate struct {
+	operand conditionNode
+}
+
+func (n *conditionNegate) eval(scopes []reflect.Value) (interface{}, error) {
+	value, err := n.operand.eval(scopes)
+	if err != nil {
+		return nil, err
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", conditionTypeName(value))
+	}
+	return -number, nil
+}
+
+// conditionBinary is a logical or comparison operator
+type conditionBinary struct {
+	op          string
+	left, right conditionNode
+}
+
+func (n *conditionBinary) eval(scopes []reflect.Value) (interface{}, error) {
+	left, err := n.left.eval(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short circuit
+	switch n.op {
+	case "&&":
+		if !conditionTruthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(scopes)
+		return conditionTruthy(right), err
+	case "||":
+		if conditionTruthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(scopes)
+		return conditionTruthy(right), err
+	}
+
+	right, err := n.right.eval(scopes)
+	if err != nil {
+		return nil, err
+	}
+	return compareConditionValues(n.op, left, right)
+}
+
+// conditionCall is a built in function call
+type conditionCall struct {
+	name string
+	args []conditionNode
+}
+
+func (n *conditionCall) eval(scopes []reflect.Value) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(scopes)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch n.name {
+	case "now":
+		return time.Now(), nil
+
+	case "len":
+		switch value := args[0].(type) {
+		case string:
+			return float64(len(strings.TrimSpace(value))), nil
+		case reflect.Value:
+			if conditionHasLen(value.Type()) {
+				return float64(value.Len()), nil
+			}
+		case nil:
+			return 0.0, nil
+		}
+		return nil, fmt.Errorf("len() needs text or a list, got %s", conditionTypeName(args[0]))
+
+	case "date":
+		date, ok := conditionDate(args[0])
+		if !ok {
+			return nil, fmt.Errorf("date() could not read %v as a date", args[0])
+		}
+		return date, nil
+
+	case "addDays":
+		date, ok := conditionDate(args[0])
+		days, isNumber := args[1].(float64)
+		if !ok || !isNumber {
+			return nil, fmt.Errorf("addDays() needs a date and a number of days")
+		}
+		return date.AddDate(0, 0, int(days)), nil
+
+	case "contains":
+		text, ok := args[0].(string)
+		part, isText := args[1].(string)
+		if !ok || !isText {
+			return nil, fmt.Errorf("contains() needs two text values")
+		}
+		return strings.Contains(strings.ToLower(text), strings.ToLower(part)), nil
+	}
+
+	return nil, fmt.Errorf("unknown function %q", n.name)
+}
+
+// conditionQuantifier evaluates a condition for every element of a list
+type conditionQuantifier struct {
+	name      string
+	list      conditionNode
+	predicate conditionNode
+}
+
+func (n *conditionQuantifier) eval(scopes []reflect.Value) (interface{}, error) {
+	value, err := n.list.eval(scopes)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.(reflect.Value)
+	if !ok {
+		list = reflect.ValueOf([]interface{}{})
+	}
+
+	matches := 0
+	for i := 0; i < list.Len(); i++ {
+		result, err := n.predicate.eval(append(scopes[:len(scopes):len(scopes)], list.Index(i)))
+		if err != nil {
+			return nil, err
+		}
+		if conditionTruthy(result) {
+			matches++
+		}
+	}
+
+	switch n.name {
+	case "any":
+		return matches > 0, nil
+	case "all":
+		return matches == list.Len(), nil
+	default:
+		return float64(matches), nil
+	}
+}
+
+// conditionValue converts a field value to a bool, float64, string, time or list
+func conditionValue(value reflect.Value) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	if date, ok := value.Interface().(time.Time); ok {
+		return date
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.String:
+		return value.String()
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return conditionValue(value.Elem())
+	}
+	return value
+}
+
+// conditionTruthy decides whether a value counts as true on its own
+func conditionTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return strings.TrimSpace(v) != ""
+	case time.Time:
+		return !v.IsZero()
+	case reflect.Value:
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return v.Len() > 0
+		}
+		return v.IsValid() && !v.IsZero()
+	}
+	return false
+}
+
+// conditionDate reads a time value or parses a date string
+func conditionDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		text := strings.TrimSpace(v)
+		for _, layout := range conditionDateLayouts {
+			if date, err := time.Parse(layout, text); err == nil {
+				return date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareConditionValues applies a comparison operator to two values of the same kind.
+// Text compares case-insensitively and a date compared with text parses the text as a date.
+func compareConditionValues(op string, left, right interface{}) (interface{}, error) {
+	var order int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %s", conditionTypeName(right))
+		}
+		order = compareFloats(l, r)
+
+	case string:
+		if date, isDate := right.(time.Time); isDate {
+			parsed, ok := conditionDate(l)
+			if !ok {
+				return nil, fmt.Errorf("cannot read %q as a date", l)
+			}
+			order = compareTimes(parsed, date)
+			break
+		}
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare text with %s", conditionTypeName(right))
+		}
+		order = strings.Compare(strings.ToLower(l), strings.ToLower(r))
+
+	case time.Time:
+		r, ok := conditionDate(right)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare date with %s", conditionTypeName(right))
+		}
+		order = compareTimes(l, r)
+
+	case bool:
+		r, ok := right.(bool)
+		if !ok || (op != "==" && op != "!=") {
+			return nil, fmt.Errorf("true/false values can only be compared with == or !=")
+		}
+		if l == r {
+			order = 0
+		} else {
+			order = 1
+		}
+
+	default:
+		return nil, fmt.Errorf("cannot compare %s with %s", conditionTypeName(left), conditionTypeName(right))
+	}
+
+	switch op {
+	case "==":
+		return order == 0, nil
+	case "!=":
+		return order != 0, nil
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case math.Abs(a-b) < 1e-9:
+		return 0
+	case a < b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Equal(b):
+		return 0
+	case a.Before(b):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// conditionTypeName names a value's type for error messages
+func conditionTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nothing"
+	case bool:
+		return "true/false"
+	case float64:
+		return "number"
+	case string:
+		return "text"
+	case time.Time:
+		return "date"
+	case reflect.Value:
+		switch value.(reflect.Value).Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return "list"
+		}
+		return "record"
+	}
+	return fmt.Sprintf("%T", value)
+}
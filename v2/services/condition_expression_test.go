@@ -0,0 +1,138 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConditionErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantError  string
+	}{
+		{"any(DisputeMethods, x == 'mail')", "needs a list of records"},
+		{"len(Limitations) > 0", "len() needs text or a list"},
+		{"len(PoliceReportFiled)", "len() needs text or a list"},
+		{"any(ClientName, Bureau == 'Equifax')", "expects a list field"},
+		{"UnknownField", "unknown field"},
+		{"Limitations.NoSuchField", "unknown field"},
+		{"len(Defendants, 1)", "takes 1 arguments"},
+		{"frobnicate(ClientName)", "unknown function"},
+		{"ClientName == 'unterminated", "unterminated string"},
+		{"ClientName ==", "unexpected"},
+		{"(ClientName", "expected \")\""},
+		{"ClientName # 'x'", "unexpected character"},
+		{strings.Repeat("(", maxConditionDepth+1) + "true" + strings.Repeat(")", maxConditionDepth+1), "nested more than"},
+		{strings.Repeat("a", maxConditionLength+1), "longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseCondition(tt.expression)
+			if err == nil {
+				t.Fatalf("ParseCondition(%q) succeeded, want error containing %q", tt.expression, tt.wantError)
+			}
+			if !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("ParseCondition(%q) error = %q, want it to contain %q", tt.expression, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluate(t *testing.T) {
+	clientCase := &ClientCase{
+		ClientName:        "Eman Youseef",
+		FraudDetails:      "Unauthorized charges in Cairo",
+		FraudAmount:       "7,500.00",
+		DiscoveryDate:     time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC),
+		DisputeCount:      3,
+		DisputeMethods:    []string{"mail", "phone"},
+		PoliceReportFiled: true,
+		EstimatedDamages:  7500,
+		Defendants: []Defendant{
+			{Name: "TD Bank", EntityType: "bank"},
+			{Name: "Equifax", EntityType: "credit_bureau"},
+		},
+		CreditBureauInteractions: []CreditBureauInteraction{
+			{Bureau: "Equifax", Type: "dispute", Date: "2024-08-01"},
+			{Bureau: "Experian", Type: "dispute", Date: "2024-08-03"},
+		},
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{"true", true},
+		{"!PoliceReportFiled", false},
+		{"PoliceReportFiled && DisputeCount >= 3", true},
+		{"DisputeCount > 3 || EstimatedDamages == 7500", true},
+		{"-DisputeCount < 0", true},
+		{"len(Defendants) > 1", true},
+		{"len(DisputeMethods) == 2", true},
+		{"len(ClientName) == 12", true},
+		{"len(PoliceReportDetails) == 0", true},
+		{"ClientName == 'eman youseef'", true},
+		{"contains(FraudDetails, 'cairo')", true},
+		{"DiscoveryDate > date('2024-01-01')", true},
+		{"DiscoveryDate == '2024-07-15'", true},
+		{"addDays(DiscoveryDate, 30) < date('2024-08-01')", false},
+		{"AccountOpenDate", false},
+		{"Limitations", false},
+		{"any(CreditBureauInteractions, Bureau == 'Equifax')", true},
+		{"all(CreditBureauInteractions, Type == 'dispute')", true},
+		{"all(Defendants, EntityType == 'bank')", false},
+		{"count(Defendants, EntityType == 'bank') == 1", true},
+		{"any(Defendants, Name == ClientName)", false},
+		{"any(FraudDetailsStructured, Amount != '')", false},
+		{"has_defendants && has_credit_disputes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			condition, err := ParseCondition(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCondition(%q): %v", tt.expression, err)
+			}
+			got, err := condition.Evaluate(clientCase)
+			if err != nil {
+				t.Fatalf("Evaluate(%q): %v", tt.expression, err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluateErrors(t *testing.T) {
+	tests := []string{
+		"ClientName > 3",
+		"PoliceReportFiled < true",
+		"date(ClientName)",
+		"addDays(ClientName, 1)",
+		"contains(DisputeCount, 'x')",
+		"-ClientName",
+	}
+
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			condition, err := ParseCondition(expression)
+			if err != nil {
+				t.Fatalf("ParseCondition(%q): %v", expression, err)
+			}
+			if _, err := condition.Evaluate(&ClientCase{ClientName: "Eman Youseef", DisputeCount: 1}); err == nil {
+				t.Errorf("Evaluate(%q) succeeded, want an error", expression)
+			}
+		})
+	}
+
+	condition, err := ParseCondition("true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := condition.Evaluate(nil); err == nil {
+		t.Error("Evaluate(nil) succeeded, want an error")
+	}
+}
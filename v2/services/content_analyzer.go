@@ -34,7 +34,12 @@ type ExtractionResult struct {
 	Confidence float64     `json:"confidence"`
 	Source     string      `json:"source"`
 	Location   int         `json:"location"`
+	End        int         `json:"end"`
 	Method     string      `json:"method"`
+	Document   string      `json:"document,omitempty"`
+	Page       int         `json:"page,omitempty"`
+	Excerpt    string      `json:"excerpt,omitempty"`
+	Boxes      []SourceBox `json:"boxes,omitempty"`
 }
 
 // LegalAnalysisResult contains comprehensive analysis of legal documents
@@ -404,17 +409,20 @@ func (ca *ContentAnalyzer) extractWithPatternsEnhanced(text, category, patternTy
 			continue
 		}
 		
-		matches := re.FindStringSubmatch(text)
-		if len(matches) > 1 {
-			value := strings.TrimSpace(matches[1])
+		matches := re.FindStringSubmatchIndex(text)
+		if len(matches) > 3 && matches[2] >= 0 {
+			captured := text[matches[2]:matches[3]]
+			value := strings.TrimSpace(captured)
 			if value != "" {
+				start := matches[2] + strings.Index(captured, value)
 				target[field] = ExtractionResult{
 					Field:      field,
 					Value:      value,
 					Confidence: 0.8,
 					Source:     docType,
 					Method:     "Enhanced Pattern Matching",
-					Location:   re.FindStringIndex(text)[0],
+					Location:   start,
+					End:        start + len(value),
 				}
 				break
 			}
@@ -523,17 +531,20 @@ func (ca *ContentAnalyzer) extractWithPatterns(text, category, patternType, fiel
 			continue
 		}
 		
-		matches := re.FindStringSubmatch(text)
-		if len(matches) > 1 {
-			value := strings.TrimSpace(matches[1])
+		matches := re.FindStringSubmatchIndex(text)
+		if len(matches) > 3 && matches[2] >= 0 {
+			captured := text[matches[2]:matches[3]]
+			value := strings.TrimSpace(captured)
 			if value != "" {
+				start := matches[2] + strings.Index(captured, value)
 				target[field] = ExtractionResult{
 					Field:      field,
 					Value:      value,
 					Confidence: 0.8, // Default confidence for pattern matches
 					Source:     "Document Text",
 					Method:     "Regex Pattern",
-					Location:   re.FindStringIndex(text)[0],
+					Location:   start,
+					End:        start + len(value),
 				}
 				break // Use first match
 			}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rule citations used in StatutoryDeadline.StatutoryBasis
+const (
+	ruleTimeComputation    = "Fed. R. Civ. P. 6(a)"
+	ruleMailExtension      = "Fed. R. Civ. P. 6(d)"
+	ruleAnswer             = "Fed. R. Civ. P. 12(a)(1)(A)(i)"
+	ruleAnswerWaiver       = "Fed. R. Civ. P. 12(a)(1)(A)(ii)"
+	ruleServiceTime        = "Fed. R. Civ. P. 4(m)"
+	statuteDiscovery       = "15 U.S.C. § 1681p(1)"
+	statuteRepose          = "15 U.S.C. § 1681p(2)"
+	statuteReinvestigation = "15 U.S.C. § 1681i(a)(1)(A)"
+)
+
+// Periods set by the rules and statutes, in days unless noted
+const (
+	answerDays              = 21
+	waiverAnswerDays        = 60
+	waiverAnswerForeignDays = 90
+	serviceDays             = 90
+	mailExtensionDays       = 3
+	reinvestigationDays     = 30
+	fcraDiscoveryYears      = 2
+	fcraReposeYears         = 5
+)
+
+// DeadlineServiceMethod is how the paper that starts a period was served. It
+// decides whether Rule 6(d) adds three days.
+type DeadlineServiceMethod string
+
+const (
+	ServiceMethodPersonal   DeadlineServiceMethod = "personal"   // Rule 4 or Rule 5(b)(2)(A)-(B)
+	ServiceMethodMail       DeadlineServiceMethod = "mail"       // Rule 5(b)(2)(C)
+	ServiceMethodClerk      DeadlineServiceMethod = "clerk"      // Rule 5(b)(2)(D)
+	ServiceMethodElectronic DeadlineServiceMethod = "electronic" // Rule 5(b)(2)(E), no extension since 2016
+	ServiceMethodConsent    DeadlineServiceMethod = "consent"    // Rule 5(b)(2)(F)
+)
+
+// addsMailDays reports whether Rule 6(d) applies to the service method
+func (m DeadlineServiceMethod) addsMailDays() bool {
+	return m == ServiceMethodMail || m == ServiceMethodClerk || m == ServiceMethodConsent
+}
+
+// FederalHoliday is a legal holiday under Rule 6(a)(6)(A) on the day it is observed
+type FederalHoliday struct {
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+}
+
+// DeadlineCalculator computes federal court deadlines. Periods are counted
+// under Rule 6(a)(1): the trigger day is excluded, every day is counted, and
+// a period ending on a weekend or legal holiday runs to the next court day.
+type DeadlineCalculator struct {
+	mu       sync.Mutex
+	holidays map[int][]FederalHoliday
+
+	// now is used for DaysRemaining
+	now func() time.Time
+}
+
+// NewDeadlineCalculator creates a deadline calculator
+func NewDeadlineCalculator() *DeadlineCalculator {
+	return &DeadlineCalculator{
+		holidays: make(map[int][]FederalHoliday),
+		now:      time.Now,
+	}
+}
+
+// FederalHolidays returns the legal holidays observed in a year, including a
+// New Year's Day of the following year observed on December 31
+func (dc *DeadlineCalculator) FederalHolidays(year int) []FederalHoliday {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if holidays, ok := dc.holidays[year]; ok {
+		return holidays
+	}
+
+	var holidays []FederalHoliday
+	for _, y := range []int{year - 1, year, year + 1} {
+		for _, holiday := range federalHolidaysFor(y) {
+			if holiday.Date.Year() == year {
+				holidays = append(holidays, holiday)
+			}
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+
+	dc.holidays[year] = holidays
+	return holidays
+}
+
+// federalHolidaysFor generates the holidays of 5 U.S.C. § 6103(a) for a year,
+// moved to the day they are observed under § 6103(b)
+func federalHolidaysFor(year int) []FederalHoliday {
+	fixed := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	holidays := []FederalHoliday{
+		{"New Year's Day", observed(fixed(time.January, 1))},
+		{"Birthday of Martin Luther King, Jr.", nthWeekday(year, time.January, time.Monday, 3)},
+		{"Washington's Birthday", nthWeekday(year, time.February, time.Monday, 3)},
+		{"Memorial Day", lastWeekday(year, time.May, time.Monday)},
+		{"Independence Day", observed(fixed(time.July, 4))},
+		{"Labor Day", nthWeekday(year, time.September, time.Monday, 1)},
+		{"Columbus Day", nthWeekday(year, time.October, time.Monday, 2)},
+		{"Veterans Day", observed(fixed(time.November, 11))},
+		{"Thanksgiving Day", nthWeekday(year, time.November, time.Thursday, 4)},
+		{"Christmas Day", observed(fixed(time.December, 25))},
+	}
+	if year >= 2021 {
+		holidays = append(holidays, FederalHoliday{"Juneteenth National Independence Day", observed(fixed(time.June, 19))})
+	}
+	return holidays
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday holiday to Monday
+func observed(date time.Time) time.Time {
+	switch date.Weekday() {
+	case time.Saturday:
+		return date.AddDate(0, 0, -1)
+	case time.Sunday:
+		return date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// nthWeekday returns the nth weekday of a month, e.g. the third Monday in January
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of a month, e.g. the last Monday in May
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// LegalHoliday returns the name of the legal holiday observed on a date, if any
+func (dc *DeadlineCalculator) LegalHoliday(date time.Time) (string, bool) {
+	day := calendarDay(date)
+	for _, holiday := range dc.FederalHolidays(day.Year()) {
+		if holiday.Date.Equal(day) {
+			return holiday.Name, true
+		}
+	}
+	return "", false
+}
+
+// IsCourtDay reports whether a date is neither a weekend nor a legal holiday
+func (dc *DeadlineCalculator) IsCourtDay(date time.Time) bool {
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	_, holiday := dc.LegalHoliday(date)
+	return !holiday
+}
+
+// NextCourtDay returns the date itself if it is a court day, otherwise the next one
+func (dc *DeadlineCalculator) NextCourtDay(date time.Time) time.Time {
+	day := calendarDay(date)
+	for !dc.IsCourtDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// AddDays counts a period stated in days under Rule 6(a)(1)
+func (dc *DeadlineCalculator) AddDays(trigger time.Time, days int) time.Time {
+	return dc.NextCourtDay(calendarDay(trigger).AddDate(0, 0, days))
+}
+
+// AddYears counts a period stated in years under Rule 6(a)(1). A period
+// starting on February 29 ends on February 28 in a common year.
+func (dc *DeadlineCalculator) AddYears(trigger time.Time, years int) time.Time {
+	day := calendarDay(trigger)
+	end := day.AddDate(years, 0, 0)
+	if end.Day() != day.Day() {
+		end = end.AddDate(0, 0, -end.Day())
+	}
+	return dc.NextCourtDay(end)
+}
+
+// ResponseDate returns the last day to act within a period after service.
+// Rule 6(d) adds three days after the period would otherwise expire when the
+// paper was served by mail, left with the clerk or served by consented means.
+func (dc *DeadlineCalculator) ResponseDate(served time.Time, days int, method DeadlineServiceMethod) time.Time {
+	end := dc.AddDays(served, days)
+	if method.addsMailDays() {
+		end = dc.NextCourtDay(end.AddDate(0, 0, mailExtensionDays))
+	}
+	return end
+}
+
+// AnswerDeadline is the defendant's time to answer after being served with
+// the summons and complaint. They are served under Rule 4, not Rule 5, so
+// Rule 6(d) adds no days however they were delivered.
+func (dc *DeadlineCalculator) AnswerDeadline(served time.Time) StatutoryDeadline {
+	return dc.newDeadline("answer", served, dc.AddDays(served, answerDays),
+		fmt.Sprintf("Answer or Rule 12 motion due %d days after service of the summons and complaint", answerDays),
+		ruleAnswer, "Default may be entered under Fed. R. Civ. P. 55(a)")
+}
+
+// WaiverAnswerDeadline is the time to answer when the defendant timely waives
+// service; it runs from the day the waiver request was sent
+func (dc *DeadlineCalculator) WaiverAnswerDeadline(requestSent time.Time, outsideUS bool) StatutoryDeadline {
+	days := waiverAnswerDays
+	sentTo := "within the United States"
+	if outsideUS {
+		days = waiverAnswerForeignDays
+		sentTo = "outside any judicial district of the United States"
+	}
+	return dc.newDeadline("answer_after_waiver", requestSent, dc.AddDays(requestSent, days),
+		fmt.Sprintf("Answer due %d days after the waiver request was sent to a defendant %s", days, sentTo),
+		ruleAnswerWaiver, "Default may be entered under Fed. R. Civ. P. 55(a)")
+}
+
+// ServiceDeadline is the time to serve each defendant after the complaint is filed
+func (dc *DeadlineCalculator) ServiceDeadline(filed time.Time) StatutoryDeadline {
+	return dc.newDeadline("service", filed, dc.AddDays(filed, serviceDays),
+		fmt.Sprintf("Summons and complaint must be served within %d days after the complaint is filed", serviceDays),
+		ruleServiceTime, "Dismissal without prejudice as to the unserved defendant")
+}
+
+// ResponseDeadline is a general period to respond after service of a paper,
+// with the Rule 6(d) extension where it applies
+func (dc *DeadlineCalculator) ResponseDeadline(served time.Time, days int, method DeadlineServiceMethod, description, basis string) StatutoryDeadline {
+	deadline := dc.newDeadline("response", served, dc.ResponseDate(served, days, method), description, basis, "Loss of the right to respond")
+	if method.addsMailDays() {
+		deadline.StatutoryBasis += "; 3 days added under " + ruleMailExtension
+	}
+	return deadline
+}
+
+// ReinvestigationDeadline is the consumer reporting agency's time to finish
+// reinvestigating a dispute. The statute fixes its own period, beginning on
+// the day the dispute is received, so Rule 6(a) does not apply.
+func (dc *DeadlineCalculator) ReinvestigationDeadline(received time.Time) StatutoryDeadline {
+	end := calendarDay(received).AddDate(0, 0, reinvestigationDays-1)
+	deadline := dc.newDeadline("reinvestigation", received, end,
+		fmt.Sprintf("Consumer reporting agency must complete its reinvestigation within the %d-day period beginning on receipt of the dispute", reinvestigationDays),
+		statuteReinvestigation, "Violation of the reinvestigation duty")
+	deadline.StatutoryBasis = statuteReinvestigation
+	return deadline
+}
+
+// FCRALimitations returns the § 1681p limitations periods: 2 years after the
+// plaintiff discovered the violation and 5 years after it occurred. A zero
+// date leaves its period out. The earlier of the two controls.
+func (dc *DeadlineCalculator) FCRALimitations(discovered, violated time.Time) []StatutoryDeadline {
+	deadlines := []StatutoryDeadline{}
+	if !discovered.IsZero() {
+		deadlines = append(deadlines, dc.newDeadline("fcra_limitations_discovery", discovered, dc.AddYears(discovered, fcraDiscoveryYears),
+			fmt.Sprintf("FCRA action must be brought within %d years after the plaintiff discovered the violation", fcraDiscoveryYears),
+			statuteDiscovery, "Claim is time-barred"))
+	}
+	if !violated.IsZero() {
+		deadlines = append(deadlines, dc.newDeadline("fcra_limitations_repose", violated, dc.AddYears(violated, fcraReposeYears),
+			fmt.Sprintf("FCRA action must be brought within %d years after the violation occurred", fcraReposeYears),
+			statuteRepose, "Claim is time-barred"))
+	}
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		return deadlines[i].DeadlineDate.Before(deadlines[j].DeadlineDate)
+	})
+	return deadlines
+}
+
+// newDeadline fills in a StatutoryDeadline computed under Rule 6(a)
+func (dc *DeadlineCalculator) newDeadline(deadlineType string, trigger, date time.Time, description, basis, penalty string) StatutoryDeadline {
+	deadline := StatutoryDeadline{
+		DeadlineID:          fmt.Sprintf("%s_%s", deadlineType, calendarDay(trigger).Format("20060102")),
+		DeadlineDate:        date,
+		DeadlineType:        deadlineType,
+		DeadlineDescription: description,
+		StatutoryBasis:      basis + "; computed under " + ruleTimeComputation,
+		ComplianceRequired:  true,
+		PenaltyForMissing:   penalty,
+		ComplianceStatus:    CompliancePending,
+		DaysRemaining:       dc.daysUntil(date),
+	}
+	return deadline
+}
+
+// daysUntil counts calendar days from today to a date
+func (dc *DeadlineCalculator) daysUntil(date time.Time) int {
+	today := calendarDay(dc.now())
+	return int(calendarDay(date).Sub(today).Hours() / 24)
+}
+
+// calendarDay drops the time of day, keeping the date as written
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func ymd(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFederalHolidays(t *testing.T) {
+	dc := NewDeadlineCalculator()
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		holiday string
+	}{
+		{"MLK Day is the third Monday in January", ymd(2024, time.January, 15), "Birthday of Martin Luther King, Jr."},
+		{"Washington's Birthday is the third Monday in February", ymd(2024, time.February, 19), "Washington's Birthday"},
+		{"Memorial Day is the last Monday in May", ymd(2024, time.May, 27), "Memorial Day"},
+		{"Labor Day is the first Monday in September", ymd(2024, time.September, 2), "Labor Day"},
+		{"Columbus Day is the second Monday in October", ymd(2024, time.October, 14), "Columbus Day"},
+		{"Thanksgiving is the fourth Thursday in November", ymd(2024, time.November, 28), "Thanksgiving Day"},
+		{"Saturday Independence Day is observed Friday", ymd(2026, time.July, 3), "Independence Day"},
+		{"Saturday Veterans Day is observed Friday", ymd(2023, time.November, 10), "Veterans Day"},
+		{"Sunday Christmas is observed Monday", ymd(2022, time.December, 26), "Christmas Day"},
+		{"Sunday Juneteenth is observed Monday", ymd(2022, time.June, 20), "Juneteenth National Independence Day"},
+		{"Saturday New Year's Day is observed the Friday before", ymd(2021, time.December, 31), "New Year's Day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := dc.LegalHoliday(tt.date)
+			if !ok || name != tt.holiday {
+				t.Errorf("LegalHoliday(%s) = %q, %v, want %q", tt.date.Format("2006-01-02"), name, ok, tt.holiday)
+			}
+		})
+	}
+
+	notHolidays := []time.Time{
+		ymd(2026, time.July, 4),      // the Saturday itself
+		ymd(2022, time.January, 1),   // observed in 2021
+		ymd(2022, time.December, 25), // the Sunday itself
+		ymd(2020, time.June, 19),     // Juneteenth became a holiday in 2021
+	}
+	for _, date := range notHolidays {
+		if name, ok := dc.LegalHoliday(date); ok {
+			t.Errorf("LegalHoliday(%s) = %q, want no holiday", date.Format("2006-01-02"), name)
+		}
+	}
+
+	counts := map[int]int{2020: 10, 2021: 12, 2022: 10, 2024: 11}
+	for year, want := range counts {
+		if got := len(dc.FederalHolidays(year)); got != want {
+			t.Errorf("len(FederalHolidays(%d)) = %d, want %d", year, got, want)
+		}
+	}
+}
+
+func TestAddDays(t *testing.T) {
+	dc := NewDeadlineCalculator()
+
+	tests := []struct {
+		name    string
+		trigger time.Time
+		days    int
+		want    time.Time
+	}{
+		{"ends on a court day", ymd(2024, time.March, 1), 3, ymd(2024, time.March, 4)},
+		{"trigger day is excluded", ymd(2024, time.March, 4), 1, ymd(2024, time.March, 5)},
+		{"Saturday rolls to Monday", ymd(2024, time.June, 1), 14, ymd(2024, time.June, 17)},
+		{"Sunday rolls to Monday", ymd(2024, time.June, 2), 14, ymd(2024, time.June, 17)},
+		{"holiday rolls to the next day", ymd(2024, time.November, 18), 10, ymd(2024, time.November, 29)},
+		{"Monday holiday after a weekend rolls to Tuesday", ymd(2024, time.January, 5), 8, ymd(2024, time.January, 16)},
+		{"Sunday holiday observed Monday rolls to Tuesday", ymd(2022, time.December, 20), 5, ymd(2022, time.December, 27)},
+		{"observed Friday holiday rolls over the weekend", ymd(2026, time.June, 30), 3, ymd(2026, time.July, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dc.AddDays(tt.trigger, tt.days); !got.Equal(tt.want) {
+				t.Errorf("AddDays(%s, %d) = %s, want %s", tt.trigger.Format("2006-01-02"), tt.days, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestAnswerDeadlines(t *testing.T) {
+	dc := NewDeadlineCalculator()
+
+	tests := []struct {
+		name     string
+		deadline StatutoryDeadline
+		want     time.Time
+		basis    []string
+		extended bool
+	}{
+		{"21 days after service of the summons", dc.AnswerDeadline(ymd(2024, time.March, 1)), ymd(2024, time.March, 22), []string{ruleAnswer, ruleTimeComputation}, false},
+		{"21 days rolls over Memorial Day", dc.AnswerDeadline(ymd(2024, time.May, 6)), ymd(2024, time.May, 28), []string{ruleAnswer}, false},
+		{"60 days after a waiver request", dc.WaiverAnswerDeadline(ymd(2024, time.March, 1), false), ymd(2024, time.April, 30), []string{ruleAnswerWaiver}, false},
+		{"90 days after a waiver request sent abroad", dc.WaiverAnswerDeadline(ymd(2024, time.March, 1), true), ymd(2024, time.May, 30), []string{ruleAnswerWaiver}, false},
+		{"response to an electronically served paper", dc.ResponseDeadline(ymd(2024, time.March, 1), 14, ServiceMethodElectronic, "Opposition", "Local rule"), ymd(2024, time.March, 15), []string{"Local rule"}, false},
+		{"response to a mailed paper", dc.ResponseDeadline(ymd(2024, time.March, 1), 14, ServiceMethodMail, "Opposition", "Local rule"), ymd(2024, time.March, 18), []string{"Local rule", ruleMailExtension}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.deadline.DeadlineDate.Equal(tt.want) {
+				t.Errorf("deadline = %s, want %s", tt.deadline.DeadlineDate.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+			for _, citation := range tt.basis {
+				if !strings.Contains(tt.deadline.StatutoryBasis, citation) {
+					t.Errorf("statutory basis %q does not cite %s", tt.deadline.StatutoryBasis, citation)
+				}
+			}
+			if !tt.extended && strings.Contains(tt.deadline.StatutoryBasis, ruleMailExtension) {
+				t.Errorf("statutory basis %q cites %s without mail service", tt.deadline.StatutoryBasis, ruleMailExtension)
+			}
+		})
+	}
+}
+
+func TestServiceDeadline(t *testing.T) {
+	dc := NewDeadlineCalculator()
+
+	tests := []struct {
+		filed time.Time
+		want  time.Time
+	}{
+		{ymd(2024, time.January, 2), ymd(2024, time.April, 1)},
+		{ymd(2024, time.April, 5), ymd(2024, time.July, 5)}, // day 90 is Independence Day
+		{ymd(2024, time.March, 3), ymd(2024, time.June, 3)}, // day 90 is a Saturday
+	}
+	for _, tt := range tests {
+		deadline := dc.ServiceDeadline(tt.filed)
+		if !deadline.DeadlineDate.Equal(tt.want) {
+			t.Errorf("ServiceDeadline(%s) = %s, want %s", tt.filed.Format("2006-01-02"), deadline.DeadlineDate.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+		if !strings.Contains(deadline.StatutoryBasis, ruleServiceTime) {
+			t.Errorf("ServiceDeadline basis %q does not cite %s", deadline.StatutoryBasis, ruleServiceTime)
+		}
+	}
+}
+
+func TestFCRALimitations(t *testing.T) {
+	dc := NewDeadlineCalculator()
+
+	tests := []struct {
+		name       string
+		discovered time.Time
+		violated   time.Time
+		want       []time.Time
+		statutes   []string
+	}{
+		{
+			name:       "repose ends first",
+			discovered: ymd(2023, time.March, 15),
+			violated:   ymd(2020, time.January, 10),
+			want:       []time.Time{ymd(2025, time.January, 10), ymd(2025, time.March, 17)},
+			statutes:   []string{statuteRepose, statuteDiscovery},
+		},
+		{
+			name:       "discovery ends first",
+			discovered: ymd(2024, time.May, 1),
+			violated:   ymd(2024, time.April, 1),
+			want:       []time.Time{ymd(2026, time.May, 1), ymd(2029, time.April, 2)},
+			statutes:   []string{statuteDiscovery, statuteRepose},
+		},
+		{
+			name:       "February 29 ends February 28, rolled to Monday",
+			discovered: ymd(2024, time.February, 29),
+			want:       []time.Time{ymd(2026, time.March, 2)},
+			statutes:   []string{statuteDiscovery},
+		},
+		{
+			name:     "violation date only",
+			violated: ymd(2021, time.June, 1),
+			want:     []time.Time{ymd(2026, time.June, 1)},
+			statutes: []string{statuteRepose},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadlines := dc.FCRALimitations(tt.discovered, tt.violated)
+			if len(deadlines) != len(tt.want) {
+				t.Fatalf("FCRALimitations returned %d deadlines, want %d", len(deadlines), len(tt.want))
+			}
+			for i, deadline := range deadlines {
+				if !deadline.DeadlineDate.Equal(tt.want[i]) {
+					t.Errorf("deadline %d = %s, want %s", i, deadline.DeadlineDate.Format("2006-01-02"), tt.want[i].Format("2006-01-02"))
+				}
+				if !strings.Contains(deadline.StatutoryBasis, tt.statutes[i]) {
+					t.Errorf("deadline %d basis %q does not cite %s", i, deadline.StatutoryBasis, tt.statutes[i])
+				}
+			}
+		})
+	}
+}
@@ -3,10 +3,12 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 type DocumentType int
@@ -49,11 +51,20 @@ type DocumentClassification struct {
 	ValidationScore   float64                `json:"validationScore"`
 }
 
+// ContentIndicator is a pattern that counted toward a classification. Start
+// and End are the first match's offsets in the text; once the document is
+// located, Location cites the page it is on and Boxes where it was drawn.
 type ContentIndicator struct {
-	Pattern    string  `json:"pattern"`
-	MatchType  string  `json:"matchType"`
-	Confidence float64 `json:"confidence"`
-	Location   string  `json:"location"`
+	Pattern    string      `json:"pattern"`
+	MatchType  string      `json:"matchType"`
+	Confidence float64     `json:"confidence"`
+	Location   string      `json:"location"`
+	Matches    int         `json:"matches,omitempty"`
+	Start      int         `json:"start,omitempty"`
+	End        int         `json:"end,omitempty"`
+	Document   string      `json:"document,omitempty"`
+	Page       int         `json:"page,omitempty"`
+	Boxes      []SourceBox `json:"boxes,omitempty"`
 }
 
 type DocumentTypePattern struct {
@@ -78,6 +89,11 @@ type DocumentClassifier struct {
 	patterns       map[string]interface{}
 	documentTypes  DocumentTypePatterns
 	contentService *ContentAnalyzer
+
+	// model is learned from the document types users corrected; nil until
+	// there are enough labels. Pipeline workers classify concurrently with retraining.
+	modelMu sync.RWMutex
+	model   *NaiveBayesModel
 }
 
 func NewDocumentClassifier(contentAnalyzer *ContentAnalyzer) (*DocumentClassifier, error) {
@@ -90,9 +106,31 @@ func NewDocumentClassifier(contentAnalyzer *ContentAnalyzer) (*DocumentClassifie
 		return nil, fmt.Errorf("failed to load patterns: %w", err)
 	}
 
+	if labels := DefaultClassificationLabels(); labels != nil {
+		classifier.Train(labels.Labels())
+	}
+
 	return classifier, nil
 }
 
+// Train replaces the learned model with one trained on the labeled documents
+func (dc *DocumentClassifier) Train(labels []ClassificationLabel) {
+	model := TrainNaiveBayes(labels)
+	dc.modelMu.Lock()
+	dc.model = model
+	dc.modelMu.Unlock()
+	if model != nil {
+		log.Printf("[DOCUMENT_CLASSIFIER] Trained learned model on %d labeled documents", model.Examples)
+	}
+}
+
+// Model returns the learned model, or nil when there aren't enough labels yet
+func (dc *DocumentClassifier) Model() *NaiveBayesModel {
+	dc.modelMu.RLock()
+	defer dc.modelMu.RUnlock()
+	return dc.model
+}
+
 func (dc *DocumentClassifier) loadPatterns() error {
 	data, err := os.ReadFile("./config/legal_patterns.json")
 	if err != nil {
@@ -207,6 +245,15 @@ func (dc *DocumentClassifier) loadPatterns() error {
 }
 
 func (dc *DocumentClassifier) ClassifyDocument(documentPath string, content string) (*DocumentClassification, error) {
+	if model := dc.Model(); model != nil {
+		return dc.classify(documentPath, content, model.Predict(documentPath, content), model.weight()), nil
+	}
+	return dc.classify(documentPath, content, nil, 0), nil
+}
+
+// classify scores a document with the rules, blending in the learned model's
+// probability of each type at the given weight
+func (dc *DocumentClassifier) classify(documentPath string, content string, modelProbabilities map[DocumentType]float64, modelWeight float64) *DocumentClassification {
 	classification := &DocumentClassification{
 		DocumentPath:      documentPath,
 		PrimaryType:       DocumentTypeUnknown,
@@ -226,6 +273,8 @@ func (dc *DocumentClassifier) ClassifyDocument(documentPath string, content stri
 
 	contentScore := dc.analyzeContent(content, scores, indicators)
 
+	dc.applyModel(modelProbabilities, modelWeight, scores, indicators)
+
 	dc.determinePrimaryType(scores, classification)
 
 	dc.calculateConfidence(classification, filenameScore, structureScore, contentScore)
@@ -236,7 +285,24 @@ func (dc *DocumentClassifier) ClassifyDocument(documentPath string, content stri
 		}
 	}
 
-	return classification, nil
+	return classification
+}
+
+// applyModel adds the learned model's weighted probabilities to the rule scores
+func (dc *DocumentClassifier) applyModel(probabilities map[DocumentType]float64, weight float64, scores map[DocumentType]float64, indicators map[DocumentType][]ContentIndicator) {
+	for docType, probability := range probabilities {
+		score := weight * probability
+		if score < 0.05 {
+			continue
+		}
+		scores[docType] += score
+		indicators[docType] = append(indicators[docType], ContentIndicator{
+			Pattern:    "learned from labeled documents",
+			MatchType:  "model",
+			Confidence: score,
+			Location:   fmt.Sprintf("%.0f%% probability", probability*100),
+		})
+	}
 }
 
 func (dc *DocumentClassifier) analyzeFilename(filepath string, scores map[DocumentType]float64, indicators map[DocumentType][]ContentIndicator) float64 {
@@ -285,13 +351,17 @@ func (dc *DocumentClassifier) analyzeDocumentStructure(content string, scores ma
 
 	checkHeaders := func(docType DocumentType, patterns []string) {
 		for _, pattern := range patterns {
-			if strings.Contains(headerUpper, pattern) {
+			// Lines are joined by one space, so offsets in the header are offsets in the content
+			if index := strings.Index(headerUpper, pattern); index >= 0 {
 				scores[docType] += 0.4
 				indicators[docType] = append(indicators[docType], ContentIndicator{
 					Pattern:    pattern,
 					MatchType:  "header",
 					Confidence: 0.4,
 					Location:   "document header",
+					Matches:    1,
+					Start:      index,
+					End:        index + len(pattern),
 				})
 				structureScore = max(structureScore, 0.4)
 			}
@@ -335,6 +405,9 @@ func (dc *DocumentClassifier) analyzeContent(content string, scores map[Document
 					MatchType:  "content",
 					Confidence: matchWeight,
 					Location:   fmt.Sprintf("found %d times", len(matches)),
+					Matches:    len(matches),
+					Start:      matches[0][0],
+					End:        matches[0][1],
 				})
 				contentScore = max(contentScore, matchWeight)
 			}
@@ -348,13 +421,16 @@ func (dc *DocumentClassifier) analyzeContent(content string, scores map[Document
 
 	checkStatutoryReferences := func(docType DocumentType, references []string) {
 		for _, ref := range references {
-			if strings.Contains(contentLower, strings.ToLower(ref)) {
+			if index := strings.Index(contentLower, strings.ToLower(ref)); index >= 0 {
 				scores[docType] += 0.4
 				indicators[docType] = append(indicators[docType], ContentIndicator{
 					Pattern:    ref,
 					MatchType:  "statutory",
 					Confidence: 0.4,
 					Location:   "statutory reference",
+					Matches:    1,
+					Start:      index,
+					End:        index + len(ref),
 				})
 				contentScore = max(contentScore, 0.4)
 			}
@@ -415,6 +491,26 @@ func (dc *DocumentClassifier) calculateConfidence(classification *DocumentClassi
 	classification.ValidationScore = classification.Confidence
 }
 
+// DocumentTypeByName returns the document type with a name such as "Denial Letter"
+func DocumentTypeByName(name string) (DocumentType, bool) {
+	for docType, typeName := range documentTypeNames {
+		if strings.EqualFold(typeName, strings.TrimSpace(name)) {
+			return docType, true
+		}
+	}
+	return DocumentTypeUnknown, false
+}
+
+// DocumentTypeNames returns the names of the document types users can label
+// documents with, in declaration order
+func DocumentTypeNames() []string {
+	names := make([]string, 0, len(documentTypeNames))
+	for docType := DocumentTypeAttorneyNotes; docType <= DocumentTypeDisputeLetter; docType++ {
+		names = append(names, documentTypeNames[docType])
+	}
+	return names
+}
+
 func (dc *DocumentClassifier) GetDocumentTypeName(docType DocumentType) string {
 	if name, ok := documentTypeNames[docType]; ok {
 		return name
@@ -1,12 +1,16 @@
 package services
 
 import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +23,9 @@ import (
 type DocumentExtractor struct {
 	SupportedFormats []string
 	MaxFileSize      int64
+	// OCR reads image-only PDF pages; nil leaves them unread
+	OCR              OCRBackend
+	OCRMinConfidence float64
 }
 
 // ExtractedContent represents the result of document text extraction
@@ -29,6 +36,10 @@ type ExtractedContent struct {
 	WordCount  int                    `json:"wordCount"`
 	Error      error                  `json:"error,omitempty"`
 	SourceFile string                 `json:"sourceFile"`
+	// PageOffsets holds the offset in RawText where each page starts
+	PageOffsets []int `json:"pageOffsets,omitempty"`
+	// Pages holds each page's span of RawText and, for PDFs, its words' positions
+	Pages []PageText `json:"pages,omitempty"`
 }
 
 // ContentPattern defines a pattern for extracting specific information
@@ -44,11 +55,19 @@ func NewDocumentExtractor() *DocumentExtractor {
 	return &DocumentExtractor{
 		SupportedFormats: []string{".pdf", ".docx", ".txt"},
 		MaxFileSize:      50 * 1024 * 1024, // 50MB max file size
+		OCR:              DefaultOCRBackend(),
+		OCRMinConfidence: defaultOCRConfidenceThreshold(),
 	}
 }
 
 // ExtractText extracts text content from a document file
 func (e *DocumentExtractor) ExtractText(filePath string) (*ExtractedContent, error) {
+	return e.ExtractTextContext(context.Background(), filePath)
+}
+
+// ExtractTextContext extracts text content from a document file, stopping
+// any OCR in progress when ctx is done
+func (e *DocumentExtractor) ExtractTextContext(ctx context.Context, filePath string) (*ExtractedContent, error) {
 	log.Printf("[EXTRACTOR] Starting text extraction for: %s", filePath)
 	
 	// Check if file exists
@@ -73,7 +92,7 @@ func (e *DocumentExtractor) ExtractText(filePath string) (*ExtractedContent, err
 	var content *ExtractedContent
 	switch ext {
 	case ".pdf":
-		content, err = e.extractFromPDF(filePath)
+		content, err = e.extractFromPDF(ctx, filePath)
 	case ".docx":
 		content, err = e.extractFromDOCX(filePath)
 	case ".txt":
@@ -105,8 +124,9 @@ func (e *DocumentExtractor) ExtractText(filePath string) (*ExtractedContent, err
 	return content, nil
 }
 
-// extractFromPDF extracts text from PDF files
-func (e *DocumentExtractor) extractFromPDF(filePath string) (*ExtractedContent, error) {
+// extractFromPDF extracts text from PDF files. Pages with images but next to
+// no text are taken to be scans and are OCR'd.
+func (e *DocumentExtractor) extractFromPDF(ctx context.Context, filePath string) (*ExtractedContent, error) {
 	log.Printf("[EXTRACTOR] Extracting text from PDF: %s", filePath)
 	
 	// Open PDF file
@@ -142,42 +162,94 @@ func (e *DocumentExtractor) extractFromPDF(filePath string) (*ExtractedContent,
 		return nil, fmt.Errorf("failed to get PDF page count: %v", err)
 	}
 	
-	// Extract text from all pages
-	var textContent strings.Builder
+	// Extract text from all pages, remembering where each page and word is.
+	// Pages that can't be read are kept, empty, so page numbers stay right.
+	var pages pageBuilder
+	var ocrPages []OCRPageResult
 	for i := 1; i <= numPages; i++ {
 		page, err := pdfReader.GetPage(i)
 		if err != nil {
 			log.Printf("[EXTRACTOR] Warning: failed to get page %d: %v", i, err)
+			pages.add("", nil, 0, 0)
 			continue
 		}
 		
-		extractor, err := extractor.New(page)
+		var width, height float64
+		mediaBox, err := page.GetMediaBox()
 		if err != nil {
-			log.Printf("[EXTRACTOR] Warning: failed to create extractor for page %d: %v", i, err)
-			continue
+			mediaBox = nil
+		} else {
+			width, height = mediaBox.Width(), mediaBox.Height()
 		}
 		
-		text, err := extractor.ExtractText()
+		text, spans, ocrResult, err := e.extractPDFPage(ctx, i, page, mediaBox)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		if err != nil {
-			log.Printf("[EXTRACTOR] Warning: failed to extract text from page %d: %v", i, err)
-			continue
+			log.Printf("[EXTRACTOR] Warning: %v", err)
 		}
-		
-		textContent.WriteString(text)
-		textContent.WriteString("\n")
+		if ocrResult != nil {
+			ocrPages = append(ocrPages, *ocrResult)
+		}
+		pages.add(text, spans, width, height)
+	}
+	rawText, pageTexts, pageOffsets := pages.content()
+	
+	metadata := map[string]interface{}{
+		"format":    "PDF",
+		"pages":     numPages,
+		"encrypted": isEncrypted,
+	}
+	if len(ocrPages) > 0 {
+		metadata["ocrPages"] = ocrPages
 	}
 	
 	return &ExtractedContent{
-		RawText:   strings.TrimSpace(textContent.String()),
-		PageCount: numPages,
-		Metadata: map[string]interface{}{
-			"format":    "PDF",
-			"pages":     numPages,
-			"encrypted": isEncrypted,
-		},
+		RawText:     rawText,
+		PageCount:   numPages,
+		PageOffsets: pageOffsets,
+		Pages:       pageTexts,
+		Metadata:    metadata,
 	}, nil
 }
 
+// extractPDFPage extracts the text of a PDF page and the position of each
+// word on a page of the given size. Pages with images but next to no text are
+// taken to be scans and are OCR'd, in which case the OCR result is returned too.
+func (e *DocumentExtractor) extractPDFPage(ctx context.Context, number int, page *model.PdfPage, mediaBox *model.PdfRectangle) (string, []TextSpan, *OCRPageResult, error) {
+	pageExtractor, err := extractor.New(page)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create extractor for page %d: %v", number, err)
+	}
+	
+	pageText, _, _, err := pageExtractor.ExtractPageText()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to extract text from page %d: %v", number, err)
+	}
+	text := pageText.Text()
+	
+	if len(strings.TrimSpace(text)) < imageOnlyPageChars {
+		images, err := pageExtractor.ExtractPageImages(nil)
+		if err != nil {
+			log.Printf("[EXTRACTOR] Warning: failed to extract images from page %d: %v", number, err)
+		} else if len(images.Images) > 0 {
+			ocrText, result := e.ocrPage(ctx, number, images.Images)
+			if ocrText != "" {
+				// OCR'd text has no positions on the page
+				return ocrText, nil, &result, nil
+			}
+			return text, nil, &result, nil
+		}
+	}
+	
+	var spans []TextSpan
+	if mediaBox != nil {
+		spans = pdfWordSpans(text, pageText.Marks(), *mediaBox)
+	}
+	return text, spans, nil, nil
+}
+
 // extractFromDOCX extracts text from DOCX files
 func (e *DocumentExtractor) extractFromDOCX(filePath string) (*ExtractedContent, error) {
 	log.Printf("[EXTRACTOR] Extracting text from DOCX: %s", filePath)
@@ -189,25 +261,231 @@ func (e *DocumentExtractor) extractFromDOCX(filePath string) (*ExtractedContent,
 	}
 	defer r.Close()
 	
-	// Extract text content
+	// Extract text content; the library hands back the raw document.xml
 	docx := r.Editable()
-	text := docx.GetContent()
+	text, err := docxXMLToText(docx.GetContent(), e.readDocxListStarts(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX content: %v", err)
+	}
 	
-	// Clean up the text (remove excessive whitespace)
-	text = strings.ReplaceAll(text, "\r\n", "\n")
-	text = strings.ReplaceAll(text, "\r", "\n")
-	text = regexp.MustCompile(`\n\s*\n`).ReplaceAllString(text, "\n\n")
-	text = strings.TrimSpace(text)
+	// Clean up each page's text (remove excessive whitespace). Pages are
+	// only known where Word recorded a page break.
+	var pages pageBuilder
+	blankLines := regexp.MustCompile(`\n\s*\n`)
+	for _, pageText := range strings.Split(text, "\f") {
+		pageText = strings.ReplaceAll(pageText, "\r\n", "\n")
+		pageText = strings.ReplaceAll(pageText, "\r", "\n")
+		pageText = blankLines.ReplaceAllString(pageText, "\n\n")
+		pages.add(pageText, nil, 0, 0)
+	}
+	rawText, pageTexts, pageOffsets := pages.content()
 	
 	return &ExtractedContent{
-		RawText:   text,
-		PageCount: 1, // DOCX doesn't have clear page boundaries in text extraction
+		RawText:     rawText,
+		PageCount:   len(pageTexts),
+		PageOffsets: pageOffsets,
+		Pages:       pageTexts,
 		Metadata: map[string]interface{}{
 			"format": "DOCX",
 		},
 	}, nil
 }
 
+// docxXMLToText converts WordprocessingML into plain text, one line per paragraph,
+// restoring decimal list numbers so numbered paragraphs keep their "N." prefix.
+// Page breaks, explicit or as Word last laid the document out, become form feeds.
+func docxXMLToText(documentXML string, listStarts map[string]int) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(documentXML))
+	
+	var text strings.Builder
+	var paragraph strings.Builder
+	counters := make(map[string]int)
+	numID := ""
+	inText := false
+	// w:tab is a tab character only inside a run; in w:tabs it defines a tab stop
+	runDepth := 0
+	// Word records a rendered break after an explicit one too; only one counts
+	pageHasText := false
+	
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		
+		switch t := token.(type) {
+		case xml.StartElement:
+			// DrawingML in shapes and charts has its own a:p and a:t
+			if !isDocxWordElement(t.Name) {
+				break
+			}
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				numID = ""
+			case "r":
+				runDepth++
+			case "numId":
+				numID = docxAttr(t, "val")
+			case "t":
+				inText = true
+			case "tab":
+				if runDepth > 0 {
+					paragraph.WriteString("\t")
+				}
+			case "br", "cr":
+				if docxAttr(t, "type") == "page" {
+					if pageHasText {
+						paragraph.WriteString("\f")
+						pageHasText = false
+					}
+					break
+				}
+				paragraph.WriteString("\n")
+			case "lastRenderedPageBreak":
+				if pageHasText {
+					paragraph.WriteString("\f")
+					pageHasText = false
+				}
+			}
+		case xml.EndElement:
+			if !isDocxWordElement(t.Name) {
+				break
+			}
+			switch t.Name.Local {
+			case "r":
+				runDepth--
+			case "t":
+				inText = false
+			case "p":
+				if start, numbered := listStarts[numID]; numbered {
+					if _, seen := counters[numID]; !seen {
+						counters[numID] = start - 1
+					}
+					counters[numID]++
+					text.WriteString(fmt.Sprintf("%d. ", counters[numID]))
+				}
+				text.WriteString(paragraph.String())
+				text.WriteString("\n")
+			case "tbl":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+				if strings.TrimSpace(string(t)) != "" {
+					pageHasText = true
+				}
+			}
+		}
+	}
+	
+	return text.String(), nil
+}
+
+// isDocxWordElement reports whether an element is WordprocessingML. Documents
+// declare the namespace; a bare "w" prefix is accepted for fragments without it.
+func isDocxWordElement(name xml.Name) bool {
+	return name.Space == docxWordNamespace || name.Space == "w"
+}
+
+// docxAttr returns the value of an element's attribute, ignoring its namespace
+func docxAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// docxNumbering is the subset of word/numbering.xml needed to restore list numbers
+type docxNumbering struct {
+	AbstractNums []struct {
+		ID     string `xml:"abstractNumId,attr"`
+		Levels []struct {
+			Level  string      `xml:"ilvl,attr"`
+			Start  docxXMLAttr `xml:"start"`
+			Format docxXMLAttr `xml:"numFmt"`
+		} `xml:"lvl"`
+	} `xml:"abstractNum"`
+	Nums []struct {
+		ID        string      `xml:"numId,attr"`
+		Abstract  docxXMLAttr `xml:"abstractNumId"`
+		Overrides []struct {
+			Level string      `xml:"ilvl,attr"`
+			Start docxXMLAttr `xml:"startOverride"`
+		} `xml:"lvlOverride"`
+	} `xml:"num"`
+}
+
+// docxXMLAttr captures the w:val attribute of a WordprocessingML element
+type docxXMLAttr struct {
+	Val string `xml:"val,attr"`
+}
+
+// readDocxListStarts returns the starting number of every decimal list in the
+// DOCX numbering part, keyed by numId
+func (e *DocumentExtractor) readDocxListStarts(filePath string) map[string]int {
+	listStarts := make(map[string]int)
+	
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return listStarts
+	}
+	defer archive.Close()
+	
+	var numbering docxNumbering
+	
+	for _, file := range archive.File {
+		if file.Name != "word/numbering.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			log.Printf("[EXTRACTOR] Warning: failed to open DOCX numbering: %v", err)
+			return listStarts
+		}
+		err = xml.NewDecoder(reader).Decode(&numbering)
+		reader.Close()
+		if err != nil {
+			log.Printf("[EXTRACTOR] Warning: failed to parse DOCX numbering: %v", err)
+			return listStarts
+		}
+	}
+	
+	// Only top level decimal lists carry paragraph numbers worth restoring
+	decimalStarts := make(map[string]int)
+	for _, abstract := range numbering.AbstractNums {
+		for _, level := range abstract.Levels {
+			if level.Level == "0" && level.Format.Val == "decimal" {
+				decimalStarts[abstract.ID], _ = strconv.Atoi(level.Start.Val)
+			}
+		}
+	}
+	
+	for _, num := range numbering.Nums {
+		start, decimal := decimalStarts[num.Abstract.Val]
+		if !decimal {
+			continue
+		}
+		for _, override := range num.Overrides {
+			if value, err := strconv.Atoi(override.Start.Val); err == nil && override.Level == "0" && value > 0 {
+				start = value
+			}
+		}
+		if start == 0 {
+			start = 1
+		}
+		listStarts[num.ID] = start
+	}
+	
+	return listStarts
+}
+
 // extractFromTXT extracts text from plain text files
 func (e *DocumentExtractor) extractFromTXT(filePath string) (*ExtractedContent, error) {
 	log.Printf("[EXTRACTOR] Reading text file: %s", filePath)
@@ -225,15 +503,22 @@ func (e *DocumentExtractor) extractFromTXT(filePath string) (*ExtractedContent,
 		return nil, fmt.Errorf("failed to read text file: %v", err)
 	}
 	
-	// Convert to string and clean up
+	// Convert to string and clean up; form feeds separate pages
 	text := string(content)
 	text = strings.ReplaceAll(text, "\r\n", "\n")
 	text = strings.ReplaceAll(text, "\r", "\n")
-	text = strings.TrimSpace(text)
+	
+	var pages pageBuilder
+	for _, pageText := range strings.Split(text, "\f") {
+		pages.add(pageText, nil, 0, 0)
+	}
+	rawText, pageTexts, pageOffsets := pages.content()
 	
 	return &ExtractedContent{
-		RawText:   text,
-		PageCount: 1,
+		RawText:     rawText,
+		PageCount:   len(pageTexts),
+		PageOffsets: pageOffsets,
+		Pages:       pageTexts,
 		Metadata: map[string]interface{}{
 			"format": "TXT",
 		},
@@ -0,0 +1,45 @@
+package services
+
+import "testing"
+
+func TestDocxXMLToText(t *testing.T) {
+	const w = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+	tests := []struct {
+		name string
+		xml  string
+		want string
+	}{
+		{
+			name: "paragraphs and run tabs",
+			xml:  `<w:document ` + w + `><w:body><w:p><w:r><w:t>Name:</w:t><w:tab/><w:t>Johnson</w:t></w:r></w:p><w:p><w:r><w:t>Second</w:t></w:r></w:p></w:body></w:document>`,
+			want: "Name:\tJohnson\nSecond\n",
+		},
+		{
+			name: "tab stop definitions are not tabs",
+			xml:  `<w:document ` + w + `><w:body><w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>Heading</w:t></w:r></w:p></w:body></w:document>`,
+			want: "Heading\n",
+		},
+		{
+			name: "drawing paragraphs don't break the Word paragraph",
+			xml:  `<w:document ` + w + `><w:body><w:p><w:r><w:t>Before</w:t></w:r><w:r><w:drawing><a:graphic><a:p><a:r><a:t>Shape</a:t></a:r></a:p></a:graphic></w:drawing></w:r><w:r><w:t> after</w:t></w:r></w:p></w:body></w:document>`,
+			want: "Before after\n",
+		},
+		{
+			name: "numbered paragraphs",
+			xml:  `<w:document ` + w + `><w:body><w:p><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>First</w:t></w:r></w:p><w:p><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Second</w:t></w:r></w:p></w:body></w:document>`,
+			want: "1. First\n2. Second\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := docxXMLToText(tt.xml, map[string]int{"1": 1})
+			if err != nil {
+				t.Fatalf("docxXMLToText: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("docxXMLToText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
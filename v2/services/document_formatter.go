@@ -33,7 +33,7 @@ type Margins struct {
 func NewLegalDocumentFormatter() *LegalDocumentFormatter {
 	return &LegalDocumentFormatter{
 		Style: DocumentStyle{
-			LineSpacing:    1.5,
+			LineSpacing:    2.0, // Pleadings are double spaced
 			FontSize:       12,
 			FontFamily:     "Times New Roman",
 			PageSize:       "Letter",
@@ -135,12 +135,166 @@ func (ldf *LegalDocumentFormatter) FormatAsHTML(content string) string {
 	return html.String()
 }
 
-// FormatAsPDF formats the document for PDF generation (placeholder)
+// FormatAsPDF renders plain document content onto pleading paper
 func (ldf *LegalDocumentFormatter) FormatAsPDF(content string) ([]byte, error) {
-	// This would integrate with a PDF generation library
-	// For now, return HTML that can be converted to PDF
-	htmlContent := ldf.FormatAsHTML(content)
-	return []byte(htmlContent), nil
+	blocks := ldf.buildPleadingBlocks(content)
+	
+	// Use a leading caption block when the content carries one
+	var caption *PleadingCaption
+	if len(blocks) > 0 && ldf.isHeaderSection(blocks[0].Text) {
+		caption = ldf.parseCaption(blocks[0].Text)
+		blocks = blocks[1:]
+	}
+	
+	title := "Legal Document"
+	if caption != nil && caption.Title != "" {
+		title = caption.Title
+	}
+	
+	return NewPleadingPDFRenderer(ldf.Style).Render(title, caption, blocks)
+}
+
+// FormatDocumentAsPDF renders a generated document onto pleading paper, building
+// the caption from its header section
+func (ldf *LegalDocumentFormatter) FormatDocumentAsPDF(document *GeneratedDocument) ([]byte, error) {
+	if document == nil {
+		return nil, fmt.Errorf("no document to format")
+	}
+	
+	// Fall back to the flattened content for documents without sections
+	if len(document.Sections) == 0 {
+		return ldf.FormatAsPDF(document.Content)
+	}
+	
+	caption, blocks := ldf.documentBlocks(document)
+	return NewPleadingPDFRenderer(ldf.Style).Render(document.Title, caption, blocks)
+}
+
+// FormatDocumentAsDOCX writes a generated document as a Word file with a caption
+// table, heading styles and numbered paragraphs
+func (ldf *LegalDocumentFormatter) FormatDocumentAsDOCX(document *GeneratedDocument) ([]byte, error) {
+	if document == nil {
+		return nil, fmt.Errorf("no document to format")
+	}
+	
+	caption, blocks := ldf.documentBlocks(document)
+	return NewDocxWriter(ldf.Style).Write(document, caption, blocks)
+}
+
+// documentBlocks builds the caption from the header section and the body blocks
+// from every other section, falling back to the flattened content
+func (ldf *LegalDocumentFormatter) documentBlocks(document *GeneratedDocument) (*PleadingCaption, []pleadingBlock) {
+	if len(document.Sections) == 0 {
+		blocks := ldf.buildPleadingBlocks(document.Content)
+		if len(blocks) > 0 && ldf.isHeaderSection(blocks[0].Text) {
+			return ldf.parseCaption(blocks[0].Text), blocks[1:]
+		}
+		return nil, blocks
+	}
+	
+	var caption *PleadingCaption
+	var blocks []pleadingBlock
+	
+	for _, section := range document.Sections {
+		if section.Type == SectionTypeHeader && caption == nil {
+			caption = ldf.parseCaption(section.Content)
+			continue
+		}
+		blocks = append(blocks, ldf.buildPleadingBlocks(section.Content)...)
+	}
+	
+	return caption, blocks
+}
+
+// buildPleadingBlocks splits content into headings, paragraphs and signature blocks
+func (ldf *LegalDocumentFormatter) buildPleadingBlocks(content string) []pleadingBlock {
+	var blocks []pleadingBlock
+	
+	for _, section := range strings.Split(content, "\n\n") {
+		if strings.TrimSpace(section) == "" {
+			continue
+		}
+		
+		kind := pleadingParagraph
+		if ldf.isSignatureBlock(section) {
+			kind = pleadingSignature
+		} else if ldf.isSectionHeader(section) {
+			kind = pleadingHeading
+		}
+		
+		blocks = append(blocks, pleadingBlock{Kind: kind, Text: strings.Trim(section, "\n")})
+	}
+	
+	return blocks
+}
+
+// parseCaption pulls the court, parties, case number and title out of a header section
+func (ldf *LegalDocumentFormatter) parseCaption(header string) *PleadingCaption {
+	caption := &PleadingCaption{}
+	
+	lines := strings.Split(header, "\n")
+	i := 0
+	
+	// Court lines run until the first blank line
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		caption.CourtLines = append(caption.CourtLines, strings.TrimSpace(lines[i]))
+	}
+	
+	inDefendants := false
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		
+		if match := captionCaseNumberPattern.FindStringSubmatch(line); match != nil {
+			caption.CaseNumber = strings.TrimSpace(match[1])
+			line = strings.TrimSpace(line[:strings.Index(line, match[0])])
+		}
+		
+		switch {
+		case line == "":
+			continue
+		case strings.EqualFold(line, "Plaintiff,") || strings.EqualFold(line, "Plaintiffs,"):
+			continue
+		case strings.EqualFold(line, "v.") || strings.EqualFold(line, "vs."):
+			inDefendants = true
+		case strings.EqualFold(line, "Defendant.") || strings.EqualFold(line, "Defendants."):
+			inDefendants = false
+		case caption.Plaintiff == "" && !inDefendants:
+			caption.Plaintiff = strings.TrimSuffix(line, ",")
+		case inDefendants:
+			caption.Defendants = append(caption.Defendants, ldf.splitPartyNames(line)...)
+		default:
+			if caption.Title != "" {
+				caption.Title += " "
+			}
+			caption.Title += line
+		}
+	}
+	
+	return caption
+}
+
+// splitPartyNames splits a comma separated list of parties without breaking
+// corporate suffixes such as "INC." or "LLC" off the preceding name
+func (ldf *LegalDocumentFormatter) splitPartyNames(line string) []string {
+	suffixes := map[string]bool{
+		"INC": true, "INC.": true, "LLC": true, "L.L.C.": true, "N.A.": true,
+		"CORP.": true, "CO.": true, "LTD.": true, "LP": true, "L.P.": true, "ET AL.": true,
+	}
+	
+	var names []string
+	for _, part := range strings.Split(line, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if len(names) > 0 && suffixes[strings.ToUpper(part)] {
+			names[len(names)-1] += ", " + part
+			continue
+		}
+		names = append(names, part)
+	}
+	
+	return names
 }
 
 // FormatAsPlainText formats the document as plain text
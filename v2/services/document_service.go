@@ -1,12 +1,14 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -19,14 +21,19 @@ type Document struct {
 	Path        string `json:"path"`
 	ContentType string `json:"contentType"` // "attorney_notes", "adverse_action", etc.
 	Size        int64  `json:"size"`
+	CacheStatus string `json:"cacheStatus,omitempty"` // "fresh" or "cached" once processed
+	// OCRPages describes the pages of a scanned PDF that were OCR'd
+	OCRPages []OCRPageResult `json:"ocrPages,omitempty"`
 }
 
 // Template represents a legal template
 type Template struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Desc string `json:"desc"`
-	Path string `json:"path"`
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Desc    string            `json:"desc"`
+	Path    string            `json:"path"`
+	Version string            `json:"version"`
+	Issues  []ValidationIssue `json:"issues,omitempty"`
 }
 
 // MissingContent represents a missing required field
@@ -99,26 +106,46 @@ type ClientCase struct {
 	// Additional evidence and impact
 	AdditionalEvidence       string    `json:"additionalEvidence"`
 	CreditImpact             string    `json:"creditImpact"`
+	
+	// Where each field's value came from, keyed by the field's JSON name
+	Provenance               map[string]FieldProvenance `json:"provenance,omitempty"`
+	
+	// FCRA statute of limitations analysis and attorney overrides, keyed by claim ID
+	Limitations              *LimitationsAnalysis           `json:"limitations,omitempty"`
+	LimitationsOverrides     map[string]LimitationsOverride `json:"limitationsOverrides,omitempty"`
+	
+	// Attorney corrections of extracted fields, keyed by the field's JSON name
+	FieldOverrides           map[string]FieldOverride       `json:"fieldOverrides,omitempty"`
 }
 
 // DocumentService handles document operations
 type DocumentService struct {
-	documentsDir               string
-	testCasesDir               string
+	caseStore                  CaseStore
+	documentsFolder            string
 	extractor                  *DocumentExtractor
 	contentAnalyzer            *ContentAnalyzer
 	attorneyNotesAnalyzer      *AttorneyNotesAnalyzer
 	civilCoverSheetAnalyzer    *CivilCoverSheetAnalyzer
+	cache                      *ExtractionCache
+	labels                     *ClassificationLabelStore
 	templateEngine             *TemplateEngine
+	violationEngine            *ViolationDetectionEngine
+	limitations                *LimitationsChecker
 	extractionPatterns         map[string]interface{}
 }
 
-// NewDocumentService creates a new document service instance
+// NewDocumentService creates a new document service instance backed by the configured case store
 func NewDocumentService() *DocumentService {
+	store, config := DefaultCaseStore()
+	return NewDocumentServiceWithStore(store, config.DocumentsFolder)
+}
+
+// NewDocumentServiceWithStore creates a document service that reads documents from the given case store
+func NewDocumentServiceWithStore(store CaseStore, documentsFolder string) *DocumentService {
 	service := &DocumentService{
-		documentsDir: "/Users/corelogic/satori-dev/clients/proj-mallon/artifacts",
-		testCasesDir: "/Users/corelogic/satori-dev/clients/proj-mallon/test_icloud/CASES",
-		extractor:    NewDocumentExtractor(),
+		caseStore:       store,
+		documentsFolder: documentsFolder,
+		extractor:       NewDocumentExtractor(),
 	}
 	
 	// Initialize content analyzer
@@ -154,15 +181,31 @@ func NewDocumentService() *DocumentService {
 	service.templateEngine = NewTemplateEngine()
 	log.Printf("[DOCUMENT_SERVICE] Initialized with dynamic template engine")
 	
+	// Initialize violation detection for the per-claim limitations check
+	violationEngine, err := NewViolationDetectionEngine()
+	if err != nil {
+		log.Printf("[DOCUMENT_SERVICE] Warning: Could not initialize violation detection engine: %v", err)
+		// Continue with the case-wide limitations check
+	} else {
+		service.violationEngine = violationEngine
+	}
+	service.limitations = NewLimitationsChecker()
+	
 	// Load extraction patterns
 	service.loadExtractionPatterns()
 	
+	// Reuse extractions of documents whose bytes and analyzer config haven't changed
+	service.cache = DefaultExtractionCache()
+	
+	// Document types users corrected in Step 1, which the classifier learns from
+	service.labels = DefaultClassificationLabels()
+	
 	return service
 }
 
 // loadExtractionPatterns loads the JSON patterns for data extraction
 func (s *DocumentService) loadExtractionPatterns() {
-	patternsPath := "/Users/corelogic/satori-dev/clients/proj-mallon/v2/config/extraction_patterns.json"
+	patternsPath := "./config/extraction_patterns.json"
 	
 	data, err := os.ReadFile(patternsPath)
 	if err != nil {
@@ -183,23 +226,18 @@ func (s *DocumentService) loadExtractionPatterns() {
 
 // GetDocuments returns all available documents
 func (s *DocumentService) GetDocuments() ([]Document, error) {
-	files, err := os.ReadDir(s.documentsDir)
+	files, err := s.caseStore.List(s.documentsFolder)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read documents directory: %v", err)
+		return nil, fmt.Errorf("failed to read documents folder: %v", err)
 	}
 	
 	var documents []Document
 	for i, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		
-		info, err := file.Info()
-		if err != nil {
+		if file.IsDirectory {
 			continue
 		}
 		
-		ext := strings.ToLower(filepath.Ext(file.Name()))
+		ext := strings.ToLower(filepath.Ext(file.Name))
 		docType := "unknown"
 		contentType := "unknown"
 		
@@ -207,20 +245,20 @@ func (s *DocumentService) GetDocuments() ([]Document, error) {
 		switch ext {
 		case ".pdf":
 			docType = "pdf"
-			if strings.Contains(file.Name(), "Adverse") {
+			if strings.Contains(file.Name, "Adverse") {
 				contentType = "adverse_action"
-			} else if strings.Contains(file.Name(), "SummonsEquifax") {
+			} else if strings.Contains(file.Name, "SummonsEquifax") {
 				contentType = "summons_equifax"
-			} else if strings.Contains(file.Name(), "Summons") {
+			} else if strings.Contains(file.Name, "Summons") {
 				contentType = "summons"
-			} else if strings.Contains(file.Name(), "Civil Cover") {
+			} else if strings.Contains(file.Name, "Civil Cover") {
 				contentType = "civil_cover_sheet"
 			}
 		case ".docx":
 			docType = "docx"
-			if strings.Contains(file.Name(), "Atty_Notes") {
+			if strings.Contains(file.Name, "Atty_Notes") {
 				contentType = "attorney_notes"
-			} else if strings.Contains(file.Name(), "Complaint") {
+			} else if strings.Contains(file.Name, "Complaint") {
 				contentType = "complaint_form"
 			}
 		}
@@ -228,11 +266,11 @@ func (s *DocumentService) GetDocuments() ([]Document, error) {
 		// Create document object
 		doc := Document{
 			ID:          fmt.Sprintf("doc_%d", i+1),
-			Name:        file.Name(),
+			Name:        file.Name,
 			Type:        docType,
-			Path:        filepath.Join(s.documentsDir, file.Name()),
+			Path:        file.Path,
 			ContentType: contentType,
-			Size:        info.Size(),
+			Size:        file.Size,
 		}
 		
 		documents = append(documents, doc)
@@ -241,150 +279,77 @@ func (s *DocumentService) GetDocuments() ([]Document, error) {
 	return documents, nil
 }
 
+// ReadableDocuments lists the documents directly in a folder of a source that
+// the extractor can read
+func (s *DocumentService) ReadableDocuments(source DocumentSource, folder string) ([]string, error) {
+	objects, err := source.List(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder %s: %v", folder, err)
+	}
+	var documents []string
+	for _, object := range objects {
+		if !object.IsDirectory && s.extractor.IsFormatSupported(object.Name) {
+			documents = append(documents, object.Path)
+		}
+	}
+	return documents, nil
+}
+
 // GetTemplates returns all available templates
 func (s *DocumentService) GetTemplates() ([]Template, error) {
-	// For the prototype, we'll return a predefined list of templates
-	templates := []Template{
-		{
-			ID:   "fcra-credit-card-fraud",
-			Name: "FCRA Complaint - Credit Card Fraud",
-			Desc: "For cases involving fraudulent credit card transactions",
-			Path: filepath.Join(s.documentsDir, "Complaint_Final.docx"),
-		},
-		{
-			ID:   "fcra-identity-theft",
-			Name: "FCRA Complaint - Identity Theft",
-			Desc: "For cases involving wider identity theft issues",
-			Path: "../templates/fcra_identity_theft.docx",
-		},
-		{
-			ID:   "fcra-inaccurate-reporting",
-			Name: "FCRA Complaint - Inaccurate Reporting",
-			Desc: "For cases involving credit report errors",
-			Path: "../templates/fcra_inaccurate_reporting.docx",
-		},
+	if s.templateEngine == nil {
+		return nil, fmt.Errorf("template engine not initialized")
+	}
+	
+	var templates []Template
+	for _, template := range s.templateEngine.ListTemplates() {
+		templates = append(templates, Template{
+			ID:      template.ID,
+			Name:    template.Name,
+			Desc:    template.Description,
+			Path:    template.SourceFile,
+			Version: template.Version,
+			Issues:  template.Issues,
+		})
 	}
 	
 	return templates, nil
 }
 
+// GetTemplateLoadErrors returns schema problems found in the templates directory
+func (s *DocumentService) GetTemplateLoadErrors() []TemplateLoadError {
+	if s.templateEngine == nil {
+		return nil
+	}
+	return s.templateEngine.GetLoadErrors()
+}
+
 // ProcessSelectedDocuments processes documents selected in Step 1 and generates dynamic case data
 func (s *DocumentService) ProcessSelectedDocuments(selectedDocIDs []string, templateID string) (*DocumentProcessingResult, *ClientCase, error) {
-	log.Printf("[DOCUMENT_SERVICE] Processing %d selected documents with DYNAMIC analysis engine", len(selectedDocIDs))
-	
-	if s.contentAnalyzer == nil {
-		return nil, nil, fmt.Errorf("content analyzer not initialized")
-	}
-	
-	// Initialize result structures
-	selectedDocs := []Document{}
-	extractedData := make(map[string]interface{})
-	missingContent := []MissingContent{}
-	allExtractedText := make(map[string]string)
-	allAnalysisResults := make(map[string]*LegalAnalysisResult)
-	
-	// Initialize ClientCase with empty values
-	clientCase := ClientCase{}
-	
-	// Track what types of documents we have
-	documentTypes := make(map[string]bool)
-	
-	// Process each selected document with real text extraction and intelligent analysis
-	for _, docPath := range selectedDocIDs {
-		log.Printf("[DOCUMENT_SERVICE] Processing document: %s", docPath)
-		
-		// Extract text from the document
-		content, err := s.extractor.ExtractText(docPath)
-		if err != nil {
-			log.Printf("[DOCUMENT_SERVICE] Error extracting text from %s: %v", docPath, err)
-			continue
-		}
-		
-		// Store extracted text for cross-reference
-		fileName := filepath.Base(docPath)
-		allExtractedText[fileName] = content.RawText
-		
-		// Create Document object
-		fileInfo, _ := os.Stat(docPath)
-		size := int64(0)
-		if fileInfo != nil {
-			size = fileInfo.Size()
-		}
-		
-		doc := Document{
-			ID:          fmt.Sprintf("doc_%d", len(selectedDocs)+1),
-			Name:        fileName,
-			Type:        strings.ToLower(filepath.Ext(fileName)),
-			Path:        docPath,
-			ContentType: s.determineContentType(fileName),
-			Size:        size,
-		}
-		selectedDocs = append(selectedDocs, doc)
-		
-		// Track document types
-		documentTypes[doc.ContentType] = true
-		
-		// Perform intelligent analysis of document content
-		analysis, err := s.contentAnalyzer.AnalyzeLegalContent(content.RawText, doc.ContentType)
-		if err != nil {
-			log.Printf("[DOCUMENT_SERVICE] Error analyzing %s: %v", fileName, err)
-			continue
-		}
-		
-		// Enhanced attorney notes analysis
-		if doc.ContentType == "attorney_notes" && s.attorneyNotesAnalyzer != nil {
-			log.Printf("[DOCUMENT_SERVICE] Performing specialized attorney notes analysis for %s", fileName)
-			attorneyAnalysis, err := s.attorneyNotesAnalyzer.AnalyzeAttorneyNotes(docPath, content.RawText)
-			if err != nil {
-				log.Printf("[DOCUMENT_SERVICE] Warning: Attorney notes analysis failed for %s: %v", fileName, err)
-			} else {
-				// Enhance the analysis with attorney intelligence
-				analysis = s.enhanceAnalysisWithAttorneyIntelligence(analysis, attorneyAnalysis)
-				log.Printf("[DOCUMENT_SERVICE] Enhanced %s with attorney intelligence - %.1f%% confidence", 
-					fileName, attorneyAnalysis.ConfidenceScores.OverallConfidence)
-			}
-		}
-		
-		// Civil cover sheet specialized analysis
-		if doc.ContentType == "civil_cover_sheet" && s.civilCoverSheetAnalyzer != nil {
-			log.Printf("[DOCUMENT_SERVICE] Performing civil cover sheet legal mapping for %s", fileName)
-			coverSheetAnalysis, err := s.civilCoverSheetAnalyzer.AnalyzeCivilCoverSheet(docPath, content.RawText)
-			if err != nil {
-				log.Printf("[DOCUMENT_SERVICE] Warning: Civil cover sheet analysis failed for %s: %v", fileName, err)
-			} else {
-				// Store civil cover sheet analysis for legal framework enhancement
-				extractedData["civil_cover_sheet_analysis"] = coverSheetAnalysis
-				log.Printf("[DOCUMENT_SERVICE] Civil cover sheet analysis complete - %.1f%% confidence, nature of suit: %s", 
-					coverSheetAnalysis.AnalysisMetadata.ConfidenceScore, coverSheetAnalysis.NatureOfSuit.PrimaryCode)
-			}
+	return s.ProcessSelectedDocumentsFromSource(s.caseStore, selectedDocIDs, templateID)
+}
+
+// ProcessSelectedDocumentsFromSource processes selected documents read from a
+// document source, using the processing pipeline's default workers and timeout
+func (s *DocumentService) ProcessSelectedDocumentsFromSource(source DocumentSource, selectedDocIDs []string, templateID string) (*DocumentProcessingResult, *ClientCase, error) {
+	return s.ProcessSelectedDocumentsContext(context.Background(), source, selectedDocIDs, templateID, ProcessingOptions{})
+}
+
+// locateAnalysisSources records the document, page and span behind each
+// extracted value and classification indicator
+func (s *DocumentService) locateAnalysisSources(analysis *LegalAnalysisResult, docPath string, content *ExtractedContent) {
+	for key, result := range analysis.ClientData {
+		analysis.ClientData[key] = locateExtraction(result, docPath, content)
+	}
+	for key, result := range analysis.FraudDetails {
+		analysis.FraudDetails[key] = locateExtraction(result, docPath, content)
+	}
+	for i := range analysis.DocumentClassifications {
+		indicators := analysis.DocumentClassifications[i].ContentIndicators
+		for j, indicator := range indicators {
+			indicators[j] = locateIndicator(indicator, docPath, content)
 		}
-		
-		allAnalysisResults[fileName] = analysis
-		log.Printf("[DOCUMENT_SERVICE] Analyzed %s - %.1f%% confidence, %d violations found", 
-			fileName, analysis.OverallConfidence, len(analysis.LegalViolations))
-	}
-	
-	// Correlate and merge analysis results into ClientCase
-	s.correlateAnalysisResults(allAnalysisResults, &clientCase, extractedData)
-	
-	// Analyze missing content based on intelligent analysis
-	missingContent = s.analyzeIntelligentMissingContent(&clientCase, documentTypes, allAnalysisResults)
-	
-	// Calculate data coverage based on populated fields
-	dataCoverage := s.calculateIntelligentDataCoverage(&clientCase, allAnalysisResults)
-	
-	// Create processing result
-	processingResult := &DocumentProcessingResult{
-		SelectedDocuments: selectedDocs,
-		ExtractedData:     extractedData,
-		MissingContent:    missingContent,
-		DataCoverage:      dataCoverage,
 	}
-	
-	log.Printf("[DOCUMENT_SERVICE] DYNAMIC extraction complete - %d documents processed, %.1f%% data coverage", 
-		len(selectedDocs), dataCoverage)
-	
-	return processingResult, &clientCase, nil
 }
 
 // determineContentType identifies the type of legal document based on filename
@@ -694,6 +659,8 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 	bestInstitutionConfidence := 0.0
 	bestTravelLocation := ""
 	bestTravelConfidence := 0.0
+	provenance := make(map[string]FieldProvenance)
+	bureauSources := []string{}
 	
 	allViolations := []string{}
 	creditBureaus := []string{}
@@ -707,6 +674,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 			if clientName.Confidence > bestClientNameConfidence {
 				bestClientName = clientName.Value.(string)
 				bestClientNameConfidence = clientName.Confidence
+				provenance["clientName"] = provenanceFromExtraction("clientName", clientName)
 				log.Printf("[DOCUMENT_SERVICE] Updated client name: %s (%.1f%% confidence)", bestClientName, clientName.Confidence)
 			}
 		}
@@ -716,6 +684,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 			if phone.Confidence > bestPhoneConfidence {
 				bestPhone = phone.Value.(string)
 				bestPhoneConfidence = phone.Confidence
+				provenance["contactInfo"] = provenanceFromExtraction("contactInfo", phone)
 				log.Printf("[DOCUMENT_SERVICE] Updated phone: %s (%.1f%% confidence)", bestPhone, phone.Confidence)
 			}
 		}
@@ -725,6 +694,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 			if fraudAmount.Confidence > bestFraudAmountConfidence {
 				bestFraudAmount = fraudAmount.Value.(string)
 				bestFraudAmountConfidence = fraudAmount.Confidence
+				provenance["fraudAmount"] = provenanceFromExtraction("fraudAmount", fraudAmount)
 				log.Printf("[DOCUMENT_SERVICE] Updated fraud amount: %s (%.1f%% confidence)", bestFraudAmount, fraudAmount.Confidence)
 			}
 		}
@@ -734,6 +704,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 			if institution.Confidence > bestInstitutionConfidence {
 				bestInstitution = institution.Value.(string)
 				bestInstitutionConfidence = institution.Confidence
+				provenance["financialInstitution"] = provenanceFromExtraction("financialInstitution", institution)
 				log.Printf("[DOCUMENT_SERVICE] Updated institution: %s (%.1f%% confidence)", bestInstitution, institution.Confidence)
 			}
 		}
@@ -743,6 +714,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 			if travel.Confidence > bestTravelConfidence {
 				bestTravelLocation = travel.Value.(string)
 				bestTravelConfidence = travel.Confidence
+				provenance["travelLocation"] = provenanceFromExtraction("travelLocation", travel)
 				log.Printf("[DOCUMENT_SERVICE] Updated travel location: %s (%.1f%% confidence)", bestTravelLocation, travel.Confidence)
 			}
 		}
@@ -757,6 +729,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 		
 		// Extract credit bureaus from summons documents
 		if strings.Contains(strings.ToLower(fileName), "summons") {
+			bureauSources = append(bureauSources, fileName)
 			if strings.Contains(strings.ToLower(fileName), "equifax") {
 				creditBureaus = append(creditBureaus, "Equifax")
 			}
@@ -775,6 +748,7 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 	clientCase.FraudAmount = bestFraudAmount
 	clientCase.FinancialInstitution = bestInstitution
 	clientCase.TravelLocation = bestTravelLocation
+	clientCase.Provenance = provenance
 	
 	// Set credit impact and credit bureaus
 	if len(creditImpact) > 0 {
@@ -782,14 +756,25 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 	}
 	if len(creditBureaus) > 0 {
 		clientCase.CreditBureauDisputes = removeDuplicates(creditBureaus)
+		clientCase.SetProvenance(FieldProvenance{
+			Field:      "creditBureauDisputes",
+			Value:      strings.Join(clientCase.CreditBureauDisputes, ", "),
+			Document:   strings.Join(bureauSources, ", "),
+			Method:     "Summons Filename",
+			Confidence: 0.7,
+		})
 	} else {
 		// Default credit bureaus if not specifically identified
 		clientCase.CreditBureauDisputes = []string{"Experian", "Equifax", "Trans Union"}
+		clientCase.setDefaultProvenance("creditBureauDisputes", strings.Join(clientCase.CreditBureauDisputes, ", "),
+			ProvenanceMethodDefault, "No summons documents named a credit bureau")
 	}
 	
 	// Set some reasonable defaults based on extracted data
 	if clientCase.ClientName != "" {
 		clientCase.ResidenceLocation = "United States" // Could be extracted from address patterns
+		clientCase.setDefaultProvenance("residenceLocation", clientCase.ResidenceLocation,
+			ProvenanceMethodDefault, "Residence was not found in the documents")
 	}
 	if clientCase.FraudAmount != "" {
 		clientCase.FraudDetails = fmt.Sprintf("Fraudulent charges totaling %s", clientCase.FraudAmount)
@@ -797,6 +782,21 @@ func (s *DocumentService) correlateAnalysisResults(analysisResults map[string]*L
 		clientCase.DiscoveryDate = time.Now().AddDate(0, -3, 0) // 3 months ago
 		clientCase.FraudStartDate = time.Now().AddDate(0, -6, 0) // 6 months ago  
 		clientCase.FraudEndDate = time.Now().AddDate(0, -3, 0) // 3 months ago
+		
+		// FraudDetails points back at the amount it was built from; the dates are estimates
+		derived := provenance["fraudAmount"]
+		derived.Field = "fraudDetails"
+		derived.Value = clientCase.FraudDetails
+		derived.Method = ProvenanceMethodDerived + " from fraudAmount"
+		clientCase.SetProvenance(derived)
+		for field, date := range map[string]time.Time{
+			"discoveryDate":  clientCase.DiscoveryDate,
+			"fraudStartDate": clientCase.FraudStartDate,
+			"fraudEndDate":   clientCase.FraudEndDate,
+		} {
+			clientCase.setDefaultProvenance(field, formatProvenanceDate(date),
+				ProvenanceMethodDefault, "Estimated from the processing date; no date was extracted")
+		}
 	}
 	
 	// Store analysis data for UI
@@ -941,8 +941,9 @@ func (s *DocumentService) GenerateComplaint(templateID string, clientCase *Clien
 	
 	log.Printf("[DOCUMENT_SERVICE] Generating complaint using template: %s for client: %s", templateID, clientCase.ClientName)
 	
-	// Convert ClientCase to enhanced format for template engine
-	enhancedClientCase := s.convertToEnhancedClientCase(clientCase)
+	// Convert ClientCase to enhanced format for template engine, starting
+	// from the attorney's corrections of the extracted values
+	enhancedClientCase := s.convertToEnhancedClientCase(clientCase.WithFieldOverrides())
 	
 	// Generate document using template engine
 	document, err := s.templateEngine.GenerateDocument(templateID, enhancedClientCase)
@@ -995,9 +996,88 @@ func (s *DocumentService) convertToEnhancedClientCase(basic *ClientCase) *Client
 	// Add defendants based on available information
 	enhanced.Defendants = s.generateDefendants(basic)
 	
+	// Carry the extracted sources over and note the values filled in here
+	for _, provenance := range basic.Provenance {
+		enhanced.SetProvenance(provenance)
+	}
+	
+	// The template engine applies the overrides again over the values filled in here
+	enhanced.FieldOverrides = basic.FieldOverrides
+	
+	// Carry the limitations check over so time-barred counts stay out. A case
+	// saved without one, e.g. ClientCase JSON written before processing ran
+	// it, is checked per violation from the documents its values came from.
+	enhanced.LimitationsOverrides = basic.LimitationsOverrides
+	if basic.Limitations != nil {
+		enhanced.SetLimitations(*basic.Limitations)
+	} else {
+		processingResult, documents := provenanceEvidence(basic)
+		enhanced.SetLimitations(s.checkLimitations(processingResult, basic, documents))
+	}
+	enhanced.setDefaultProvenance("residenceLocation", enhanced.ResidenceLocation,
+		ProvenanceMethodDefault, "Residence was not found in the documents")
+	enhanced.setDefaultProvenance("courtJurisdiction", enhanced.CourtJurisdiction,
+		ProvenanceMethodDefault, "Jurisdiction defaults to the Southern District of New York")
+	enhanced.setDefaultProvenance("caseNumber", enhanced.CaseNumber,
+		ProvenanceMethodDefault, "Case number is assigned by the court at filing")
+	enhanced.setDefaultProvenance("defendants", fmt.Sprintf("%d defendants", len(enhanced.Defendants)),
+		ProvenanceMethodDerived+" from creditBureauDisputes and financialInstitution",
+		"The three national credit bureaus plus the financial institution")
+	
 	return enhanced
 }
 
+// checkLimitations applies § 1681p to each violation the documents support,
+// dated by the violation, so counts under a time-barred section are left out
+// of the complaint. Without detected violations the case is checked as a whole.
+func (s *DocumentService) checkLimitations(processingResult *DocumentProcessingResult, clientCase *ClientCase, documents []string) LimitationsAnalysis {
+	var violations []DetectedViolation
+	if s.violationEngine != nil {
+		detected, err := s.violationEngine.DetectViolations(processingResult, clientCase, documents)
+		if err != nil {
+			log.Printf("[DOCUMENT_SERVICE] Warning: Violation detection failed, checking limitations case-wide: %v", err)
+		} else {
+			violations = detected
+		}
+	}
+
+	analysis := s.limitations.Check(clientCase, violations)
+	log.Printf("[DOCUMENT_SERVICE] Limitations check complete: %d of %d claims time-barred", analysis.TimeBarredCount, len(analysis.Claims))
+	return analysis
+}
+
+// RecheckLimitations checks the case again with the attorney's field overrides
+// applied, e.g. after the discovery date was corrected, keeping the limitations
+// overrides. Without a processing result the documents come from the
+// extracted values' provenance.
+func (s *DocumentService) RecheckLimitations(processingResult *DocumentProcessingResult, clientCase *ClientCase, documents []string) {
+	effective := clientCase.WithFieldOverrides()
+	if processingResult == nil {
+		processingResult, documents = provenanceEvidence(clientCase)
+	}
+	clientCase.SetLimitations(s.checkLimitations(processingResult, effective, documents))
+}
+
+// provenanceEvidence rebuilds the documents and extracted values behind a
+// case from its provenance, for a case saved without its processing result
+func provenanceEvidence(clientCase *ClientCase) (*DocumentProcessingResult, []string) {
+	result := &DocumentProcessingResult{ExtractedData: make(map[string]interface{})}
+	documents := []string{}
+	seen := make(map[string]bool)
+	for field, provenance := range clientCase.Provenance {
+		if provenance.Document == "" {
+			continue
+		}
+		result.ExtractedData[field] = provenance.Value
+		if !seen[provenance.Document] {
+			seen[provenance.Document] = true
+			documents = append(documents, provenance.Document)
+		}
+	}
+	sort.Strings(documents)
+	return result, documents
+}
+
 // determineResidenceLocation determines the client's residence location
 func (s *DocumentService) determineResidenceLocation(clientCase *ClientCase) string {
 	if clientCase.ResidenceLocation != "" {
@@ -1023,6 +1103,12 @@ func (s *DocumentService) generateCaseNumber() string {
 
 // generateDefendants creates defendant information based on the case
 func (s *DocumentService) generateDefendants(clientCase *ClientCase) []Defendant {
+	return derivedDefendants(clientCase)
+}
+
+// derivedDefendants names the credit bureaus and the financial institution as
+// defendants, for cases whose documents didn't name them
+func derivedDefendants(clientCase *ClientCase) []Defendant {
 	defendants := []Defendant{}
 	
 	// Add credit bureaus as defendants
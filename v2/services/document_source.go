@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Document source providers selectable in Step 0
+const (
+	ProviderCaseStore = "casestore"
+	ProviderLocal     = "local"
+	ProviderWebDAV    = "webdav"
+	ProviderS3        = "s3"
+)
+
+// DocumentSource is a read-only tree of case folders and documents that the
+// Step 0 folder browser and the extraction pipeline can work from. Every
+// CaseStore is also a DocumentSource.
+type DocumentSource interface {
+	List(folder string) ([]StoredObject, error)
+	Stat(objectPath string) (*StoredObject, error)
+	Read(objectPath string) ([]byte, error)
+
+	// LocalPath returns a file on local disk holding the object, for extractors that need one
+	LocalPath(objectPath string) (string, error)
+}
+
+// DocumentProvider describes a provider for the Step 0 provider picker
+type DocumentProvider struct {
+	ID            string `json:"id"`
+	Label         string `json:"label"`
+	Description   string `json:"description"`
+	RequiresLogin bool   `json:"requiresLogin"`
+	Configured    bool   `json:"configured"`
+}
+
+// DocumentSourcesConfig configures the providers besides the case store itself
+type DocumentSourcesConfig struct {
+	Default  string               `json:"default"`
+	CacheDir string               `json:"cacheDir"`
+	Local    LocalCaseStoreConfig `json:"local"`
+	WebDAV   WebDAVSourceConfig   `json:"webdav"`
+	S3       S3CaseStoreConfig    `json:"s3"`
+}
+
+// WebDAVSourceConfig configures a WebDAV server such as Nextcloud or ownCloud.
+// URL is the collection holding the case folders, e.g.
+// https://cloud.example.com/remote.php/dav/files/<user>/Cases
+type WebDAVSourceConfig struct {
+	URL      string `json:"url"`
+	Username string `json:"-"`
+	Password string `json:"-"`
+}
+
+// SourceCredentials are the account details a user enters when connecting a provider
+type SourceCredentials struct {
+	Username string
+	Password string
+}
+
+// Providers lists the document providers and whether each one is configured
+func (c DocumentSourcesConfig) Providers() []DocumentProvider {
+	providers := []DocumentProvider{
+		{
+			ID:          ProviderCaseStore,
+			Label:       "Firm Case Storage",
+			Description: "Case folders in the firm's configured case store",
+			Configured:  true,
+		},
+		{
+			ID:          ProviderLocal,
+			Label:       "Local Folder",
+			Description: "A directory on the server, such as a synced iCloud Drive or network share",
+			Configured:  c.Local.Root != "",
+		},
+		{
+			ID:            ProviderWebDAV,
+			Label:         "WebDAV (Nextcloud / ownCloud)",
+			Description:   "Folders on a WebDAV server, using your account and an app password",
+			RequiresLogin: c.WebDAV.Username == "",
+			Configured:    c.WebDAV.URL != "",
+		},
+		{
+			ID:            ProviderS3,
+			Label:         "S3 Bucket",
+			Description:   "Folders in an S3-compatible bucket such as AWS S3 or MinIO",
+			RequiresLogin: c.S3.AccessKeyID == "",
+			Configured:    c.S3.Bucket != "",
+		},
+	}
+	sort.SliceStable(providers, func(i, j int) bool {
+		return providers[i].Configured && !providers[j].Configured
+	})
+	return providers
+}
+
+// OpenDocumentSource opens a provider other than the case store. Credentials,
+// when given, replace any configured in the environment.
+func OpenDocumentSource(provider string, config DocumentSourcesConfig, credentials *SourceCredentials) (DocumentSource, error) {
+	switch provider {
+	case ProviderLocal:
+		if config.Local.Root == "" {
+			return nil, fmt.Errorf("local folder provider is not configured")
+		}
+		if info, err := os.Stat(config.Local.Root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("local folder %s is not available", config.Local.Root)
+		}
+		return NewLocalCaseStore(config.Local.Root, ""), nil
+
+	case ProviderWebDAV:
+		if credentials != nil {
+			config.WebDAV.Username = credentials.Username
+			config.WebDAV.Password = credentials.Password
+		}
+		return NewWebDAVDocumentSource(config.WebDAV, filepath.Join(config.CacheDir, ProviderWebDAV))
+
+	case ProviderS3:
+		if credentials != nil {
+			config.S3.AccessKeyID = credentials.Username
+			config.S3.SecretAccessKey = credentials.Password
+			config.S3.SessionToken = ""
+		}
+		if config.S3.CacheDir == "" {
+			config.S3.CacheDir = filepath.Join(config.CacheDir, ProviderS3)
+		}
+		return NewS3CaseStore(config.S3, "")
+
+	default:
+		return nil, fmt.Errorf("unknown document provider %q", provider)
+	}
+}
+
+// cacheSourceObject copies a remote object into cacheDir for the extractors,
+// reusing the cached copy while its size and modification time still match
+func cacheSourceObject(cacheDir string, object *StoredObject, read func(string) ([]byte, error)) (string, error) {
+	if object.IsDirectory {
+		return "", fmt.Errorf("%s is a folder", object.Path)
+	}
+
+	localPath := filepath.Join(cacheDir, filepath.FromSlash(object.Path))
+	if info, err := os.Stat(localPath); err == nil && info.Size() == object.Size && !info.ModTime().Before(object.Modified) {
+		return localPath, nil
+	}
+
+	data, err := read(object.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache folder for %s: %v", object.Path, err)
+	}
+	if err := os.WriteFile(localPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %v", object.Path, err)
+	}
+
+	log.Printf("[DOCUMENT_SOURCE] Cached %s (%d bytes)", object.Path, len(data))
+	return localPath, nil
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Where a document version came from
+const (
+	VersionGenerated = "generated" // regenerated by TemplateEngine or the Step 4 preview
+	VersionManual    = "manual"    // saved from the document editor
+	VersionRestored  = "restored"  // an older version restored as the latest
+)
+
+// manualEditWindow is how long editor saves by one author keep updating the same version
+const manualEditWindow = 10 * time.Minute
+
+// GeneratedDocumentVersion is one version of a matter's generated document,
+// with the case data and template it was produced from
+type GeneratedDocumentVersion struct {
+	MatterID        string             `json:"matterId"`
+	Version         int                `json:"version"`
+	DocumentType    string             `json:"documentType"`
+	Source          string             `json:"source"`
+	RestoredFrom    int                `json:"restoredFrom,omitempty"`
+	Author          string             `json:"author,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	TemplateID      string             `json:"templateId,omitempty"`
+	TemplateVersion string             `json:"templateVersion,omitempty"`
+	ClientCase      *ClientCase        `json:"clientCase,omitempty"`
+	Content         string             `json:"content"`
+	ContentHash     string             `json:"contentHash"`
+	Document        *GeneratedDocument `json:"document,omitempty"`
+}
+
+// SourceLabel describes where the version came from
+func (v GeneratedDocumentVersion) SourceLabel() string {
+	switch v.Source {
+	case VersionManual:
+		return "Manual edit"
+	case VersionRestored:
+		return fmt.Sprintf("Restored from v%d", v.RestoredFrom)
+	default:
+		return "Regenerated"
+	}
+}
+
+// Diff row and word operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+	DiffChange = "change"
+)
+
+// DiffSpan is a run of words that is unchanged, inserted or deleted
+type DiffSpan struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// VersionOrigin identifies the version that introduced a line of text
+type VersionOrigin struct {
+	Version int       `json:"version"`
+	Source  string    `json:"source"`
+	Author  string    `json:"author,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Manual reports whether the text was typed in the editor rather than generated
+func (o VersionOrigin) Manual() bool {
+	return o.Source == VersionManual
+}
+
+// Label describes how the text got into the document
+func (o VersionOrigin) Label() string {
+	switch o.Source {
+	case VersionManual:
+		return "Manual edit"
+	case VersionRestored:
+		return "Restored"
+	default:
+		return "Regenerated"
+	}
+}
+
+// DocumentDiffRow is one paragraph of a diff. Changed rows pair an old and a
+// new paragraph and carry word-level spans for both.
+type DocumentDiffRow struct {
+	Op       string         `json:"op"`
+	Old      string         `json:"old,omitempty"`
+	New      string         `json:"new,omitempty"`
+	OldSpans []DiffSpan     `json:"oldSpans,omitempty"`
+	NewSpans []DiffSpan     `json:"newSpans,omitempty"`
+	Spans    []DiffSpan     `json:"spans,omitempty"` // old and new words interleaved, for inline views
+	Origin   *VersionOrigin `json:"origin,omitempty"`
+}
+
+// DocumentVersionDiff compares two versions of a document paragraph by paragraph
+type DocumentVersionDiff struct {
+	From             GeneratedDocumentVersion `json:"from"`
+	To               GeneratedDocumentVersion `json:"to"`
+	Rows             []DocumentDiffRow        `json:"rows"`
+	Added            int                      `json:"added"`
+	Removed          int                      `json:"removed"`
+	Changed          int                      `json:"changed"`
+	ManualChanges    int                      `json:"manualChanges"`
+	GeneratedChanges int                      `json:"generatedChanges"`
+}
+
+var (
+	documentBodyPattern  = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	documentStylePattern = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	documentBlockPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(div|p|h[1-6]|li|tr|pre|blockquote)>`)
+	documentTagPattern   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// DocumentBodyHTML returns the contents of a full HTML page's body, or the
+// input when it is already a fragment
+func DocumentBodyHTML(page string) string {
+	if match := documentBodyPattern.FindStringSubmatch(page); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return strings.TrimSpace(page)
+}
+
+// DocumentParagraphs splits document HTML into its paragraphs of plain text
+func DocumentParagraphs(documentHTML string) []string {
+	text := documentStylePattern.ReplaceAllString(documentHTML, "")
+	text = documentBlockPattern.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(documentTagPattern.ReplaceAllString(text, ""))
+
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return paragraphs
+}
+
+// DiffDocumentVersions compares two versions from a matter's history, which
+// must be in version order. When from is older than to, every added or changed
+// paragraph is attributed to the version between them that introduced it, so
+// manual edits can be told apart from regenerated text.
+func DiffDocumentVersions(history []GeneratedDocumentVersion, from, to int) (*DocumentVersionDiff, error) {
+	fromIndex, toIndex := -1, -1
+	for i, version := range history {
+		if version.Version == from {
+			fromIndex = i
+		}
+		if version.Version == to {
+			toIndex = i
+		}
+	}
+	if fromIndex < 0 || toIndex < 0 {
+		return nil, ErrDocumentVersionNotFound
+	}
+
+	diff := &DocumentVersionDiff{From: history[fromIndex], To: history[toIndex]}
+	oldParagraphs := DocumentParagraphs(diff.From.Content)
+	newParagraphs := DocumentParagraphs(diff.To.Content)
+
+	var origins []*VersionOrigin
+	if fromIndex < toIndex {
+		origins = blameParagraphs(history[fromIndex : toIndex+1])
+	}
+
+	newIndex := 0
+	for _, row := range pairChanges(diffSequences(oldParagraphs, newParagraphs)) {
+		if row.Op == DiffInsert || row.Op == DiffChange {
+			if newIndex < len(origins) {
+				row.Origin = origins[newIndex]
+			}
+			if row.Origin != nil && row.Origin.Manual() {
+				diff.ManualChanges++
+			} else if row.Origin != nil && row.Origin.Source == VersionGenerated {
+				diff.GeneratedChanges++
+			}
+		}
+		if row.Op != DiffDelete {
+			newIndex++
+		}
+
+		switch row.Op {
+		case DiffInsert:
+			diff.Added++
+		case DiffDelete:
+			diff.Removed++
+		case DiffChange:
+			diff.Changed++
+			row.OldSpans, row.NewSpans, row.Spans = diffWords(row.Old, row.New)
+		}
+		diff.Rows = append(diff.Rows, row)
+	}
+	return diff, nil
+}
+
+// blameParagraphs returns, for each paragraph of the last version, the version
+// that introduced it. Paragraphs already in the first version have no origin.
+func blameParagraphs(versions []GeneratedDocumentVersion) []*VersionOrigin {
+	previous := DocumentParagraphs(versions[0].Content)
+	origins := make([]*VersionOrigin, len(previous))
+
+	for _, version := range versions[1:] {
+		current := DocumentParagraphs(version.Content)
+		origin := &VersionOrigin{Version: version.Version, Source: version.Source, Author: version.Author, At: version.CreatedAt}
+
+		next := make([]*VersionOrigin, 0, len(current))
+		oldIndex := 0
+		for _, row := range diffSequences(previous, current) {
+			switch row.Op {
+			case DiffEqual:
+				next = append(next, origins[oldIndex])
+				oldIndex++
+			case DiffDelete:
+				oldIndex++
+			case DiffInsert:
+				next = append(next, origin)
+			}
+		}
+		previous, origins = current, next
+	}
+	return origins
+}
+
+// pairChanges turns each run of deletions followed by insertions into changed
+// rows, one old paragraph against one new, so side-by-side views line up
+func pairChanges(rows []DocumentDiffRow) []DocumentDiffRow {
+	var paired []DocumentDiffRow
+	for i := 0; i < len(rows); {
+		if rows[i].Op != DiffDelete {
+			paired = append(paired, rows[i])
+			i++
+			continue
+		}
+
+		deleteEnd := i
+		for deleteEnd < len(rows) && rows[deleteEnd].Op == DiffDelete {
+			deleteEnd++
+		}
+		insertEnd := deleteEnd
+		for insertEnd < len(rows) && rows[insertEnd].Op == DiffInsert {
+			insertEnd++
+		}
+
+		deletes, inserts := rows[i:deleteEnd], rows[deleteEnd:insertEnd]
+		for j := 0; j < len(deletes) || j < len(inserts); j++ {
+			switch {
+			case j < len(deletes) && j < len(inserts):
+				paired = append(paired, DocumentDiffRow{Op: DiffChange, Old: deletes[j].Old, New: inserts[j].New})
+			case j < len(deletes):
+				paired = append(paired, deletes[j])
+			default:
+				paired = append(paired, inserts[j])
+			}
+		}
+		i = insertEnd
+	}
+	return paired
+}
+
+// diffSequences returns the equal, deleted and inserted rows that turn old
+// into new, from their longest common subsequence
+func diffSequences(old, new []string) []DocumentDiffRow {
+	lcs := longestCommonSuffixes(old, new)
+
+	var rows []DocumentDiffRow
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			rows = append(rows, DocumentDiffRow{Op: DiffEqual, Old: old[i], New: new[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			rows = append(rows, DocumentDiffRow{Op: DiffDelete, Old: old[i]})
+			i++
+		default:
+			rows = append(rows, DocumentDiffRow{Op: DiffInsert, New: new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		rows = append(rows, DocumentDiffRow{Op: DiffDelete, Old: old[i]})
+	}
+	for ; j < len(new); j++ {
+		rows = append(rows, DocumentDiffRow{Op: DiffInsert, New: new[j]})
+	}
+	return rows
+}
+
+// diffWords returns word-level spans for an old and a new paragraph, and both
+// interleaved in reading order
+func diffWords(old, new string) (oldSpans, newSpans, spans []DiffSpan) {
+	for _, row := range diffSequences(strings.Fields(old), strings.Fields(new)) {
+		switch row.Op {
+		case DiffEqual:
+			oldSpans = appendSpan(oldSpans, DiffEqual, row.Old)
+			newSpans = appendSpan(newSpans, DiffEqual, row.New)
+			spans = appendSpan(spans, DiffEqual, row.New)
+		case DiffDelete:
+			oldSpans = appendSpan(oldSpans, DiffDelete, row.Old)
+			spans = appendSpan(spans, DiffDelete, row.Old)
+		case DiffInsert:
+			newSpans = appendSpan(newSpans, DiffInsert, row.New)
+			spans = appendSpan(spans, DiffInsert, row.New)
+		}
+	}
+	return oldSpans, newSpans, spans
+}
+
+// appendSpan adds a word to spans, joining it to the last span when the operation matches
+func appendSpan(spans []DiffSpan, op, word string) []DiffSpan {
+	if last := len(spans) - 1; last >= 0 && spans[last].Op == op {
+		spans[last].Text += " " + word
+		return spans
+	}
+	if len(spans) > 0 {
+		word = " " + word
+	}
+	return append(spans, DiffSpan{Op: op, Text: word})
+}
+
+// longestCommonSuffixes returns the table of common subsequence lengths of
+// old[i:] and new[j:]
+func longestCommonSuffixes(old, new []string) [][]int {
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs
+}
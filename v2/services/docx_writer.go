@@ -0,0 +1,374 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocxWriter writes generated legal documents as Word (.docx) files
+type DocxWriter struct {
+	Style DocumentStyle
+}
+
+// docxParagraph is a single Word paragraph before it is serialized
+type docxParagraph struct {
+	Style    string
+	Text     string
+	NumID    int
+	KeepNext bool
+}
+
+// docxBody accumulates paragraphs and list instances for document.xml
+type docxBody struct {
+	xml        strings.Builder
+	listStarts []int
+	currentNum int
+	lastNumber int
+}
+
+const (
+	twipsPerInch = 1440
+
+	docxStyleCourt     = "CaptionCourt"
+	docxStyleCaption   = "CaptionText"
+	docxStyleTitle     = "CaptionTitle"
+	docxStyleHeading   = "Heading1"
+	docxStyleCount     = "Heading2"
+	docxStyleBody      = "PleadingBody"
+	docxStyleNumbered  = "PleadingNumbered"
+	docxStyleSubItem   = "PleadingSubItem"
+	docxStyleSignature = "SignatureBlock"
+)
+
+// NewDocxWriter creates a Word writer for the given style
+func NewDocxWriter(style DocumentStyle) *DocxWriter {
+	return &DocxWriter{
+		Style: style,
+	}
+}
+
+// Write renders the document into a .docx package
+func (w *DocxWriter) Write(document *GeneratedDocument, caption *PleadingCaption, blocks []pleadingBlock) ([]byte, error) {
+	body := &docxBody{}
+
+	if caption != nil {
+		w.writeCaption(body, caption)
+	}
+
+	for _, block := range blocks {
+		switch block.Kind {
+		case pleadingHeading:
+			for i, line := range strings.Split(block.Text, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+				style := docxStyleHeading
+				if strings.HasPrefix(strings.ToUpper(line), "COUNT") || i > 0 {
+					style = docxStyleCount
+				}
+				body.paragraph(docxParagraph{Style: style, Text: line, KeepNext: true})
+			}
+			// A heading ends any running list so the next numbered paragraph starts fresh
+			body.currentNum = 0
+
+		case pleadingSignature:
+			for _, line := range strings.Split(block.Text, "\n") {
+				body.paragraph(docxParagraph{Style: docxStyleSignature, Text: strings.TrimSpace(line), KeepNext: true})
+			}
+
+		default:
+			for _, line := range strings.Split(block.Text, "\n") {
+				trimmed := strings.TrimLeft(line, " \t")
+				if trimmed == "" {
+					continue
+				}
+
+				if match := numberedParagraphPattern.FindString(trimmed); match != "" {
+					number, _ := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match), ".")))
+					body.paragraph(docxParagraph{
+						Style: docxStyleNumbered,
+						Text:  strings.TrimSpace(trimmed[len(match):]),
+						NumID: body.listFor(number),
+					})
+					continue
+				}
+
+				style := docxStyleBody
+				if len(line) != len(trimmed) {
+					style = docxStyleSubItem
+				}
+				body.paragraph(docxParagraph{Style: style, Text: trimmed})
+			}
+		}
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", w.coreProperties(document)},
+		{"docProps/app.xml", docxAppProperties},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/document.xml", w.documentXML(body)},
+		{"word/styles.xml", w.stylesXML()},
+		{"word/numbering.xml", w.numberingXML(body.listStarts)},
+		{"word/footer1.xml", docxFooter},
+		{"word/settings.xml", docxSettings},
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to DOCX: %v", file.name, err)
+		}
+		if _, err := writer.Write([]byte(file.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s to DOCX: %v", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish DOCX: %v", err)
+	}
+
+	log.Printf("[DOCX_WRITER] Wrote %q: %d lists, %d bytes", document.Title, len(body.listStarts), buffer.Len())
+	return buffer.Bytes(), nil
+}
+
+// writeCaption writes the court name and a borderless caption table
+func (w *DocxWriter) writeCaption(body *docxBody, caption *PleadingCaption) {
+	for _, courtLine := range caption.CourtLines {
+		body.paragraph(docxParagraph{Style: docxStyleCourt, Text: strings.ToUpper(courtLine), KeepNext: true})
+	}
+
+	var left []string
+	left = append(left, strings.ToUpper(caption.Plaintiff)+",", "\tPlaintiff,", "", "v.", "")
+	for _, defendant := range caption.Defendants {
+		left = append(left, strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(defendant)), ",")+",")
+	}
+	left = append(left, "\tDefendants.")
+
+	caseNumber := caption.CaseNumber
+	if caseNumber == "" {
+		caseNumber = "____________"
+	}
+	right := []string{"", "", "Case No. " + caseNumber, "", strings.ToUpper(caption.Title)}
+
+	separators := make([]string, len(left))
+	for i := range separators {
+		separators[i] = ")"
+	}
+
+	// Widths are in twips; the table spans the text area between the margins
+	textWidth := int((8.5 - w.Style.Margins.Left - w.Style.Margins.Right) * twipsPerInch)
+	leftWidth := textWidth * 55 / 100
+	middleWidth := 360
+	rightWidth := textWidth - leftWidth - middleWidth
+
+	body.xml.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="` + strconv.Itoa(textWidth) + `" w:type="dxa"/>`)
+	body.xml.WriteString(`<w:tblBorders><w:top w:val="nil"/><w:left w:val="nil"/><w:bottom w:val="nil"/><w:right w:val="nil"/><w:insideH w:val="nil"/><w:insideV w:val="nil"/></w:tblBorders>`)
+	body.xml.WriteString(`<w:tblLayout w:type="fixed"/><w:tblLook w:val="0000"/></w:tblPr>`)
+	body.xml.WriteString(`<w:tblGrid><w:gridCol w:w="` + strconv.Itoa(leftWidth) + `"/><w:gridCol w:w="` + strconv.Itoa(middleWidth) + `"/><w:gridCol w:w="` + strconv.Itoa(rightWidth) + `"/></w:tblGrid>`)
+	body.xml.WriteString(`<w:tr>`)
+	body.cell(leftWidth, left, docxStyleCaption, `<w:bottom w:val="single" w:sz="4" w:space="0" w:color="000000"/>`)
+	body.cell(middleWidth, separators, docxStyleCaption, "")
+	body.cell(rightWidth, right, docxStyleTitle, "")
+	body.xml.WriteString(`</w:tr></w:tbl>`)
+
+	// Word needs a paragraph between a table and whatever follows it
+	body.paragraph(docxParagraph{Style: docxStyleBody})
+}
+
+// cell writes a single table cell with one paragraph per line
+func (b *docxBody) cell(width int, lines []string, style string, borders string) {
+	b.xml.WriteString(`<w:tc><w:tcPr><w:tcW w:w="` + strconv.Itoa(width) + `" w:type="dxa"/>`)
+	if borders != "" {
+		b.xml.WriteString(`<w:tcBorders>` + borders + `</w:tcBorders>`)
+	}
+	b.xml.WriteString(`</w:tcPr>`)
+	for _, line := range lines {
+		b.paragraph(docxParagraph{Style: style, Text: line})
+	}
+	b.xml.WriteString(`</w:tc>`)
+}
+
+// listFor returns the list instance for a numbered paragraph, starting a new
+// list whenever the source numbering restarts
+func (b *docxBody) listFor(number int) int {
+	if b.currentNum == 0 || number <= b.lastNumber {
+		b.listStarts = append(b.listStarts, number)
+		b.currentNum = len(b.listStarts)
+	}
+	b.lastNumber = number
+	return b.currentNum
+}
+
+// paragraph serializes a paragraph into the body
+func (b *docxBody) paragraph(p docxParagraph) {
+	b.xml.WriteString(`<w:p><w:pPr><w:pStyle w:val="` + p.Style + `"/>`)
+	if p.KeepNext {
+		b.xml.WriteString(`<w:keepNext/>`)
+	}
+	if p.NumID > 0 {
+		b.xml.WriteString(`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="` + strconv.Itoa(p.NumID) + `"/></w:numPr>`)
+	}
+	b.xml.WriteString(`</w:pPr>`)
+
+	for i, segment := range strings.Split(p.Text, "\t") {
+		if i > 0 {
+			b.xml.WriteString(`<w:r><w:tab/></w:r>`)
+		}
+		if segment != "" {
+			b.xml.WriteString(`<w:r><w:t xml:space="preserve">` + xmlEscape(segment) + `</w:t></w:r>`)
+		}
+	}
+	b.xml.WriteString(`</w:p>`)
+}
+
+// documentXML wraps the body with the page setup for the pleading
+func (w *DocxWriter) documentXML(body *docxBody) string {
+	margin := func(inches float64) string { return strconv.Itoa(int(inches * twipsPerInch)) }
+
+	var doc strings.Builder
+	doc.WriteString(xml.Header)
+	doc.WriteString(`<w:document xmlns:w="` + docxWordNamespace + `" xmlns:r="` + docxRelNamespace + `"><w:body>`)
+	doc.WriteString(body.xml.String())
+	doc.WriteString(`<w:sectPr><w:footerReference w:type="default" r:id="rIdFooter1"/>`)
+	doc.WriteString(`<w:pgSz w:w="12240" w:h="15840"/>`)
+	doc.WriteString(`<w:pgMar w:top="` + margin(w.Style.Margins.Top) + `" w:right="` + margin(w.Style.Margins.Right) +
+		`" w:bottom="` + margin(w.Style.Margins.Bottom) + `" w:left="` + margin(w.Style.Margins.Left) +
+		`" w:header="720" w:footer="720" w:gutter="0"/>`)
+	doc.WriteString(`<w:lnNumType w:countBy="1" w:distance="360" w:restart="newPage"/>`)
+	doc.WriteString(`</w:sectPr></w:body></w:document>`)
+	return doc.String()
+}
+
+// stylesXML defines the paragraph styles used by generated complaints
+func (w *DocxWriter) stylesXML() string {
+	font := xmlEscape(w.Style.FontFamily)
+	size := strconv.Itoa(w.Style.FontSize * 2)
+	line := strconv.Itoa(int(240 * w.Style.LineSpacing))
+
+	paragraphStyle := func(id, name, pPr, rPr string) string {
+		return `<w:style w:type="paragraph" w:customStyle="1" w:styleId="` + id + `"><w:name w:val="` + name + `"/>` +
+			`<w:basedOn w:val="Normal"/><w:qFormat/><w:pPr>` + pPr + `</w:pPr><w:rPr>` + rPr + `</w:rPr></w:style>`
+	}
+
+	var styles strings.Builder
+	styles.WriteString(xml.Header)
+	styles.WriteString(`<w:styles xmlns:w="` + docxWordNamespace + `">`)
+	styles.WriteString(`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="` + font + `" w:hAnsi="` + font + `" w:cs="` + font + `"/>`)
+	styles.WriteString(`<w:sz w:val="` + size + `"/><w:szCs w:val="` + size + `"/></w:rPr></w:rPrDefault>`)
+	styles.WriteString(`<w:pPrDefault><w:pPr><w:spacing w:after="0" w:line="` + line + `" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>`)
+	styles.WriteString(`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>`)
+
+	// Caption lines are single spaced so the table stays on the first page
+	single := `<w:spacing w:line="240" w:lineRule="auto"/>`
+	styles.WriteString(paragraphStyle(docxStyleCourt, "Caption Court", `<w:jc w:val="center"/>`, `<w:b/><w:caps/>`))
+	styles.WriteString(paragraphStyle(docxStyleCaption, "Caption Text", single+`<w:tabs><w:tab w:val="left" w:pos="2880"/></w:tabs>`, ""))
+	styles.WriteString(paragraphStyle(docxStyleTitle, "Caption Title", single+`<w:ind w:left="144"/>`, `<w:b/>`))
+
+	styles.WriteString(`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="PleadingBody"/><w:qFormat/>` +
+		`<w:pPr><w:keepNext/><w:jc w:val="center"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:u w:val="single"/></w:rPr></w:style>`)
+	styles.WriteString(`<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="PleadingNumbered"/><w:qFormat/>` +
+		`<w:pPr><w:keepNext/><w:jc w:val="center"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>`)
+
+	styles.WriteString(paragraphStyle(docxStyleBody, "Pleading Body", `<w:ind w:firstLine="720"/><w:jc w:val="both"/>`, ""))
+	styles.WriteString(paragraphStyle(docxStyleNumbered, "Pleading Numbered", `<w:ind w:firstLine="720"/><w:jc w:val="both"/>`, ""))
+	styles.WriteString(paragraphStyle(docxStyleSubItem, "Pleading Sub Item", `<w:ind w:left="1440"/>`, ""))
+	styles.WriteString(paragraphStyle(docxStyleSignature, "Signature Block", single+`<w:ind w:left="4680"/>`, ""))
+
+	styles.WriteString(`</w:styles>`)
+	return styles.String()
+}
+
+// numberingXML defines one decimal list per run of numbered paragraphs
+func (w *DocxWriter) numberingXML(listStarts []int) string {
+	var numbering strings.Builder
+	numbering.WriteString(xml.Header)
+	numbering.WriteString(`<w:numbering xmlns:w="` + docxWordNamespace + `">`)
+	numbering.WriteString(`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="singleLevel"/>`)
+	numbering.WriteString(`<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="decimal"/><w:lvlText w:val="%1."/><w:lvlJc w:val="left"/>`)
+	numbering.WriteString(`<w:pPr><w:ind w:left="0" w:firstLine="720"/></w:pPr></w:lvl></w:abstractNum>`)
+	for i, start := range listStarts {
+		numbering.WriteString(`<w:num w:numId="` + strconv.Itoa(i+1) + `"><w:abstractNumId w:val="0"/>`)
+		numbering.WriteString(`<w:lvlOverride w:ilvl="0"><w:startOverride w:val="` + strconv.Itoa(start) + `"/></w:lvlOverride></w:num>`)
+	}
+	numbering.WriteString(`</w:numbering>`)
+	return numbering.String()
+}
+
+// coreProperties records the title and creation time
+func (w *DocxWriter) coreProperties(document *GeneratedDocument) string {
+	created := document.Metadata.GeneratedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>` + xmlEscape(document.Title) + `</dc:title><dc:creator>Mallon Legal Assistant</dc:creator>` +
+		`<dcterms:created xsi:type="dcterms:W3CDTF">` + created.UTC().Format(time.RFC3339) + `</dcterms:created>` +
+		`</cp:coreProperties>`
+}
+
+// xmlEscape escapes text for use inside WordprocessingML
+func xmlEscape(text string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
+
+const (
+	docxWordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	docxRelNamespace  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+		`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+		`<Override PartName="/word/settings.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"/>` +
+		`<Override PartName="/word/footer1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>` +
+		`</Types>`
+
+	docxPackageRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>` +
+		`</Relationships>`
+
+	docxDocumentRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>` +
+		`<Relationship Id="rIdSettings" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>` +
+		`<Relationship Id="rIdFooter1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="footer1.xml"/>` +
+		`</Relationships>`
+
+	docxAppProperties = xml.Header + `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+		`<Application>Mallon Legal Assistant</Application></Properties>`
+
+	docxSettings = xml.Header + `<w:settings xmlns:w="` + docxWordNamespace + `"><w:defaultTabStop w:val="720"/></w:settings>`
+
+	// The footer carries "Page X of Y" as Word fields so it stays correct after editing
+	docxFooter = xml.Header + `<w:ftr xmlns:w="` + docxWordNamespace + `"><w:p><w:pPr><w:jc w:val="center"/></w:pPr>` +
+		`<w:r><w:t xml:space="preserve">Page </w:t></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> PAGE </w:instrText></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>1</w:t></w:r><w:r><w:fldChar w:fldCharType="end"/></w:r>` +
+		`<w:r><w:t xml:space="preserve"> of </w:t></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> NUMPAGES </w:instrText></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>1</w:t></w:r><w:r><w:fldChar w:fldCharType="end"/></w:r>` +
+		`</w:p></w:ftr>`
+)
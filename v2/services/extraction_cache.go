@@ -0,0 +1,266 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Whether a document's extraction and analysis were computed or read from the cache
+const (
+	ExtractionFresh  = "fresh"
+	ExtractionCached = "cached"
+)
+
+// extractionCacheSchema is bumped when the extractor or analyzers change in a
+// way that makes earlier cache entries wrong
+const extractionCacheSchema = "3"
+
+// analyzerConfigFiles are the pattern files the extractor and analyzers load at
+// startup. Editing any of them gives the cache a new config version.
+var analyzerConfigFiles = []string{
+	"./config/extraction_patterns.json",
+	"./config/legal_patterns.json",
+	"./config/legal_patterns_enhanced.json",
+	"./config/attorney_analysis_patterns.json",
+}
+
+// ExtractionCacheConfig configures the extraction cache; an empty Dir turns it off
+type ExtractionCacheConfig struct {
+	Dir string `json:"dir"`
+}
+
+// ExtractionCache keeps the extracted text and analysis of each document on
+// disk, keyed by the SHA-256 of the document's bytes. Entries live under a
+// folder for the analyzer config version, so changing the pattern files
+// starts an empty cache and the old one is removed.
+type ExtractionCache struct {
+	dir           string
+	configVersion string
+
+	// mu serializes updates, which read, change and rewrite an entry
+	mu sync.Mutex
+}
+
+// extractionCacheEntry is one document's cached extraction and its analysis
+// for each content type it has been analyzed as
+type extractionCacheEntry struct {
+	ContentHash   string                       `json:"contentHash"`
+	ConfigVersion string                       `json:"configVersion"`
+	CachedAt      time.Time                    `json:"cachedAt"`
+	Content       *ExtractedContent            `json:"content"`
+	Analyses      map[string]*documentAnalysis `json:"analyses,omitempty"`
+}
+
+var (
+	defaultExtractionCache     *ExtractionCache
+	defaultExtractionCacheOnce sync.Once
+)
+
+// DefaultExtractionCache returns the extraction cache configured in
+// config/storage.json, or nil when it is turned off or cannot be opened
+func DefaultExtractionCache() *ExtractionCache {
+	defaultExtractionCacheOnce.Do(func() {
+		_, config := DefaultCaseStore()
+		if config.ExtractionCache.Dir == "" {
+			log.Printf("[EXTRACTION_CACHE] No cache folder configured - documents are extracted on every run")
+			return
+		}
+
+		cache, err := OpenExtractionCache(config.ExtractionCache.Dir, analyzerConfigFiles)
+		if err != nil {
+			log.Printf("[EXTRACTION_CACHE] Warning: %v - documents are extracted on every run", err)
+			return
+		}
+		defaultExtractionCache = cache
+		log.Printf("[EXTRACTION_CACHE] Using %s for analyzer config version %s", cache.dir, cache.configVersion)
+	})
+	return defaultExtractionCache
+}
+
+// OpenExtractionCache opens the cache in dir for the current contents of the
+// analyzer config files, removing entries made with any other version
+func OpenExtractionCache(dir string, configFiles []string) (*ExtractionCache, error) {
+	version, err := analyzerConfigVersion(configFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ExtractionCache{dir: dir, configVersion: version}
+	if err := os.MkdirAll(cache.versionDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create extraction cache folder: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extraction cache folder: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != version {
+			log.Printf("[EXTRACTION_CACHE] Removing entries for old analyzer config version %s", entry.Name())
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				log.Printf("[EXTRACTION_CACHE] Warning: %v", err)
+			}
+		}
+	}
+	return cache, nil
+}
+
+// ConfigVersion identifies the analyzer config the cached results were made with
+func (c *ExtractionCache) ConfigVersion() string {
+	return c.configVersion
+}
+
+// HashFile returns the SHA-256 of a file's bytes, the key of its cache entry
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Content returns the cached extraction of a document
+func (c *ExtractionCache) Content(contentHash string) (*ExtractedContent, bool) {
+	entry, ok := c.load(contentHash)
+	if !ok || entry.Content == nil {
+		return nil, false
+	}
+	return entry.Content, true
+}
+
+// Analysis returns the cached analysis of a document as a content type
+func (c *ExtractionCache) Analysis(contentHash, contentType string) (*documentAnalysis, bool) {
+	entry, ok := c.load(contentHash)
+	if !ok {
+		return nil, false
+	}
+	analysis, ok := entry.Analyses[contentType]
+	if !ok || analysis == nil || analysis.Analysis == nil {
+		return nil, false
+	}
+	return analysis, true
+}
+
+// StoreContent caches a document's extraction
+func (c *ExtractionCache) StoreContent(contentHash string, content *ExtractedContent) {
+	c.update(contentHash, func(entry *extractionCacheEntry) {
+		entry.Content = content
+	})
+}
+
+// StoreAnalysis caches a document's analysis as a content type
+func (c *ExtractionCache) StoreAnalysis(contentHash, contentType string, analysis *documentAnalysis) {
+	c.update(contentHash, func(entry *extractionCacheEntry) {
+		if entry.Analyses == nil {
+			entry.Analyses = make(map[string]*documentAnalysis)
+		}
+		entry.Analyses[contentType] = analysis
+	})
+}
+
+// load reads a cache entry. Entries from another config version, or that
+// can't be read, are misses.
+func (c *ExtractionCache) load(contentHash string) (*extractionCacheEntry, bool) {
+	data, err := os.ReadFile(c.entryPath(contentHash))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[EXTRACTION_CACHE] Warning: failed to read entry %s: %v", contentHash, err)
+		}
+		return nil, false
+	}
+
+	var entry extractionCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("[EXTRACTION_CACHE] Warning: ignoring corrupt entry %s: %v", contentHash, err)
+		return nil, false
+	}
+	if entry.ContentHash != contentHash || entry.ConfigVersion != c.configVersion {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// update changes a cache entry and writes it back. Writes go through a
+// temporary file so a concurrent reader never sees half an entry.
+func (c *ExtractionCache) update(contentHash string, change func(*extractionCacheEntry)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.load(contentHash)
+	if !ok {
+		entry = &extractionCacheEntry{ContentHash: contentHash, ConfigVersion: c.configVersion}
+	}
+	change(entry)
+	entry.CachedAt = time.Now()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[EXTRACTION_CACHE] Warning: failed to encode entry %s: %v", contentHash, err)
+		return
+	}
+
+	path := c.entryPath(contentHash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("[EXTRACTION_CACHE] Warning: failed to create cache folder: %v", err)
+		return
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), contentHash+".*.tmp")
+	if err != nil {
+		log.Printf("[EXTRACTION_CACHE] Warning: failed to write entry %s: %v", contentHash, err)
+		return
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		log.Printf("[EXTRACTION_CACHE] Warning: failed to write entry %s: %v", contentHash, err)
+	}
+}
+
+// versionDir holds the entries for the current analyzer config version
+func (c *ExtractionCache) versionDir() string {
+	return filepath.Join(c.dir, c.configVersion)
+}
+
+// entryPath spreads entries over subfolders by the first byte of their hash
+func (c *ExtractionCache) entryPath(contentHash string) string {
+	if len(contentHash) < 2 {
+		return filepath.Join(c.versionDir(), contentHash+".json")
+	}
+	return filepath.Join(c.versionDir(), contentHash[:2], contentHash+".json")
+}
+
+// analyzerConfigVersion hashes the cache schema and the contents of the
+// analyzer config files. A missing file counts as empty.
+func analyzerConfigVersion(configFiles []string) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "schema %s\n", extractionCacheSchema)
+	for _, path := range configFiles {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read analyzer config %s: %v", path, err)
+		}
+		fmt.Fprintf(hash, "%s %d\n", filepath.Base(path), len(data))
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProvenanceMethodOverride marks a field whose value an attorney entered
+const ProvenanceMethodOverride = "Attorney Override"
+
+// FieldOverride is an attorney's correction of an extracted ClientCase field.
+// Overrides are kept when the documents are processed again and applied when
+// the complaint is generated.
+type FieldOverride struct {
+	Field        string    `json:"field"`
+	Value        string    `json:"value"`
+	Reason       string    `json:"reason"`
+	OverriddenBy string    `json:"overriddenBy"`
+	OverriddenAt time.Time `json:"overriddenAt"`
+	// Extracted is the extracted value the override replaced
+	Extracted string `json:"extracted"`
+}
+
+// FieldOverrideView is a field as shown in the Step 3 review: the value the
+// latest extraction found, and the attorney's override of it if any
+type FieldOverrideView struct {
+	Field     string
+	Label     string
+	Multiline bool
+	// Extracted is the value of the latest extraction
+	Extracted string
+	// Value is the value generation uses, the override's if there is one
+	Value    string
+	Override *FieldOverride
+	// Disagrees is set when the latest extraction found a different value
+	// than the override
+	Disagrees bool
+	// Reextracted is set when the extracted value changed since the override was made
+	Reextracted bool
+}
+
+// overridableField describes a ClientCase field an attorney can correct
+type overridableField struct {
+	Field string
+	Label string
+	// Multiline fields are edited in a text area; list fields take one value per line
+	Multiline bool
+	get       func(cc *ClientCase) string
+	set       func(cc *ClientCase, value string) error
+}
+
+// overridableFields are the fields an attorney can correct, in review order,
+// keyed by the field's JSON name like Provenance
+var overridableFields = []overridableField{
+	{Field: "clientName", Label: "Client name",
+		get: func(cc *ClientCase) string { return cc.ClientName },
+		set: func(cc *ClientCase, value string) error { cc.ClientName = value; return nil }},
+	{Field: "contactInfo", Label: "Contact information",
+		get: func(cc *ClientCase) string { return cc.ContactInfo },
+		set: func(cc *ClientCase, value string) error { cc.ContactInfo = value; return nil }},
+	{Field: "residenceLocation", Label: "Residence",
+		get: func(cc *ClientCase) string { return cc.ResidenceLocation },
+		set: func(cc *ClientCase, value string) error { cc.ResidenceLocation = value; return nil }},
+	{Field: "courtJurisdiction", Label: "Court jurisdiction",
+		get: func(cc *ClientCase) string { return cc.CourtJurisdiction },
+		set: func(cc *ClientCase, value string) error { cc.CourtJurisdiction = value; return nil }},
+	{Field: "caseNumber", Label: "Case number",
+		get: func(cc *ClientCase) string { return cc.CaseNumber },
+		set: func(cc *ClientCase, value string) error { cc.CaseNumber = value; return nil }},
+	{Field: "financialInstitution", Label: "Financial institution",
+		get: func(cc *ClientCase) string { return cc.FinancialInstitution },
+		set: func(cc *ClientCase, value string) error {
+			cc.FinancialInstitution = value
+			if len(cc.FraudDetailsStructured) == 1 {
+				cc.FraudDetailsStructured = []FraudDetail{cc.FraudDetailsStructured[0]}
+				cc.FraudDetailsStructured[0].Institution = value
+			}
+			return nil
+		}},
+	{Field: "accountOpenDate", Label: "Account opened",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.AccountOpenDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.AccountOpenDate, value) }},
+	{Field: "creditLimit", Label: "Credit limit",
+		get: func(cc *ClientCase) string { return cc.CreditLimit },
+		set: func(cc *ClientCase, value string) error {
+			if _, err := parseOverrideAmount(value); err != nil {
+				return err
+			}
+			cc.CreditLimit = value
+			return nil
+		}},
+	{Field: "travelLocation", Label: "Travel location",
+		get: func(cc *ClientCase) string { return cc.TravelLocation },
+		set: func(cc *ClientCase, value string) error { cc.TravelLocation = value; return nil }},
+	{Field: "travelStartDate", Label: "Travel start",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.TravelStartDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.TravelStartDate, value) }},
+	{Field: "travelEndDate", Label: "Travel end",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.TravelEndDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.TravelEndDate, value) }},
+	{Field: "fraudAmount", Label: "Fraud amount",
+		get: func(cc *ClientCase) string { return cc.FraudAmount },
+		set: func(cc *ClientCase, value string) error {
+			if _, err := parseOverrideAmount(value); err != nil {
+				return err
+			}
+			cc.FraudAmount = value
+			if len(cc.FraudDetailsStructured) == 1 {
+				cc.FraudDetailsStructured = []FraudDetail{cc.FraudDetailsStructured[0]}
+				cc.FraudDetailsStructured[0].Amount = value
+			}
+			return nil
+		}},
+	{Field: "fraudStartDate", Label: "Fraud start",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.FraudStartDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.FraudStartDate, value) }},
+	{Field: "fraudEndDate", Label: "Fraud end",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.FraudEndDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.FraudEndDate, value) }},
+	{Field: "fraudDetails", Label: "Fraud details", Multiline: true,
+		get: func(cc *ClientCase) string { return cc.FraudDetails },
+		set: func(cc *ClientCase, value string) error { cc.FraudDetails = value; return nil }},
+	{Field: "discoveryDate", Label: "Discovery date",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.DiscoveryDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.DiscoveryDate, value) }},
+	{Field: "disputeCount", Label: "Number of disputes",
+		get: func(cc *ClientCase) string {
+			if cc.DisputeCount == 0 {
+				return ""
+			}
+			return strconv.Itoa(cc.DisputeCount)
+		},
+		set: func(cc *ClientCase, value string) error {
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return fmt.Errorf("%q is not a number of disputes", value)
+			}
+			cc.DisputeCount = count
+			return nil
+		}},
+	{Field: "disputeMethods", Label: "Dispute methods", Multiline: true,
+		get: func(cc *ClientCase) string { return strings.Join(cc.DisputeMethods, "\n") },
+		set: func(cc *ClientCase, value string) error { cc.DisputeMethods = overrideLines(value); return nil }},
+	{Field: "bankResponse", Label: "Bank response", Multiline: true,
+		get: func(cc *ClientCase) string { return cc.BankResponse },
+		set: func(cc *ClientCase, value string) error { cc.BankResponse = value; return nil }},
+	{Field: "policeReportFiled", Label: "Police report filed",
+		get: func(cc *ClientCase) string {
+			if cc.PoliceReportFiled {
+				return "yes"
+			}
+			return "no"
+		},
+		set: func(cc *ClientCase, value string) error {
+			switch strings.ToLower(value) {
+			case "yes", "true", "y":
+				cc.PoliceReportFiled = true
+			case "no", "false", "n":
+				cc.PoliceReportFiled = false
+			default:
+				return fmt.Errorf("police report filed must be yes or no, not %q", value)
+			}
+			return nil
+		}},
+	{Field: "policeReportDetails", Label: "Police report details", Multiline: true,
+		get: func(cc *ClientCase) string { return cc.PoliceReportDetails },
+		set: func(cc *ClientCase, value string) error { cc.PoliceReportDetails = value; return nil }},
+	{Field: "creditBureauDisputes", Label: "Credit bureaus disputed", Multiline: true,
+		get: func(cc *ClientCase) string { return strings.Join(cc.CreditBureauDisputes, "\n") },
+		set: func(cc *ClientCase, value string) error { cc.CreditBureauDisputes = overrideLines(value); return nil }},
+	{Field: "creditBureauDisputeDate", Label: "Credit bureau dispute date",
+		get: func(cc *ClientCase) string { return formatProvenanceDate(cc.CreditBureauDisputeDate) },
+		set: func(cc *ClientCase, value string) error { return setOverrideDate(&cc.CreditBureauDisputeDate, value) }},
+	{Field: "defendants", Label: "Defendants", Multiline: true,
+		get: func(cc *ClientCase) string {
+			names := make([]string, 0, len(cc.Defendants))
+			for _, defendant := range caseDefendants(cc) {
+				names = append(names, defendant.Name)
+			}
+			return strings.Join(names, "\n")
+		},
+		set: func(cc *ClientCase, value string) error {
+			cc.Defendants = overrideDefendants(caseDefendants(cc), overrideLines(value))
+			return nil
+		}},
+	{Field: "estimatedDamages", Label: "Estimated damages",
+		get: func(cc *ClientCase) string {
+			if cc.EstimatedDamages == 0 {
+				return ""
+			}
+			return fmt.Sprintf("$%.2f", cc.EstimatedDamages)
+		},
+		set: func(cc *ClientCase, value string) error {
+			amount, err := parseOverrideAmount(value)
+			if err != nil {
+				return err
+			}
+			cc.EstimatedDamages = amount
+			return nil
+		}},
+	{Field: "additionalEvidence", Label: "Additional evidence", Multiline: true,
+		get: func(cc *ClientCase) string { return cc.AdditionalEvidence },
+		set: func(cc *ClientCase, value string) error { cc.AdditionalEvidence = value; return nil }},
+	{Field: "creditImpact", Label: "Credit impact", Multiline: true,
+		get: func(cc *ClientCase) string { return cc.CreditImpact },
+		set: func(cc *ClientCase, value string) error { cc.CreditImpact = value; return nil }},
+}
+
+// overrideDateLayouts are the date formats an override value may use
+var overrideDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "January 2, 2006", "Jan 2, 2006"}
+
+// lookupOverridableField looks up a field by its JSON name
+func lookupOverridableField(field string) (overridableField, bool) {
+	for _, candidate := range overridableFields {
+		if candidate.Field == field {
+			return candidate, true
+		}
+	}
+	return overridableField{}, false
+}
+
+func setOverrideDate(date *time.Time, value string) error {
+	for _, layout := range overrideDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			*date = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a date; use YYYY-MM-DD", value)
+}
+
+func parseOverrideAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.NewReplacer("$", "", ",", "").Replace(value), 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("%q is not a dollar amount", value)
+	}
+	return amount, nil
+}
+
+// overrideLines splits a multiline override into its trimmed, non-empty lines
+func overrideLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// caseDefendants returns the defendants of a case, or the ones generation
+// derives when the documents named none
+func caseDefendants(cc *ClientCase) []Defendant {
+	if len(cc.Defendants) > 0 {
+		return cc.Defendants
+	}
+	return derivedDefendants(cc)
+}
+
+// overrideDefendants returns the named defendants, keeping the entity type
+// and address of the ones already on the case
+func overrideDefendants(existing []Defendant, names []string) []Defendant {
+	defendants := make([]Defendant, 0, len(names))
+	for _, name := range names {
+		defendant := Defendant{Name: name, Address: "[ADDRESS TO BE DETERMINED]"}
+		for _, known := range existing {
+			if strings.EqualFold(known.Name, name) {
+				defendant = known
+				break
+			}
+		}
+		defendants = append(defendants, defendant)
+	}
+	return defendants
+}
+
+// ExtractedValue returns the value of an overridable field as extracted,
+// ignoring any override
+func (cc *ClientCase) ExtractedValue(field string) string {
+	overridable, ok := lookupOverridableField(field)
+	if !ok {
+		return ""
+	}
+	return overridable.get(cc)
+}
+
+// OverrideField records an attorney's correction of a field. The value is
+// checked against the field's type so a bad entry fails here and not at generation.
+func (cc *ClientCase) OverrideField(field, value, reason, attorney string) error {
+	overridable, ok := lookupOverridableField(field)
+	if !ok {
+		return fmt.Errorf("field %s can't be overridden", field)
+	}
+	value = strings.TrimSpace(strings.ReplaceAll(value, "\r\n", "\n"))
+	if value == "" {
+		return fmt.Errorf("a value is required to override %s", overridable.Label)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fmt.Errorf("a reason is required to override %s", overridable.Label)
+	}
+	check := *cc
+	if err := overridable.set(&check, value); err != nil {
+		return err
+	}
+
+	if cc.FieldOverrides == nil {
+		cc.FieldOverrides = make(map[string]FieldOverride)
+	}
+	cc.FieldOverrides[field] = FieldOverride{
+		Field:        field,
+		Value:        value,
+		Reason:       reason,
+		OverriddenBy: attorney,
+		OverriddenAt: time.Now(),
+		Extracted:    overridable.get(cc),
+	}
+	return nil
+}
+
+// ClearFieldOverride withdraws an override so the extracted value is used again
+func (cc *ClientCase) ClearFieldOverride(field string) {
+	delete(cc.FieldOverrides, field)
+}
+
+// CarryFieldOverrides keeps the overrides of an earlier extraction on a newly
+// extracted case
+func (cc *ClientCase) CarryFieldOverrides(overrides map[string]FieldOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+	if cc.FieldOverrides == nil {
+		cc.FieldOverrides = make(map[string]FieldOverride, len(overrides))
+	}
+	for field, override := range overrides {
+		if _, exists := cc.FieldOverrides[field]; !exists {
+			cc.FieldOverrides[field] = override
+		}
+	}
+}
+
+// WithFieldOverrides returns a copy of the case with the overrides applied and
+// their provenance marked as attorney overrides. The case itself keeps the
+// extracted values so a later extraction can be compared with the overrides.
+func (cc *ClientCase) WithFieldOverrides() *ClientCase {
+	effective := *cc
+	if len(cc.FieldOverrides) == 0 {
+		return &effective
+	}
+
+	effective.Provenance = make(map[string]FieldProvenance, len(cc.Provenance)+len(cc.FieldOverrides))
+	for field, provenance := range cc.Provenance {
+		effective.Provenance[field] = provenance
+	}
+	for _, overridable := range overridableFields {
+		override, exists := cc.FieldOverrides[overridable.Field]
+		if !exists {
+			continue
+		}
+		if err := overridable.set(&effective, override.Value); err != nil {
+			log.Printf("[DOCUMENT_SERVICE] Skipping override of %s: %v", override.Field, err)
+			continue
+		}
+		effective.SetProvenance(FieldProvenance{
+			Field:      override.Field,
+			Value:      override.Value,
+			Method:     ProvenanceMethodOverride + " by " + override.OverriddenBy,
+			Excerpt:    override.Reason,
+			Confidence: 1,
+		})
+	}
+	return &effective
+}
+
+// FieldOverrideViews returns every overridable field in review order, each
+// with its extracted value and override
+func (cc *ClientCase) FieldOverrideViews() []FieldOverrideView {
+	views := make([]FieldOverrideView, 0, len(overridableFields))
+	for _, overridable := range overridableFields {
+		extracted := overridable.get(cc)
+		view := FieldOverrideView{
+			Field:     overridable.Field,
+			Label:     overridable.Label,
+			Multiline: overridable.Multiline,
+			Extracted: extracted,
+			Value:     extracted,
+		}
+		if override, exists := cc.FieldOverrides[overridable.Field]; exists {
+			view.Value = override.Value
+			view.Override = &override
+			view.Disagrees = extracted != "" && !strings.EqualFold(extracted, override.Value)
+			view.Reextracted = extracted != override.Extracted
+		}
+		views = append(views, view)
+	}
+	return views
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FieldProvenance records where a ClientCase value came from
+type FieldProvenance struct {
+	Field      string  `json:"field"`
+	Value      string  `json:"value"`
+	Document   string  `json:"document"`
+	Page       int     `json:"page"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Excerpt    string  `json:"excerpt"`
+	Method     string  `json:"method"`
+	Confidence float64 `json:"confidence"`
+	// Boxes are where the value was drawn on the page, for PDFs
+	Boxes []SourceBox `json:"boxes,omitempty"`
+}
+
+// SourceFact ties a sentence of a generated section to the case field it was built from
+type SourceFact struct {
+	Field      string           `json:"field"`
+	Value      string           `json:"value"`
+	Sentence   string           `json:"sentence"`
+	Provenance *FieldProvenance `json:"provenance,omitempty"`
+}
+
+const (
+	ProvenanceMethodDefault = "Default Value"
+	ProvenanceMethodDerived = "Derived"
+	excerptRadius           = 120
+)
+
+// A sentence ends at a line break or at punctuation followed by a capitalized word,
+// so "INC. is" and "$7,500.00" stay inside one sentence
+var sentenceBreakPattern = regexp.MustCompile(`\n+|[.!?]+\s+["(]?[A-Z0-9]`)
+
+// SetProvenance records the source of a field, replacing any earlier record
+func (cc *ClientCase) SetProvenance(provenance FieldProvenance) {
+	if cc.Provenance == nil {
+		cc.Provenance = make(map[string]FieldProvenance)
+	}
+	cc.Provenance[provenance.Field] = provenance
+}
+
+// setDefaultProvenance marks a field as filled in without a source document,
+// unless the field already has a recorded source
+func (cc *ClientCase) setDefaultProvenance(field, value, method, reason string) {
+	if _, exists := cc.Provenance[field]; exists {
+		return
+	}
+	cc.SetProvenance(FieldProvenance{
+		Field:   field,
+		Value:   value,
+		Method:  method,
+		Excerpt: reason,
+	})
+}
+
+// ProvenanceFor returns the recorded source of a field, if any
+func (cc *ClientCase) ProvenanceFor(field string) *FieldProvenance {
+	provenance, exists := cc.Provenance[field]
+	if !exists {
+		return nil
+	}
+	return &provenance
+}
+
+// provenanceFromExtraction converts an extraction result into a provenance record
+func provenanceFromExtraction(field string, result ExtractionResult) FieldProvenance {
+	return FieldProvenance{
+		Field:      field,
+		Value:      fmt.Sprintf("%v", result.Value),
+		Document:   result.Document,
+		Page:       result.Page,
+		Start:      result.Location,
+		End:        result.End,
+		Excerpt:    result.Excerpt,
+		Method:     result.Method,
+		Confidence: result.Confidence,
+		Boxes:      result.Boxes,
+	}
+}
+
+// locateExtraction fills in the document, character span, page, excerpt and
+// position on the page of an extraction result from the text it was extracted from
+func locateExtraction(result ExtractionResult, documentPath string, content *ExtractedContent) ExtractionResult {
+	result.Document = documentPath
+	text := content.RawText
+
+	value := strings.TrimSpace(fmt.Sprintf("%v", result.Value))
+	if result.End <= result.Location && value != "" {
+		// Pattern extractors that don't report a span: find the value itself
+		if index := strings.Index(strings.ToLower(text), strings.ToLower(value)); index >= 0 {
+			result.Location = index
+			result.End = index + len(value)
+		}
+	}
+
+	if result.End > result.Location && result.End <= len(text) {
+		result.Page = content.PageAt(result.Location)
+		result.Excerpt = sourceExcerpt(text, result.Location, result.End)
+		result.Boxes = content.Boxes(result.Location, result.End)
+	}
+
+	return result
+}
+
+// locateIndicator cites the document and page where a classification
+// indicator matched, and where on the page it was drawn
+func locateIndicator(indicator ContentIndicator, documentPath string, content *ExtractedContent) ContentIndicator {
+	if indicator.End <= indicator.Start || indicator.End > len(content.RawText) {
+		return indicator
+	}
+	indicator.Document = documentPath
+	indicator.Page = content.PageAt(indicator.Start)
+	indicator.Boxes = content.Boxes(indicator.Start, indicator.End)
+	indicator.Location = SourceCitation(documentPath, indicator.Page)
+	return indicator
+}
+
+// pageForOffset returns the 1-based page containing a character offset
+func pageForOffset(pageOffsets []int, offset int) int {
+	page := 1
+	for i, start := range pageOffsets {
+		if offset >= start {
+			page = i + 1
+		}
+	}
+	return page
+}
+
+// sourceExcerpt returns the text around a span, trimmed to whole words
+func sourceExcerpt(text string, start, end int) string {
+	from := start - excerptRadius
+	if from < 0 {
+		from = 0
+	} else if space := strings.IndexAny(text[from:start], " \n"); space >= 0 {
+		from += space + 1
+	}
+
+	to := end + excerptRadius
+	if to > len(text) {
+		to = len(text)
+	} else if space := strings.LastIndexAny(text[end:to], " \n"); space >= 0 {
+		to = end + space
+	}
+
+	excerpt := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(text) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+// caseFieldValues returns the text a ClientCase field contributes to a document
+func caseFieldValues(clientCase *ClientCase, field string) []string {
+	var values []string
+	switch field {
+	case "clientName":
+		values = []string{clientCase.ClientName}
+	case "contactInfo":
+		values = []string{clientCase.ContactInfo}
+	case "residenceLocation":
+		values = []string{clientCase.ResidenceLocation}
+	case "courtJurisdiction":
+		values = []string{clientCase.CourtJurisdiction}
+	case "caseNumber":
+		values = []string{clientCase.CaseNumber}
+	case "fraudAmount":
+		values = []string{clientCase.FraudAmount}
+		for _, fraud := range clientCase.FraudDetailsStructured {
+			values = append(values, fraud.Amount)
+		}
+	case "financialInstitution":
+		values = []string{clientCase.FinancialInstitution}
+		for _, fraud := range clientCase.FraudDetailsStructured {
+			values = append(values, fraud.Institution)
+		}
+	case "travelLocation":
+		values = []string{clientCase.TravelLocation}
+	case "discoveryDate":
+		values = []string{formatProvenanceDate(clientCase.DiscoveryDate)}
+	case "creditBureauDisputes":
+		values = append(values, clientCase.CreditBureauDisputes...)
+		for _, interaction := range clientCase.CreditBureauInteractions {
+			values = append(values, interaction.Bureau)
+		}
+	case "defendants":
+		for _, defendant := range clientCase.Defendants {
+			values = append(values, defendant.Name)
+		}
+	}
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[strings.ToLower(value)] {
+			continue
+		}
+		seen[strings.ToLower(value)] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+// newSourceFact links a sentence to the field value it states
+func newSourceFact(clientCase *ClientCase, field, value, sentence string) SourceFact {
+	provenance := clientCase.ProvenanceFor(field)
+
+	// The institution named as a defendant was extracted, not generated
+	if field == "defendants" {
+		if institution := clientCase.ProvenanceFor("financialInstitution"); institution != nil &&
+			strings.EqualFold(institution.Value, value) {
+			provenance = institution
+		}
+	}
+
+	return SourceFact{
+		Field:      field,
+		Value:      value,
+		Sentence:   strings.Join(strings.Fields(sentence), " "),
+		Provenance: provenance,
+	}
+}
+
+// splitSentences breaks section content into trimmed sentences
+func splitSentences(content string) []string {
+	var sentences []string
+	start := 0
+	for _, match := range sentenceBreakPattern.FindAllStringIndex(content, -1) {
+		// Keep the punctuation, leave the next sentence's first character
+		end := match[1] - 1
+		if content[match[0]] == '\n' {
+			end = match[0]
+		}
+		if sentence := strings.TrimSpace(content[start:end]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	if sentence := strings.TrimSpace(content[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// collectSourceFacts finds the sentences of a section that use each field's value
+func collectSourceFacts(content string, clientCase *ClientCase, fields ...string) []SourceFact {
+	sentences := splitSentences(content)
+
+	var facts []SourceFact
+	for _, field := range fields {
+		for _, value := range caseFieldValues(clientCase, field) {
+			lowerValue := strings.ToLower(value)
+			for _, sentence := range sentences {
+				if !strings.Contains(strings.ToLower(sentence), lowerValue) {
+					continue
+				}
+				facts = append(facts, newSourceFact(clientCase, field, value, sentence))
+				break
+			}
+		}
+	}
+	return facts
+}
+
+// FactsForSentence returns the source facts whose sentence or value appears in the given text
+func (d *GeneratedDocument) FactsForSentence(text string) []SourceFact {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if normalized == "" {
+		return nil
+	}
+
+	var facts []SourceFact
+	seen := make(map[string]bool)
+	for _, section := range d.Sections {
+		for _, fact := range section.SourceFacts {
+			sentence := strings.ToLower(fact.Sentence)
+			value := strings.ToLower(fact.Value)
+			if sentence == "" || value == "" || seen[fact.Field+"\x00"+value] {
+				continue
+			}
+			// Only a reasonably long fragment is matched inside a sentence
+			partial := len(normalized) >= 20 && strings.Contains(sentence, normalized)
+			if strings.Contains(normalized, sentence) || partial ||
+				strings.Contains(normalized, value) {
+				seen[fact.Field+"\x00"+value] = true
+				facts = append(facts, fact)
+			}
+		}
+	}
+	return facts
+}
+
+func formatProvenanceDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("January 2, 2006")
+}
@@ -1,10 +1,12 @@
 package services
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"log"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,164 +21,193 @@ type ICloudDocument struct {
 	IsDirectory bool      `json:"isDirectory"`
 }
 
-// ICloudService handles iCloud Drive operations
+// ICloudService browses case folders and documents in the selected document source
 type ICloudService struct {
-	testPath string
+	store   CaseStore
+	sources DocumentSourcesConfig
+
+	mu        sync.Mutex
+	connected map[string]DocumentSource // opened sources keyed by provider and account
 }
 
-// NewICloudService creates a new iCloud service instance
+// NewICloudService creates a new iCloud service instance backed by the configured case store
 func NewICloudService() *ICloudService {
+	store, config := DefaultCaseStore()
+	return NewICloudServiceWithStore(store, config.Sources)
+}
+
+// NewICloudServiceWithStore creates an iCloud service that reads from the given case store
+// and the configured document sources
+func NewICloudServiceWithStore(store CaseStore, sources DocumentSourcesConfig) *ICloudService {
 	return &ICloudService{
-		testPath: "/Users/corelogic/satori-dev/clients/proj-mallon/test_icloud",
+		store:     store,
+		sources:   sources,
+		connected: make(map[string]DocumentSource),
 	}
 }
 
-// GetRootFolders returns the top-level folders in iCloud Drive
-func (s *ICloudService) GetRootFolders(username, appPassword string) ([]ICloudDocument, error) {
-	// Get iCloud Drive path on macOS - no test folder fallback for production
-	icloudPath := "/Users/" + getCurrentUser() + "/Library/Mobile Documents/com~apple~CloudDocs"
-	
-	// Check if iCloud Drive is available
-	if _, err := os.Stat(icloudPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("iCloud Drive not available or not synced")
+// Providers lists the document providers available in Step 0
+func (s *ICloudService) Providers() []DocumentProvider {
+	return s.sources.Providers()
+}
+
+// DefaultProvider returns the provider used when a session has not picked one
+func (s *ICloudService) DefaultProvider() string {
+	return s.sources.Default
+}
+
+// Connect signs in to a provider and keeps the source open for that account.
+// Credentials are held in memory only and never written to the session file.
+func (s *ICloudService) Connect(provider, username, password string) error {
+	provider = s.normalizeProvider(provider)
+	if provider == ProviderCaseStore {
+		return nil
 	}
-	fmt.Printf("[DEBUG] Using real iCloud directory: %s\n", icloudPath)
-	
-	// List directories in iCloud Drive root
-	dirs, err := os.ReadDir(icloudPath)
+
+	var credentials *SourceCredentials
+	if username != "" || password != "" {
+		credentials = &SourceCredentials{Username: username, Password: password}
+	}
+	source, err := OpenDocumentSource(provider, s.sources, credentials)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read iCloud Drive: %v", err)
+		return err
+	}
+	if _, err := source.List(""); err != nil {
+		return fmt.Errorf("could not connect to %s: %v", provider, err)
+	}
+
+	s.mu.Lock()
+	s.connected[provider+"/"+username] = source
+	s.mu.Unlock()
+
+	log.Printf("[ICLOUD_SERVICE] Connected %s source for %s", provider, username)
+	return nil
+}
+
+// Source returns the document source for a provider and the account that connected it,
+// falling back to credentials from the server configuration
+func (s *ICloudService) Source(provider, username string) (DocumentSource, error) {
+	provider = s.normalizeProvider(provider)
+	if provider == ProviderCaseStore {
+		return s.store, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range []string{provider + "/" + username, provider + "/"} {
+		if source, ok := s.connected[key]; ok {
+			return source, nil
+		}
+	}
+
+	source, err := OpenDocumentSource(provider, s.sources, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.connected[provider+"/"] = source
+	return source, nil
+}
+
+func (s *ICloudService) normalizeProvider(provider string) string {
+	if provider == "" {
+		provider = s.sources.Default
+	}
+	if provider == "" {
+		provider = ProviderCaseStore
+	}
+	return provider
+}
+
+// GetRootFolders returns the top-level folders in a provider
+func (s *ICloudService) GetRootFolders(provider, username string) ([]ICloudDocument, error) {
+	source, err := s.Source(provider, username)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := source.List("")
+	if err != nil {
+		return nil, fmt.Errorf("document source not available: %v", err)
 	}
 	
 	var folders []ICloudDocument
-	for i, dir := range dirs {
-		if dir.IsDir() && !strings.HasPrefix(dir.Name(), ".") {
-			info, err := dir.Info()
-			if err != nil {
-				continue
-			}
-			
-			folder := ICloudDocument{
-				ID:          fmt.Sprintf("icloud_folder_%d", i),
-				Name:        dir.Name(),
-				Path:        "/" + dir.Name(),
-				IsDirectory: true,
-				Modified:    info.ModTime(),
-				Size:        0, // Directories don't have size
-			}
-			folders = append(folders, folder)
+	for i, object := range objects {
+		if !object.IsDirectory {
+			continue
 		}
+		
+		folder := ICloudDocument{
+			ID:          fmt.Sprintf("icloud_folder_%d", i),
+			Name:        object.Name,
+			Path:        "/" + object.Name,
+			IsDirectory: true,
+			Modified:    object.Modified,
+			Size:        0, // Directories don't have size
+		}
+		folders = append(folders, folder)
 	}
 	
-	fmt.Printf("Found %d real iCloud folders\n", len(folders))
+	log.Printf("[ICLOUD_SERVICE] Found %d folders in %s source", len(folders), s.normalizeProvider(provider))
 	return folders, nil
 }
 
-// GetSubfolders returns subfolders within a specific iCloud directory
-func (s *ICloudService) GetSubfolders(username, appPassword, parentFolder string) ([]ICloudDocument, error) {
-	// Get iCloud Drive path on macOS - no test folder fallback for production
-	icloudPath := "/Users/" + getCurrentUser() + "/Library/Mobile Documents/com~apple~CloudDocs"
-	
-	// Check if iCloud Drive is available
-	if _, err := os.Stat(icloudPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("iCloud Drive not available or not synced")
-	}
-	fmt.Printf("[DEBUG] Using real iCloud directory for subfolders: %s\n", icloudPath)
-	
-	// Clean the parent folder path
-	cleanParent := strings.TrimPrefix(parentFolder, "/")
-	fullPath := filepath.Join(icloudPath, cleanParent)
-	
-	// Check if parent folder exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("folder does not exist: %s", parentFolder)
+// GetSubfolders returns subfolders within a specific folder
+func (s *ICloudService) GetSubfolders(provider, username, parentFolder string) ([]ICloudDocument, error) {
+	source, err := s.Source(provider, username)
+	if err != nil {
+		return nil, err
 	}
-	
-	// List subdirectories
-	dirs, err := os.ReadDir(fullPath)
+	objects, err := source.List(parentFolder)
 	if err != nil {
+		if errors.Is(err, ErrCaseObjectNotFound) {
+			return nil, fmt.Errorf("folder does not exist: %s", parentFolder)
+		}
 		return nil, fmt.Errorf("failed to read folder %s: %v", parentFolder, err)
 	}
 	
 	var subfolders []ICloudDocument
-	for i, dir := range dirs {
-		if dir.IsDir() && !strings.HasPrefix(dir.Name(), ".") {
-			info, err := dir.Info()
-			if err != nil {
-				continue
-			}
-			
-			subfolder := ICloudDocument{
-				ID:          fmt.Sprintf("icloud_subfolder_%d", i),
-				Name:        dir.Name(),
-				Path:        parentFolder + "/" + dir.Name(),
-				IsDirectory: true,
-				Modified:    info.ModTime(),
-				Size:        0,
-			}
-			subfolders = append(subfolders, subfolder)
+	for i, object := range objects {
+		if !object.IsDirectory {
+			continue
+		}
+		
+		subfolder := ICloudDocument{
+			ID:          fmt.Sprintf("icloud_subfolder_%d", i),
+			Name:        object.Name,
+			Path:        parentFolder + "/" + object.Name,
+			IsDirectory: true,
+			Modified:    object.Modified,
+			Size:        0,
 		}
+		subfolders = append(subfolders, subfolder)
 	}
 	
-	fmt.Printf("Found %d subfolders in %s\n", len(subfolders), parentFolder)
+	log.Printf("[ICLOUD_SERVICE] Found %d subfolders in %s", len(subfolders), parentFolder)
 	return subfolders, nil
 }
 
-// GetDocuments returns documents from a specific iCloud folder
-func (s *ICloudService) GetDocuments(username, appPassword, folderPath string) ([]ICloudDocument, error) {
-	// Get iCloud Drive path on macOS - no test folder fallback for production
-	icloudPath := "/Users/" + getCurrentUser() + "/Library/Mobile Documents/com~apple~CloudDocs"
-	
-	// Check if iCloud Drive is available
-	if _, err := os.Stat(icloudPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("iCloud Drive not available or not synced")
-	}
-	fmt.Printf("[DEBUG] Using real iCloud directory: %s\n", icloudPath)
-	
-	// Clean the folder path
-	cleanPath := strings.TrimPrefix(folderPath, "/")
-	fullPath := filepath.Join(icloudPath, cleanPath)
-	
-	// Use root if path is empty
-	if cleanPath == "" {
-		fullPath = icloudPath
-	}
-	
-	fmt.Printf("[DEBUG] Resolved full path: %s\n", fullPath)
-	
-	// Check if folder exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		fmt.Printf("[ERROR] Folder does not exist: %s (resolved to %s)\n", folderPath, fullPath)
-		return nil, fmt.Errorf("folder does not exist: %s", folderPath)
+// GetDocuments returns documents from a specific folder
+func (s *ICloudService) GetDocuments(provider, username, folderPath string) ([]ICloudDocument, error) {
+	source, err := s.Source(provider, username)
+	if err != nil {
+		return nil, err
 	}
-	
-	// List all items in the folder
-	items, err := os.ReadDir(fullPath)
+	objects, err := source.List(folderPath)
 	if err != nil {
-		fmt.Printf("[ERROR] Failed to read folder %s: %v\n", fullPath, err)
+		if errors.Is(err, ErrCaseObjectNotFound) {
+			log.Printf("[ICLOUD_SERVICE] Folder does not exist: %s", folderPath)
+			return nil, fmt.Errorf("folder does not exist: %s", folderPath)
+		}
+		log.Printf("[ICLOUD_SERVICE] Failed to read folder %s: %v", folderPath, err)
 		return nil, fmt.Errorf("failed to read folder %s: %v", folderPath, err)
 	}
 	
-	fmt.Printf("[DEBUG] Found %d items in directory %s\n", len(items), fullPath)
-	
 	var documents []ICloudDocument
-	for i, item := range items {
-		// Skip hidden files
-		if strings.HasPrefix(item.Name(), ".") {
-			fmt.Printf("[DEBUG] Skipping hidden file: %s\n", item.Name())
-			continue
-		}
-		
-		info, err := item.Info()
-		if err != nil {
-			fmt.Printf("[DEBUG] Error getting file info for %s: %v\n", item.Name(), err)
-			continue
-		}
-		
+	for i, object := range objects {
 		// Determine file type
 		fileType := "unknown"
-		if !item.IsDir() {
-			ext := strings.ToLower(filepath.Ext(item.Name()))
+		if !object.IsDirectory {
+			ext := strings.ToLower(filepath.Ext(object.Name))
 			switch ext {
 			case ".pdf":
 				fileType = "pdf"
@@ -195,22 +226,21 @@ func (s *ICloudService) GetDocuments(username, appPassword, folderPath string) (
 		
 		doc := ICloudDocument{
 			ID:          fmt.Sprintf("icloud_doc_%d", i),
-			Name:        item.Name(),
-			Path:        folderPath + "/" + item.Name(),
-			IsDirectory: item.IsDir(),
-			Modified:    info.ModTime(),
-			Size:        info.Size(),
+			Name:        object.Name,
+			Path:        folderPath + "/" + object.Name,
+			IsDirectory: object.IsDirectory,
+			Modified:    object.Modified,
+			Size:        object.Size,
 			Type:        fileType,
 		}
 		documents = append(documents, doc)
-		fmt.Printf("[DEBUG] Added document: %s (%s, %d bytes)\n", doc.Name, doc.Type, doc.Size)
 	}
 	
-	fmt.Printf("[SUCCESS] Found %d documents in iCloud folder %s\n", len(documents), folderPath)
+	log.Printf("[ICLOUD_SERVICE] Found %d documents in folder %s", len(documents), folderPath)
 	return documents, nil
 }
 
-// Helper function to get the current macOS username
-func getCurrentUser() string {
-	return "corelogic" // Simplified for development
-}
\ No newline at end of file
+// GetCaseForFolder returns the case registered for a provider's case folder, registering it if needed
+func (s *ICloudService) GetCaseForFolder(provider, folderPath string) (*CaseMetadata, error) {
+	return s.store.EnsureCase(s.normalizeProvider(provider), folderPath)
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// naiveBayesSmoothing is the Laplace smoothing added to every token count
+	naiveBayesSmoothing = 1.0
+
+	// learnedModelWeight is the most the model adds to a type's rule score. It
+	// ramps up with the number of labels, reaching full weight at
+	// learnedModelFullWeightLabels, so a handful of labels can't outvote the rules.
+	learnedModelWeight           = 1.2
+	learnedModelFullWeightLabels = 20
+
+	// learnedModelMinLabels and learnedModelMinTypes are what the model needs
+	// before it is used at all
+	learnedModelMinLabels = 3
+	learnedModelMinTypes  = 2
+)
+
+// NaiveBayesModel is a multinomial naive Bayes classifier over the words and
+// word pairs of a document and the words of its file name, trained on the
+// document types users corrected in Step 1
+type NaiveBayesModel struct {
+	Examples    int
+	TrainedAt   time.Time
+	typeCounts  map[DocumentType]int
+	tokenCounts map[DocumentType]map[string]int
+	tokenTotals map[DocumentType]int
+	vocabulary  map[string]bool
+}
+
+// trainingExample is a labeled document's features
+type trainingExample struct {
+	docType  DocumentType
+	features map[string]int
+	total    int
+}
+
+// TrainNaiveBayes trains a model from labeled documents. It returns nil when
+// there are too few labels, or too few types, to learn anything from.
+func TrainNaiveBayes(labels []ClassificationLabel) *NaiveBayesModel {
+	examples := trainingExamples(labels)
+	types := make(map[DocumentType]bool)
+	for _, example := range examples {
+		types[example.docType] = true
+	}
+	if len(examples) < learnedModelMinLabels || len(types) < learnedModelMinTypes {
+		return nil
+	}
+
+	model := &NaiveBayesModel{
+		Examples:    len(examples),
+		TrainedAt:   time.Now(),
+		typeCounts:  make(map[DocumentType]int),
+		tokenCounts: make(map[DocumentType]map[string]int),
+		tokenTotals: make(map[DocumentType]int),
+		vocabulary:  make(map[string]bool),
+	}
+	for _, example := range examples {
+		model.typeCounts[example.docType]++
+		counts := model.tokenCounts[example.docType]
+		if counts == nil {
+			counts = make(map[string]int)
+			model.tokenCounts[example.docType] = counts
+		}
+		for token, count := range example.features {
+			counts[token] += count
+			model.vocabulary[token] = true
+		}
+		model.tokenTotals[example.docType] += example.total
+	}
+	return model
+}
+
+// trainingExamples turns labels into feature counts, skipping labels of unknown types
+func trainingExamples(labels []ClassificationLabel) []trainingExample {
+	examples := make([]trainingExample, 0, len(labels))
+	for _, label := range labels {
+		docType, ok := DocumentTypeByName(label.DocumentType)
+		if !ok || docType == DocumentTypeUnknown {
+			continue
+		}
+		features, total := documentFeatures(label.Name, label.Text)
+		examples = append(examples, trainingExample{docType: docType, features: features, total: total})
+	}
+	return examples
+}
+
+// Predict returns the probability of each document type the model was trained on
+func (m *NaiveBayesModel) Predict(documentPath, content string) map[DocumentType]float64 {
+	features, _ := documentFeatures(filepath.Base(documentPath), content)
+	return m.posteriors(features, nil)
+}
+
+// weight is how much the model counts against the rule scores
+func (m *NaiveBayesModel) weight() float64 {
+	return learnedModelWeight * math.Min(1, float64(m.Examples)/learnedModelFullWeightLabels)
+}
+
+// posteriors scores features against every type, leaving out a training
+// example's counts when one is given, for leave-one-out evaluation
+func (m *NaiveBayesModel) posteriors(features map[string]int, exclude *trainingExample) map[DocumentType]float64 {
+	examples := m.Examples
+	if exclude != nil {
+		examples--
+	}
+	vocabularySize := float64(len(m.vocabulary))
+
+	logScores := make(map[DocumentType]float64, len(m.typeCounts))
+	best := math.Inf(-1)
+	for docType, typeCount := range m.typeCounts {
+		counts := m.tokenCounts[docType]
+		total := m.tokenTotals[docType]
+		if exclude != nil && exclude.docType == docType {
+			typeCount--
+			total -= exclude.total
+		}
+		if typeCount <= 0 {
+			continue
+		}
+
+		score := math.Log(float64(typeCount) / float64(examples))
+		denominator := math.Log(float64(total) + naiveBayesSmoothing*vocabularySize)
+		for token, count := range features {
+			tokenCount := counts[token]
+			if exclude != nil && exclude.docType == docType {
+				tokenCount -= exclude.features[token]
+			}
+			score += float64(count) * (math.Log(float64(tokenCount)+naiveBayesSmoothing) - denominator)
+		}
+		logScores[docType] = score
+		best = math.Max(best, score)
+	}
+
+	// Normalize in log space so long documents don't underflow
+	sum := 0.0
+	for _, score := range logScores {
+		sum += math.Exp(score - best)
+	}
+	probabilities := make(map[DocumentType]float64, len(logScores))
+	for docType, score := range logScores {
+		probabilities[docType] = math.Exp(score-best) / sum
+	}
+	return probabilities
+}
+
+// documentFeatures counts the words and adjacent word pairs of a document's
+// text, and the words of its file name as separate features
+func documentFeatures(fileName, content string) (map[string]int, int) {
+	features := make(map[string]int)
+	total := 0
+
+	for _, word := range featureWords(strings.TrimSuffix(fileName, filepath.Ext(fileName))) {
+		features["name:"+word]++
+		total++
+	}
+
+	words := featureWords(content)
+	for i, word := range words {
+		features[word]++
+		total++
+		if i > 0 {
+			features[words[i-1]+" "+word]++
+			total++
+		}
+	}
+	return features, total
+}
+
+// featureWords splits text into lowercase words, dropping numbers and one-letter words
+func featureWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if len(field) < 2 || strings.IndexFunc(field, unicode.IsLetter) < 0 {
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+// ClassifierMetrics is how well the classifier agrees with the labeled documents.
+// Each labeled document is classified by a model trained on the other labels.
+type ClassifierMetrics struct {
+	Labeled int `json:"labeled"`
+	// Accuracy is of the rules blended with the learned model, RulesAccuracy
+	// of the rules alone
+	Accuracy      float64       `json:"accuracy"`
+	RulesAccuracy float64       `json:"rulesAccuracy"`
+	ModelActive   bool          `json:"modelActive"`
+	Types         []TypeMetrics `json:"types"`
+	EvaluatedAt   time.Time     `json:"evaluatedAt"`
+}
+
+// TypeMetrics is the precision and recall of one document type
+type TypeMetrics struct {
+	DocumentType string  `json:"documentType"`
+	Labeled      int     `json:"labeled"`
+	Predicted    int     `json:"predicted"`
+	Correct      int     `json:"correct"`
+	Precision    float64 `json:"precision"`
+	Recall       float64 `json:"recall"`
+}
+
+// Evaluate classifies every labeled document and compares the result with its label
+func (dc *DocumentClassifier) Evaluate(labels []ClassificationLabel) *ClassifierMetrics {
+	metrics := &ClassifierMetrics{EvaluatedAt: time.Now()}
+	model := TrainNaiveBayes(labels)
+	metrics.ModelActive = model != nil
+
+	byType := make(map[DocumentType]*TypeMetrics)
+	typeMetrics := func(docType DocumentType) *TypeMetrics {
+		if byType[docType] == nil {
+			byType[docType] = &TypeMetrics{DocumentType: dc.GetDocumentTypeName(docType)}
+		}
+		return byType[docType]
+	}
+
+	correct, rulesCorrect := 0, 0
+	for _, label := range labels {
+		labeled, ok := DocumentTypeByName(label.DocumentType)
+		if !ok || labeled == DocumentTypeUnknown {
+			continue
+		}
+		metrics.Labeled++
+
+		rules := dc.classify(label.Name, label.Text, nil, 0)
+		if rules.PrimaryType == labeled {
+			rulesCorrect++
+		}
+
+		predicted := rules.PrimaryType
+		if model != nil {
+			features, total := documentFeatures(label.Name, label.Text)
+			example := &trainingExample{docType: labeled, features: features, total: total}
+			predicted = dc.classify(label.Name, label.Text, model.posteriors(features, example), model.weight()).PrimaryType
+		}
+
+		typeMetrics(labeled).Labeled++
+		typeMetrics(predicted).Predicted++
+		if predicted == labeled {
+			typeMetrics(labeled).Correct++
+			correct++
+		}
+	}
+	if metrics.Labeled == 0 {
+		return metrics
+	}
+	metrics.Accuracy = float64(correct) / float64(metrics.Labeled)
+	metrics.RulesAccuracy = float64(rulesCorrect) / float64(metrics.Labeled)
+
+	for _, typeMetrics := range byType {
+		if typeMetrics.Predicted > 0 {
+			typeMetrics.Precision = float64(typeMetrics.Correct) / float64(typeMetrics.Predicted)
+		}
+		if typeMetrics.Labeled > 0 {
+			typeMetrics.Recall = float64(typeMetrics.Correct) / float64(typeMetrics.Labeled)
+		}
+		metrics.Types = append(metrics.Types, *typeMetrics)
+	}
+	sort.Slice(metrics.Types, func(i, j int) bool {
+		if metrics.Types[i].Labeled != metrics.Types[j].Labeled {
+			return metrics.Types[i].Labeled > metrics.Types[j].Labeled
+		}
+		return metrics.Types[i].DocumentType < metrics.Types[j].DocumentType
+	})
+	return metrics
+}
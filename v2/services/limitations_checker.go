@@ -0,0 +1,398 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limitations statuses of a claim
+const (
+	LimitationsTimely     = "timely"
+	LimitationsExpiring   = "expiring"
+	LimitationsTimeBarred = "time_barred"
+	LimitationsUnknown    = "unknown"
+)
+
+const (
+	// limitationsWarningDays marks a claim as expiring when fewer days remain
+	limitationsWarningDays = 90
+
+	// unrecordedDateConfidence is used for dates with no provenance record
+	unrecordedDateConfidence = 0.5
+
+	// caseClaimID is the claim checked when no violations were detected
+	caseClaimID = "FCRA"
+)
+
+// uscSectionPattern finds U.S. Code section numbers such as 1681i in a citation
+var uscSectionPattern = regexp.MustCompile(`1681[a-z]?`)
+
+// remedySections create liability for violations of the other sections rather
+// than duties of their own, so counts under them rest on every claim
+var remedySections = map[string]bool{
+	"1681n": true,
+	"1681o": true,
+}
+
+// limitationsDateFields are the ClientCase fields claims are dated from
+var limitationsDateFields = map[string]bool{
+	"discoveryDate":           true,
+	"fraudStartDate":          true,
+	"accountOpenDate":         true,
+	"creditBureauDisputeDate": true,
+}
+
+// IsLimitationsDate reports whether the limitations check dates claims from a
+// field, so a change to it calls for a new check
+func IsLimitationsDate(field string) bool {
+	return limitationsDateFields[field]
+}
+
+// LimitationsOverride lets an attorney keep a time-barred claim in the
+// complaint, e.g. when tolling or a later violation applies
+type LimitationsOverride struct {
+	ClaimID      string    `json:"claimId"`
+	Reason       string    `json:"reason"`
+	OverriddenBy string    `json:"overriddenBy"`
+	OverriddenAt time.Time `json:"overriddenAt"`
+}
+
+// ClaimLimitations is the § 1681p analysis of one claim
+type ClaimLimitations struct {
+	ClaimID       string `json:"claimId"`
+	ViolationName string `json:"violationName"`
+	Statute       string `json:"statute"`
+	Section       string `json:"section"`
+
+	DiscoveryDate   time.Time `json:"discoveryDate"`
+	DiscoveryEvent  string    `json:"discoveryEvent"`
+	DiscoverySource string    `json:"discoverySource"`
+	ViolationDate   time.Time `json:"violationDate"`
+	ViolationEvent  string    `json:"violationEvent"`
+	ViolationSource string    `json:"violationSource"`
+
+	// Deadlines are the discovery and repose periods, earliest first
+	Deadlines []StatutoryDeadline `json:"deadlines"`
+
+	// The controlling deadline and the event that started it
+	Deadline       time.Time `json:"deadline"`
+	DaysRemaining  int       `json:"daysRemaining"`
+	TriggerEvent   string    `json:"triggerEvent"`
+	TriggerDate    time.Time `json:"triggerDate"`
+	TriggerSource  string    `json:"triggerSource"`
+	DateConfidence float64   `json:"dateConfidence"`
+	DateMethod     string    `json:"dateMethod"`
+
+	Status   string               `json:"status"`
+	Override *LimitationsOverride `json:"override,omitempty"`
+}
+
+// Blocked reports whether the claim is time-barred and no attorney has overridden it
+func (cl *ClaimLimitations) Blocked() bool {
+	return cl.Status == LimitationsTimeBarred && cl.Override == nil
+}
+
+// LimitationsAnalysis is the limitations check of every claim in a case
+type LimitationsAnalysis struct {
+	CheckedAt       time.Time          `json:"checkedAt"`
+	Claims          []ClaimLimitations `json:"claims"`
+	TimeBarredCount int                `json:"timeBarredCount"`
+	BlockedCount    int                `json:"blockedCount"`
+}
+
+// Claim returns the analysis of a claim, if any
+func (la *LimitationsAnalysis) Claim(claimID string) *ClaimLimitations {
+	if la == nil {
+		return nil
+	}
+	for i := range la.Claims {
+		if la.Claims[i].ClaimID == claimID {
+			return &la.Claims[i]
+		}
+	}
+	return nil
+}
+
+// BarsCause reports whether a count citing the statutory basis must be left
+// out of the complaint. A count is barred when every claim it rests on is
+// blocked; counts under § 1681n or § 1681o alone rest on every claim.
+func (la *LimitationsAnalysis) BarsCause(statutoryBasis string) bool {
+	claims := la.claimsFor(statutoryBasis)
+	if len(claims) == 0 {
+		return false
+	}
+	for _, claim := range claims {
+		if !claim.Blocked() {
+			return false
+		}
+	}
+	return true
+}
+
+// claimsFor returns the claims a count citing the statutory basis rests on
+func (la *LimitationsAnalysis) claimsFor(statutoryBasis string) []ClaimLimitations {
+	if la == nil {
+		return nil
+	}
+
+	sections := make(map[string]bool)
+	for _, section := range uscSectionPattern.FindAllString(statutoryBasis, -1) {
+		if !remedySections[section] {
+			sections[section] = true
+		}
+	}
+	if len(sections) == 0 {
+		return la.Claims
+	}
+
+	var claims []ClaimLimitations
+	for _, claim := range la.Claims {
+		if sections[claim.Section] || claim.ClaimID == caseClaimID {
+			claims = append(claims, claim)
+		}
+	}
+	return claims
+}
+
+// applyOverrides attaches attorney overrides to time-barred claims and recounts
+func (la *LimitationsAnalysis) applyOverrides(overrides map[string]LimitationsOverride) {
+	la.TimeBarredCount = 0
+	la.BlockedCount = 0
+	for i := range la.Claims {
+		claim := &la.Claims[i]
+		claim.Override = nil
+		if override, exists := overrides[claim.ClaimID]; exists && claim.Status == LimitationsTimeBarred {
+			claim.Override = &override
+		}
+		if claim.Status == LimitationsTimeBarred {
+			la.TimeBarredCount++
+		}
+		if claim.Blocked() {
+			la.BlockedCount++
+		}
+	}
+}
+
+// SetLimitations stores a limitations analysis on the case, keeping any
+// overrides the attorney already made
+func (cc *ClientCase) SetLimitations(analysis LimitationsAnalysis) {
+	analysis.Claims = append([]ClaimLimitations{}, analysis.Claims...)
+	analysis.applyOverrides(cc.LimitationsOverrides)
+	cc.Limitations = &analysis
+}
+
+// OverrideLimitations records an attorney's decision to plead a time-barred claim
+func (cc *ClientCase) OverrideLimitations(claimID, reason, attorney string) error {
+	claim := cc.Limitations.Claim(claimID)
+	if claim == nil {
+		return fmt.Errorf("no limitations analysis for claim %s", claimID)
+	}
+	if claim.Status != LimitationsTimeBarred {
+		return fmt.Errorf("claim %s is not time-barred", claimID)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fmt.Errorf("a reason is required to override the limitations check")
+	}
+
+	if cc.LimitationsOverrides == nil {
+		cc.LimitationsOverrides = make(map[string]LimitationsOverride)
+	}
+	cc.LimitationsOverrides[claimID] = LimitationsOverride{
+		ClaimID:      claimID,
+		Reason:       reason,
+		OverriddenBy: attorney,
+		OverriddenAt: time.Now(),
+	}
+	cc.Limitations.applyOverrides(cc.LimitationsOverrides)
+	return nil
+}
+
+// ClearLimitationsOverride withdraws an override so the claim is blocked again
+func (cc *ClientCase) ClearLimitationsOverride(claimID string) {
+	delete(cc.LimitationsOverrides, claimID)
+	if cc.Limitations != nil {
+		cc.Limitations.applyOverrides(cc.LimitationsOverrides)
+	}
+}
+
+// LimitationsChecker applies the FCRA statute of limitations, 15 U.S.C. § 1681p,
+// to the claims in a case
+type LimitationsChecker struct {
+	Deadlines *DeadlineCalculator
+}
+
+// NewLimitationsChecker creates a limitations checker
+func NewLimitationsChecker() *LimitationsChecker {
+	return &LimitationsChecker{
+		Deadlines: NewDeadlineCalculator(),
+	}
+}
+
+// Check analyzes each detected violation. With no violations the case as a
+// whole is checked from its reporting and discovery dates.
+func (lc *LimitationsChecker) Check(clientCase *ClientCase, violations []DetectedViolation) LimitationsAnalysis {
+	analysis := LimitationsAnalysis{
+		CheckedAt: time.Now(),
+		Claims:    []ClaimLimitations{},
+	}
+	if clientCase == nil {
+		return analysis
+	}
+
+	for _, violation := range violations {
+		definition := violation.ViolationDefinition
+		claim := ClaimLimitations{
+			ClaimID:         definition.ViolationID,
+			ViolationName:   definition.ViolationName,
+			Statute:         definition.Statute.Citation,
+			Section:         uscSectionPattern.FindString(definition.Statute.Citation),
+			ViolationDate:   violation.ViolationDate,
+			ViolationEvent:  violation.ViolationEvent,
+			ViolationSource: violation.ViolationDateSource,
+		}
+		lc.checkClaim(&claim, clientCase)
+		analysis.Claims = append(analysis.Claims, claim)
+	}
+
+	if len(violations) == 0 {
+		violated, source, event := reportingDate(clientCase)
+		claim := ClaimLimitations{
+			ClaimID:         caseClaimID,
+			ViolationName:   "Violation of the Fair Credit Reporting Act",
+			Statute:         "15 U.S.C. § 1681 et seq.",
+			ViolationDate:   violated,
+			ViolationEvent:  event,
+			ViolationSource: source,
+		}
+		lc.checkClaim(&claim, clientCase)
+		analysis.Claims = append(analysis.Claims, claim)
+	}
+
+	sort.SliceStable(analysis.Claims, func(i, j int) bool {
+		return analysis.Claims[i].DaysRemaining < analysis.Claims[j].DaysRemaining
+	})
+	analysis.applyOverrides(clientCase.LimitationsOverrides)
+	return analysis
+}
+
+// checkClaim fills in the discovery date, the § 1681p deadlines and the status of a claim
+func (lc *LimitationsChecker) checkClaim(claim *ClaimLimitations, clientCase *ClientCase) {
+	claim.DiscoveryDate, claim.DiscoverySource, claim.DiscoveryEvent = discoveryDate(clientCase)
+
+	// A violation cannot be discovered before it happens
+	if !claim.ViolationDate.IsZero() && claim.DiscoveryDate.Before(claim.ViolationDate) {
+		claim.DiscoveryDate = claim.ViolationDate
+		claim.DiscoverySource = claim.ViolationSource
+		claim.DiscoveryEvent = claim.ViolationEvent
+	}
+
+	claim.Deadlines = lc.Deadlines.FCRALimitations(claim.DiscoveryDate, claim.ViolationDate)
+	if len(claim.Deadlines) == 0 {
+		claim.Status = LimitationsUnknown
+		claim.TriggerEvent = "No discovery or violation date was found"
+		return
+	}
+
+	controlling := claim.Deadlines[0]
+	claim.Deadline = controlling.DeadlineDate
+	claim.DaysRemaining = controlling.DaysRemaining
+	if controlling.DeadlineType == "fcra_limitations_discovery" {
+		claim.TriggerEvent = claim.DiscoveryEvent
+		claim.TriggerDate = claim.DiscoveryDate
+		claim.TriggerSource = claim.DiscoverySource
+	} else {
+		claim.TriggerEvent = claim.ViolationEvent
+		claim.TriggerDate = claim.ViolationDate
+		claim.TriggerSource = claim.ViolationSource
+	}
+	claim.DateConfidence, claim.DateMethod = dateConfidence(clientCase, claim.TriggerSource)
+
+	switch {
+	case claim.DaysRemaining < 0:
+		claim.Status = LimitationsTimeBarred
+	case claim.DaysRemaining <= limitationsWarningDays:
+		claim.Status = LimitationsExpiring
+	default:
+		claim.Status = LimitationsTimely
+	}
+}
+
+// discoveryDate returns when the plaintiff discovered the violation. Without
+// an extracted discovery date the first dispute shows the plaintiff knew of
+// the inaccuracy by then.
+func discoveryDate(clientCase *ClientCase) (time.Time, string, string) {
+	if !clientCase.DiscoveryDate.IsZero() {
+		return clientCase.DiscoveryDate, "discoveryDate", "Plaintiff discovered the inaccurate reporting"
+	}
+	if disputed, source := earliestDisputeDate(clientCase); !disputed.IsZero() {
+		return disputed, source, "Plaintiff disputed the reporting (no discovery date was found)"
+	}
+	return time.Time{}, "", ""
+}
+
+// reportingDate returns the date the inaccurate information was first reported
+func reportingDate(clientCase *ClientCase) (time.Time, string, string) {
+	if !clientCase.FraudStartDate.IsZero() {
+		return clientCase.FraudStartDate, "fraudStartDate", "Fraudulent activity began"
+	}
+	if !clientCase.AccountOpenDate.IsZero() {
+		return clientCase.AccountOpenDate, "accountOpenDate", "Fraudulent account was opened"
+	}
+	return time.Time{}, "", ""
+}
+
+// earliestDisputeDate returns the first dispute sent to a credit bureau
+func earliestDisputeDate(clientCase *ClientCase) (time.Time, string) {
+	var earliest time.Time
+	source := ""
+	for _, interaction := range clientCase.CreditBureauInteractions {
+		if !strings.Contains(strings.ToLower(interaction.Type), "dispute") {
+			continue
+		}
+		date := parseInteractionDate(interaction.Date)
+		if !date.IsZero() && (earliest.IsZero() || date.Before(earliest)) {
+			earliest = date
+			source = "creditBureauInteractions"
+		}
+	}
+	if !clientCase.CreditBureauDisputeDate.IsZero() &&
+		(earliest.IsZero() || clientCase.CreditBureauDisputeDate.Before(earliest)) {
+		earliest = clientCase.CreditBureauDisputeDate
+		source = "creditBureauDisputeDate"
+	}
+	return earliest, source
+}
+
+// parseInteractionDate parses a CreditBureauInteraction date
+func parseInteractionDate(value string) time.Time {
+	layouts := []string{
+		"January 2, 2006",
+		"Jan 2, 2006",
+		"01/02/2006",
+		"1/2/2006",
+		"2006-01-02",
+	}
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// dateConfidence returns the confidence and method recorded for a date field
+func dateConfidence(clientCase *ClientCase, field string) (float64, string) {
+	// Interaction dates are copied from the dispute date
+	if field == "creditBureauInteractions" {
+		field = "creditBureauDisputeDate"
+	}
+	if provenance := clientCase.ProvenanceFor(field); provenance != nil {
+		return provenance.Confidence, provenance.Method
+	}
+	return unrecordedDateConfidence, "Not recorded"
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscoveryDateOverrideRechecksCounts(t *testing.T) {
+	s := &DocumentService{limitations: NewLimitationsChecker()}
+	now := time.Now().UTC().Truncate(24 * time.Hour)
+
+	// Reported three years ago and discovered soon after, so the two years
+	// from discovery have run out
+	clientCase := &ClientCase{
+		ClientName:     "Eman Youssef",
+		FraudStartDate: now.AddDate(-3, 0, 0),
+		DiscoveryDate:  now.AddDate(-3, 1, 0),
+	}
+	counts := []string{
+		"15 U.S.C. § 1681e(b)",
+		"15 U.S.C. § 1681i",
+		"15 U.S.C. §§ 1681n, 1681o",
+	}
+	generated := func() []string {
+		var kept []string
+		for _, count := range counts {
+			if !clientCase.Limitations.BarsCause(count) {
+				kept = append(kept, count)
+			}
+		}
+		return kept
+	}
+
+	s.RecheckLimitations(nil, clientCase, nil)
+	if kept := generated(); len(kept) != 0 {
+		t.Fatalf("counts generated with the extracted discovery date = %v, want none", kept)
+	}
+
+	// The attorney finds the plaintiff only learned of the reporting last month
+	discovered := now.AddDate(0, -1, 0).Format("2006-01-02")
+	if err := clientCase.OverrideField("discoveryDate", discovered, "Client first saw the report last month", "attorney"); err != nil {
+		t.Fatalf("OverrideField: %v", err)
+	}
+	if !IsLimitationsDate("discoveryDate") {
+		t.Fatal("IsLimitationsDate(discoveryDate) = false")
+	}
+	s.RecheckLimitations(nil, clientCase, nil)
+	if kept := generated(); len(kept) != len(counts) {
+		t.Errorf("counts generated with the overridden discovery date = %v, want %v", kept, counts)
+	}
+	if !clientCase.DiscoveryDate.Equal(now.AddDate(-3, 1, 0)) {
+		t.Errorf("the check changed the extracted discovery date to %s", clientCase.DiscoveryDate.Format("2006-01-02"))
+	}
+
+	// Withdrawing the override blocks the counts again
+	clientCase.ClearFieldOverride("discoveryDate")
+	s.RecheckLimitations(nil, clientCase, nil)
+	if kept := generated(); len(kept) != 0 {
+		t.Errorf("counts generated after the override was withdrawn = %v, want none", kept)
+	}
+
+	if IsLimitationsDate("creditLimit") {
+		t.Error("IsLimitationsDate(creditLimit) = true")
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalCaseStore keeps cases on the local filesystem under <root>/<tenant>
+type LocalCaseStore struct {
+	root     string
+	tenant   string
+	registry *caseRegistry
+}
+
+// NewLocalCaseStore creates a filesystem case store
+func NewLocalCaseStore(root, tenant string) *LocalCaseStore {
+	store := &LocalCaseStore{
+		root:   filepath.Join(root, tenant),
+		tenant: tenant,
+	}
+	store.registry = &caseRegistry{tenant: tenant, read: store.Read, write: store.Write}
+	return store
+}
+
+// Backend names the storage backend
+func (s *LocalCaseStore) Backend() string { return "local" }
+
+// Tenant returns the tenant this store serves
+func (s *LocalCaseStore) Tenant() string { return s.tenant }
+
+// List returns the visible files and folders directly inside a folder
+func (s *LocalCaseStore) List(folder string) ([]StoredObject, error) {
+	folder = cleanStorePath(folder)
+
+	entries, err := os.ReadDir(s.resolve(folder))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("folder %s: %w", folder, ErrCaseObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to read folder %s: %v", folder, err)
+	}
+
+	var objects []StoredObject
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		object := StoredObject{
+			Name:        entry.Name(),
+			Path:        path.Join(folder, entry.Name()),
+			Modified:    info.ModTime(),
+			IsDirectory: entry.IsDir(),
+		}
+		if !entry.IsDir() {
+			object.Size = info.Size()
+		}
+		objects = append(objects, object)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+	return objects, nil
+}
+
+// Stat describes a single file or folder
+func (s *LocalCaseStore) Stat(objectPath string) (*StoredObject, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	info, err := os.Stat(s.resolve(objectPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to stat %s: %v", objectPath, err)
+	}
+
+	object := &StoredObject{
+		Name:        path.Base(objectPath),
+		Path:        objectPath,
+		Modified:    info.ModTime(),
+		IsDirectory: info.IsDir(),
+	}
+	if !info.IsDir() {
+		object.Size = info.Size()
+	}
+	return object, nil
+}
+
+// Read returns the contents of a file
+func (s *LocalCaseStore) Read(objectPath string) ([]byte, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	data, err := os.ReadFile(s.resolve(objectPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", objectPath, err)
+	}
+	return data, nil
+}
+
+// Write replaces a file, creating its folder if needed. The data is written to
+// a temporary file first so readers never see a partial document.
+func (s *LocalCaseStore) Write(objectPath string, data []byte) error {
+	objectPath = cleanStorePath(objectPath)
+	target := s.resolve(objectPath)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %v", objectPath, err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(target), ".write-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	if err := os.Rename(tempFile.Name(), target); err != nil {
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	return nil
+}
+
+// LocalPath returns the file's path on disk
+func (s *LocalCaseStore) LocalPath(objectPath string) (string, error) {
+	objectPath = cleanStorePath(objectPath)
+	localPath := s.resolve(objectPath)
+
+	if _, err := os.Stat(localPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+		}
+		return "", fmt.Errorf("failed to stat %s: %v", objectPath, err)
+	}
+	return localPath, nil
+}
+
+// EnsureCase returns the case for a folder, registering it on first use
+func (s *LocalCaseStore) EnsureCase(provider, folder string) (*CaseMetadata, error) {
+	if provider == "" || provider == ProviderCaseStore {
+		if _, err := s.Stat(folder); err != nil {
+			return nil, err
+		}
+	}
+	return s.registry.ensure(provider, folder)
+}
+
+// GetCase looks up a case by ID
+func (s *LocalCaseStore) GetCase(caseID string) (*CaseMetadata, error) {
+	return s.registry.get(caseID)
+}
+
+// ListCases returns every registered case for the tenant
+func (s *LocalCaseStore) ListCases() ([]CaseMetadata, error) {
+	return s.registry.list()
+}
+
+// UpdateCase saves changes to a case's metadata
+func (s *LocalCaseStore) UpdateCase(metadata CaseMetadata) error {
+	return s.registry.update(metadata)
+}
+
+// resolve maps a clean store path to a path under the tenant root
+func (s *LocalCaseStore) resolve(objectPath string) string {
+	return filepath.Join(s.root, filepath.FromSlash(objectPath))
+}
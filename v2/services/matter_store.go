@@ -0,0 +1,692 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrMatterNotFound is returned when a matter ID is not in the matter store
+var ErrMatterNotFound = errors.New("matter not found")
+
+// ErrMatterConflict is returned when a session saves case data over changes
+// another session made to the matter after the data was loaded
+var ErrMatterConflict = errors.New("matter was changed by another session")
+
+// ErrDocumentVersionNotFound is returned for a version number a matter does not have
+var ErrDocumentVersionNotFound = errors.New("document version not found")
+
+// Matter store tables. Matters are keyed by case ID; every other table holds
+// one nested bucket per matter.
+var (
+	mattersBucket           = []byte("matters")
+	clientsBucket           = []byte("clients")
+	defendantsBucket        = []byte("defendants")
+	sourceDocumentsBucket   = []byte("source_documents")
+	extractionResultsBucket = []byte("extraction_results")
+	violationsBucket        = []byte("violations")
+	documentVersionsBucket  = []byte("document_versions")
+
+	matterStoreBuckets = [][]byte{
+		mattersBucket,
+		clientsBucket,
+		defendantsBucket,
+		sourceDocumentsBucket,
+		extractionResultsBucket,
+		violationsBucket,
+		documentVersionsBucket,
+	}
+)
+
+// MatterStoreConfig configures the matter database; an empty path turns it off
+// and sessions keep the whole case analysis as before
+type MatterStoreConfig struct {
+	Path string `json:"path"`
+}
+
+// Matter is a case folder's durable record. It holds the current ClientCase and
+// processing result that sessions point at through WorkflowState.MatterID.
+type Matter struct {
+	ID                 string                    `json:"id"`
+	Tenant             string                    `json:"tenant"`
+	Provider           string                    `json:"provider,omitempty"`
+	CaseFolder         string                    `json:"caseFolder"`
+	Name               string                    `json:"name"`
+	ClientName         string                    `json:"clientName,omitempty"`
+	TemplateID         string                    `json:"templateId,omitempty"`
+	SelectedDocuments  []string                  `json:"selectedDocuments,omitempty"`
+	ProcessingResult   *DocumentProcessingResult `json:"processingResult,omitempty"`
+	ClientCase         *ClientCase               `json:"clientCase,omitempty"`
+	LatestExtractionID string                    `json:"latestExtractionId,omitempty"`
+	Revision           int                       `json:"revision"` // counts changes to the ClientCase and processing result
+	CreatedBy          string                    `json:"createdBy,omitempty"`
+	CreatedAt          time.Time                 `json:"createdAt"`
+	UpdatedAt          time.Time                 `json:"updatedAt"`
+}
+
+// MatterClient is the client of a matter as of its latest extraction
+type MatterClient struct {
+	MatterID          string    `json:"matterId"`
+	Name              string    `json:"name"`
+	ContactInfo       string    `json:"contactInfo,omitempty"`
+	ResidenceLocation string    `json:"residenceLocation,omitempty"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// MatterDefendant is one defendant named in a matter's latest extraction
+type MatterDefendant struct {
+	MatterID string `json:"matterId"`
+	Position int    `json:"position"`
+	Defendant
+}
+
+// SourceDocumentRecord is a document that has been processed for a matter
+type SourceDocumentRecord struct {
+	MatterID         string    `json:"matterId"`
+	Path             string    `json:"path"`
+	Document         Document  `json:"document"`
+	ExtractionID     string    `json:"extractionId"`
+	FirstProcessedAt time.Time `json:"firstProcessedAt"`
+	LastProcessedAt  time.Time `json:"lastProcessedAt"`
+}
+
+// ExtractionRecord is one run of ProcessSelectedDocuments for a matter
+type ExtractionRecord struct {
+	ID               string                    `json:"id"`
+	MatterID         string                    `json:"matterId"`
+	TemplateID       string                    `json:"templateId"`
+	Documents        []string                  `json:"documents"`
+	ProcessedBy      string                    `json:"processedBy,omitempty"`
+	ProcessedAt      time.Time                 `json:"processedAt"`
+	ProcessingResult *DocumentProcessingResult `json:"processingResult"`
+	ClientCase       *ClientCase               `json:"clientCase"`
+}
+
+// MatterViolation is a violation detected in a matter's latest analysis
+type MatterViolation struct {
+	MatterID   string            `json:"matterId"`
+	DetectedAt time.Time         `json:"detectedAt"`
+	Violation  DetectedViolation `json:"violation"`
+}
+
+// MatterStore keeps matters, their parties, source documents, extraction
+// results, violations and generated documents in an embedded BoltDB file, so a
+// case analysis survives the session that produced it
+type MatterStore struct {
+	db   *bolt.DB
+	path string
+}
+
+var (
+	defaultMatterStore     *MatterStore
+	defaultMatterStoreOnce sync.Once
+)
+
+// DefaultMatterStore returns the matter store configured in config/storage.json,
+// or nil when it is turned off or cannot be opened
+func DefaultMatterStore() *MatterStore {
+	defaultMatterStoreOnce.Do(func() {
+		_, config := DefaultCaseStore()
+		if config.Database.Path == "" {
+			log.Printf("[MATTER_STORE] No database path configured - case data is kept in sessions only")
+			return
+		}
+
+		store, err := OpenMatterStore(config.Database.Path)
+		if err != nil {
+			log.Printf("[MATTER_STORE] Warning: %v - case data is kept in sessions only", err)
+			return
+		}
+		defaultMatterStore = store
+		log.Printf("[MATTER_STORE] Using matter database %s", store.Path())
+	})
+	return defaultMatterStore
+}
+
+// OpenMatterStore opens or creates the matter database at path
+func OpenMatterStore(path string) (*MatterStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create matter database directory: %v", err)
+	}
+
+	// The database holds client data, so it is only readable by the server user
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open matter database %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range matterStoreBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize matter database %s: %v", path, err)
+	}
+
+	return &MatterStore{db: db, path: path}, nil
+}
+
+// Path returns the database file path
+func (s *MatterStore) Path() string {
+	return s.path
+}
+
+// Close closes the database file
+func (s *MatterStore) Close() error {
+	return s.db.Close()
+}
+
+// EnsureMatter returns the matter for a registered case, creating it on first use
+func (s *MatterStore) EnsureMatter(metadata CaseMetadata, username string) (*Matter, error) {
+	var matter Matter
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		found, err := getMatter(tx, metadata.ID, &matter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			matter = Matter{
+				ID:         metadata.ID,
+				ClientName: metadata.ClientName,
+				CreatedBy:  username,
+				CreatedAt:  now,
+			}
+		} else if matter.Tenant == metadata.Tenant && matter.Provider == metadata.Provider &&
+			matter.CaseFolder == metadata.Folder && matter.Name == metadata.Name {
+			return nil
+		}
+		matter.Tenant = metadata.Tenant
+		matter.Provider = metadata.Provider
+		matter.CaseFolder = metadata.Folder
+		matter.Name = metadata.Name
+		matter.UpdatedAt = now
+		return putJSON(tx.Bucket(mattersBucket), []byte(matter.ID), matter)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save matter %s: %v", metadata.ID, err)
+	}
+	return &matter, nil
+}
+
+// GetMatter returns a matter by ID
+func (s *MatterStore) GetMatter(matterID string) (*Matter, error) {
+	var matter Matter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found, err := getMatter(tx, matterID, &matter)
+		if err == nil && !found {
+			err = ErrMatterNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &matter, nil
+}
+
+// ListMatters returns every matter, most recently updated first
+func (s *MatterStore) ListMatters() ([]Matter, error) {
+	var matters []Matter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(mattersBucket).ForEach(func(_, value []byte) error {
+			var matter Matter
+			if err := json.Unmarshal(value, &matter); err != nil {
+				return err
+			}
+			// The list is for browsing; the case data is loaded per matter
+			matter.ProcessingResult = nil
+			matter.ClientCase = nil
+			matters = append(matters, matter)
+			return nil
+		})
+	})
+	sort.Slice(matters, func(i, j int) bool {
+		return matters[i].UpdatedAt.After(matters[j].UpdatedAt)
+	})
+	return matters, err
+}
+
+// SaveWorkingState stores the current ClientCase and processing result of a
+// matter and returns the matter's revision. baseRevision is the revision the
+// data was loaded from: a save over changes made since then is refused with
+// ErrMatterConflict, while 0 stores the results of a new processing run
+// whatever the revision. Sessions call this on every save, so unchanged data
+// is compared in a read transaction and not rewritten.
+func (s *MatterStore) SaveWorkingState(matterID string, baseRevision int, result *DocumentProcessingResult, clientCase *ClientCase) (int, error) {
+	if clientCase == nil {
+		return 0, fmt.Errorf("no case data to save for matter %s", matterID)
+	}
+	after, err := json.Marshal([]interface{}{result, clientCase})
+	if err != nil {
+		return 0, err
+	}
+
+	var unchanged bool
+	var revision int
+	err = s.db.View(func(tx *bolt.Tx) error {
+		var matter Matter
+		found, err := getMatter(tx, matterID, &matter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrMatterNotFound
+		}
+		before, err := json.Marshal([]interface{}{matter.ProcessingResult, matter.ClientCase})
+		unchanged, revision = bytes.Equal(before, after), matter.Revision
+		return err
+	})
+	if err != nil || unchanged {
+		return revision, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		var matter Matter
+		found, err := getMatter(tx, matterID, &matter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrMatterNotFound
+		}
+		if baseRevision != 0 && matter.Revision != baseRevision {
+			return ErrMatterConflict
+		}
+		matter.ProcessingResult = result
+		matter.ClientCase = clientCase
+		if clientCase.ClientName != "" {
+			matter.ClientName = clientCase.ClientName
+		}
+		matter.Revision++
+		matter.UpdatedAt = time.Now()
+		revision = matter.Revision
+		return putJSON(tx.Bucket(mattersBucket), []byte(matter.ID), matter)
+	})
+	return revision, err
+}
+
+// RecordExtraction stores the results of processing a matter's selected
+// documents: the extraction itself, the documents it read, the client and
+// defendants it found, and the matter's new current case data
+func (s *MatterStore) RecordExtraction(matterID, templateID, username string, result *DocumentProcessingResult, clientCase *ClientCase) (*ExtractionRecord, error) {
+	var record *ExtractionRecord
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var matter Matter
+		found, err := getMatter(tx, matterID, &matter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrMatterNotFound
+		}
+
+		extractions, err := matterBucket(tx, extractionResultsBucket, matterID)
+		if err != nil {
+			return err
+		}
+		sequence, err := extractions.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		record = &ExtractionRecord{
+			ID:               fmt.Sprintf("%s-%06d", matterID, sequence),
+			MatterID:         matterID,
+			TemplateID:       templateID,
+			ProcessedBy:      username,
+			ProcessedAt:      now,
+			ProcessingResult: result,
+			ClientCase:       clientCase,
+		}
+		if result != nil {
+			for _, document := range result.SelectedDocuments {
+				record.Documents = append(record.Documents, document.Path)
+			}
+		}
+		if err := putJSON(extractions, sequenceKey(sequence), record); err != nil {
+			return err
+		}
+
+		if err := recordSourceDocuments(tx, record, result); err != nil {
+			return err
+		}
+		if err := recordParties(tx, matterID, clientCase, now); err != nil {
+			return err
+		}
+
+		matter.TemplateID = templateID
+		matter.SelectedDocuments = record.Documents
+		matter.LatestExtractionID = record.ID
+		// A run that produced no case leaves the matter's current case data alone
+		if clientCase != nil {
+			matter.ProcessingResult = result
+			matter.ClientCase = clientCase
+			if clientCase.ClientName != "" {
+				matter.ClientName = clientCase.ClientName
+			}
+			matter.Revision++
+		}
+		matter.UpdatedAt = now
+		return putJSON(tx.Bucket(mattersBucket), []byte(matter.ID), matter)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record extraction for matter %s: %v", matterID, err)
+	}
+	return record, nil
+}
+
+// RecordViolations replaces a matter's detected violations with the latest analysis
+func (s *MatterStore) RecordViolations(matterID string, violations []DetectedViolation) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(violationsBucket)
+		if parent.Bucket([]byte(matterID)) != nil {
+			if err := parent.DeleteBucket([]byte(matterID)); err != nil {
+				return err
+			}
+		}
+		bucket, err := parent.CreateBucket([]byte(matterID))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, violation := range violations {
+			key := fmt.Sprintf("%04d_%s", i, violation.ViolationDefinition.ViolationID)
+			record := MatterViolation{MatterID: matterID, DetectedAt: now, Violation: violation}
+			if err := putJSON(bucket, []byte(key), record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record violations for matter %s: %v", matterID, err)
+	}
+	return nil
+}
+
+// RecordDocumentVersion stores a document as the next version of a matter's
+// document history and fills in its number, time and content hash. A document
+// identical to the latest version is not stored again; the latest version is
+// returned with stored set to false. Manual saves by the same author within
+// manualEditWindow of the latest manual version update it in place, so the
+// editor's autosave does not add a version every few seconds.
+func (s *MatterStore) RecordDocumentVersion(matterID string, version GeneratedDocumentVersion) (recorded *GeneratedDocumentVersion, stored bool, err error) {
+	hash := sha256.Sum256([]byte(version.Content))
+	version.MatterID = matterID
+	version.ContentHash = hex.EncodeToString(hash[:])
+	version.CreatedAt = time.Now()
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(mattersBucket).Get([]byte(matterID)) == nil {
+			return ErrMatterNotFound
+		}
+		versions, err := matterBucket(tx, documentVersionsBucket, matterID)
+		if err != nil {
+			return err
+		}
+
+		if key, latest := versions.Cursor().Last(); latest != nil {
+			var previous GeneratedDocumentVersion
+			if err := json.Unmarshal(latest, &previous); err != nil {
+				return err
+			}
+			if previous.ContentHash == version.ContentHash {
+				recorded = &previous
+				return nil
+			}
+			if previous.Source == VersionManual && version.Source == VersionManual &&
+				previous.Author == version.Author && version.CreatedAt.Sub(previous.CreatedAt) < manualEditWindow {
+				version.Version = previous.Version
+				recorded, stored = &version, true
+				return putJSON(versions, key, version)
+			}
+		}
+
+		sequence, err := versions.NextSequence()
+		if err != nil {
+			return err
+		}
+		version.Version = int(sequence)
+		recorded, stored = &version, true
+		return putJSON(versions, sequenceKey(sequence), version)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record document version for matter %s: %v", matterID, err)
+	}
+	return recorded, stored, nil
+}
+
+// DocumentVersion returns one version of a matter's document history
+func (s *MatterStore) DocumentVersion(matterID string, number int) (*GeneratedDocumentVersion, error) {
+	var version GeneratedDocumentVersion
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(documentVersionsBucket).Bucket([]byte(matterID))
+		if bucket == nil || number < 1 {
+			return ErrDocumentVersionNotFound
+		}
+		value := bucket.Get(sequenceKey(uint64(number)))
+		if value == nil {
+			return ErrDocumentVersionNotFound
+		}
+		return json.Unmarshal(value, &version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Client returns the client recorded for a matter
+func (s *MatterStore) Client(matterID string) (*MatterClient, error) {
+	var client MatterClient
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(clientsBucket).Get([]byte(matterID))
+		if value == nil {
+			return ErrMatterNotFound
+		}
+		return json.Unmarshal(value, &client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// Defendants returns the defendants recorded for a matter
+func (s *MatterStore) Defendants(matterID string) ([]MatterDefendant, error) {
+	var defendants []MatterDefendant
+	err := s.eachInMatter(defendantsBucket, matterID, func(value []byte) error {
+		var defendant MatterDefendant
+		if err := json.Unmarshal(value, &defendant); err != nil {
+			return err
+		}
+		defendants = append(defendants, defendant)
+		return nil
+	})
+	return defendants, err
+}
+
+// SourceDocuments returns the documents that have been processed for a matter
+func (s *MatterStore) SourceDocuments(matterID string) ([]SourceDocumentRecord, error) {
+	var documents []SourceDocumentRecord
+	err := s.eachInMatter(sourceDocumentsBucket, matterID, func(value []byte) error {
+		var document SourceDocumentRecord
+		if err := json.Unmarshal(value, &document); err != nil {
+			return err
+		}
+		documents = append(documents, document)
+		return nil
+	})
+	return documents, err
+}
+
+// Extractions returns a matter's extraction history, oldest first
+func (s *MatterStore) Extractions(matterID string) ([]ExtractionRecord, error) {
+	var records []ExtractionRecord
+	err := s.eachInMatter(extractionResultsBucket, matterID, func(value []byte) error {
+		var record ExtractionRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// Violations returns the violations detected in a matter's latest analysis
+func (s *MatterStore) Violations(matterID string) ([]MatterViolation, error) {
+	var records []MatterViolation
+	err := s.eachInMatter(violationsBucket, matterID, func(value []byte) error {
+		var record MatterViolation
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// DocumentVersions returns a matter's generated document versions, oldest first
+func (s *MatterStore) DocumentVersions(matterID string) ([]GeneratedDocumentVersion, error) {
+	var versions []GeneratedDocumentVersion
+	err := s.eachInMatter(documentVersionsBucket, matterID, func(value []byte) error {
+		var version GeneratedDocumentVersion
+		if err := json.Unmarshal(value, &version); err != nil {
+			return err
+		}
+		versions = append(versions, version)
+		return nil
+	})
+	return versions, err
+}
+
+// eachInMatter calls fn with every value in a matter's bucket of a table, in key order
+func (s *MatterStore) eachInMatter(table []byte, matterID string, fn func(value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(table).Bucket([]byte(matterID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, value []byte) error {
+			return fn(value)
+		})
+	})
+}
+
+// recordSourceDocuments upserts the documents read by an extraction
+func recordSourceDocuments(tx *bolt.Tx, record *ExtractionRecord, result *DocumentProcessingResult) error {
+	if result == nil {
+		return nil
+	}
+	bucket, err := matterBucket(tx, sourceDocumentsBucket, record.MatterID)
+	if err != nil {
+		return err
+	}
+	for _, document := range result.SelectedDocuments {
+		source := SourceDocumentRecord{
+			MatterID:         record.MatterID,
+			Path:             document.Path,
+			FirstProcessedAt: record.ProcessedAt,
+		}
+		if existing := bucket.Get([]byte(document.Path)); existing != nil {
+			if err := json.Unmarshal(existing, &source); err != nil {
+				return err
+			}
+		}
+		source.Document = document
+		source.ExtractionID = record.ID
+		source.LastProcessedAt = record.ProcessedAt
+		if err := putJSON(bucket, []byte(document.Path), source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordParties replaces a matter's client and defendants with those of a ClientCase
+func recordParties(tx *bolt.Tx, matterID string, clientCase *ClientCase, now time.Time) error {
+	if clientCase == nil {
+		return nil
+	}
+
+	client := MatterClient{
+		MatterID:          matterID,
+		Name:              clientCase.ClientName,
+		ContactInfo:       clientCase.ContactInfo,
+		ResidenceLocation: clientCase.ResidenceLocation,
+		UpdatedAt:         now,
+	}
+	if err := putJSON(tx.Bucket(clientsBucket), []byte(matterID), client); err != nil {
+		return err
+	}
+
+	parent := tx.Bucket(defendantsBucket)
+	if parent.Bucket([]byte(matterID)) != nil {
+		if err := parent.DeleteBucket([]byte(matterID)); err != nil {
+			return err
+		}
+	}
+	defendants, err := parent.CreateBucket([]byte(matterID))
+	if err != nil {
+		return err
+	}
+	for i, defendant := range clientCase.Defendants {
+		record := MatterDefendant{MatterID: matterID, Position: i, Defendant: defendant}
+		if err := putJSON(defendants, []byte(fmt.Sprintf("%04d", i)), record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getMatter reads a matter into matter and reports whether it exists
+func getMatter(tx *bolt.Tx, matterID string, matter *Matter) (bool, error) {
+	value := tx.Bucket(mattersBucket).Get([]byte(matterID))
+	if value == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, matter)
+}
+
+// matterBucket returns a matter's nested bucket in a table, creating it if needed
+func matterBucket(tx *bolt.Tx, table []byte, matterID string) (*bolt.Bucket, error) {
+	return tx.Bucket(table).CreateBucketIfNotExists([]byte(matterID))
+}
+
+// putJSON stores value as JSON under key
+func putJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// sequenceKey encodes a bucket sequence number so keys sort in insertion order
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/unidoc/unipdf/v3/extractor"
+)
+
+const (
+	// defaultOCRMinConfidence is the mean word confidence, out of 100, below
+	// which an OCR'd page is flagged for review
+	defaultOCRMinConfidence = 60.0
+
+	// imageOnlyPageChars is how little text a page with images can have before
+	// it is treated as a scan
+	imageOnlyPageChars = 20
+)
+
+// OCRBackend recognizes the text in a page image. Backends are selected with
+// MALLON_OCR_BACKEND; see DefaultOCRBackend.
+type OCRBackend interface {
+	Name() string
+	Recognize(ctx context.Context, imagePath string) (*OCRText, error)
+}
+
+// OCRText is the text an OCR backend found in an image
+type OCRText struct {
+	Text       string
+	Confidence float64 // mean word confidence, 0-100
+	Words      int
+}
+
+// OCRPageResult records how the text of an image-only PDF page was recovered.
+// Pages that could not be read, because OCR failed or no backend is
+// configured, have an Error and count as low confidence.
+type OCRPageResult struct {
+	Page          int     `json:"page"`
+	Backend       string  `json:"backend,omitempty"`
+	Confidence    float64 `json:"confidence"`
+	Words         int     `json:"words"`
+	LowConfidence bool    `json:"lowConfidence"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// OCRWarning is an OCR'd page of a processed document that needs a human look
+type OCRWarning struct {
+	Document   string
+	Page       int
+	Confidence float64
+	Reason     string
+}
+
+// OCRPages returns the OCR result of each image-only page, recorded in
+// Metadata["ocrPages"]. Metadata read back from JSON is converted as well.
+func (c *ExtractedContent) OCRPages() []OCRPageResult {
+	switch pages := c.Metadata["ocrPages"].(type) {
+	case nil:
+		return nil
+	case []OCRPageResult:
+		return pages
+	default:
+		data, err := json.Marshal(pages)
+		if err != nil {
+			return nil
+		}
+		var decoded []OCRPageResult
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil
+		}
+		return decoded
+	}
+}
+
+// HasUnreadPages reports whether an image-only page could not be OCR'd
+func (c *ExtractedContent) HasUnreadPages() bool {
+	for _, page := range c.OCRPages() {
+		if page.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// OCRWarnings lists the low-confidence and unreadable OCR pages of every processed document
+func (r *DocumentProcessingResult) OCRWarnings() []OCRWarning {
+	var warnings []OCRWarning
+	for _, doc := range r.SelectedDocuments {
+		for _, page := range doc.OCRPages {
+			if !page.LowConfidence {
+				continue
+			}
+			reason := fmt.Sprintf("OCR confidence %.0f%%", page.Confidence)
+			if page.Error != "" {
+				reason = page.Error
+			}
+			warnings = append(warnings, OCRWarning{Document: doc.Name, Page: page.Page, Confidence: page.Confidence, Reason: reason})
+		}
+	}
+	return warnings
+}
+
+var (
+	defaultOCRBackend     OCRBackend
+	defaultOCRBackendOnce sync.Once
+)
+
+// DefaultOCRBackend returns the OCR backend named by MALLON_OCR_BACKEND:
+// "tesseract" (the default) runs the tesseract binary found on PATH or at
+// MALLON_TESSERACT_PATH, reading MALLON_OCR_LANGUAGE (default "eng"); "none"
+// turns OCR off. It returns nil when no backend is available.
+func DefaultOCRBackend() OCRBackend {
+	defaultOCRBackendOnce.Do(func() {
+		switch backend := envOrDefault("MALLON_OCR_BACKEND", "tesseract"); backend {
+		case "none", "off":
+			log.Printf("[EXTRACTOR] OCR is turned off - image-only PDF pages will be flagged as unread")
+		case "tesseract":
+			path, err := exec.LookPath(envOrDefault("MALLON_TESSERACT_PATH", "tesseract"))
+			if err != nil {
+				log.Printf("[EXTRACTOR] Warning: tesseract not found (%v) - image-only PDF pages will be flagged as unread", err)
+				return
+			}
+			defaultOCRBackend = &TesseractOCR{Path: path, Language: envOrDefault("MALLON_OCR_LANGUAGE", "eng")}
+			log.Printf("[EXTRACTOR] Using tesseract OCR at %s", path)
+		default:
+			log.Printf("[EXTRACTOR] Warning: unknown OCR backend %q - image-only PDF pages will be flagged as unread", backend)
+		}
+	})
+	return defaultOCRBackend
+}
+
+// defaultOCRConfidenceThreshold reads MALLON_OCR_MIN_CONFIDENCE
+func defaultOCRConfidenceThreshold() float64 {
+	value := os.Getenv("MALLON_OCR_MIN_CONFIDENCE")
+	if value == "" {
+		return defaultOCRMinConfidence
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		log.Printf("[EXTRACTOR] Warning: ignoring invalid MALLON_OCR_MIN_CONFIDENCE %q", value)
+		return defaultOCRMinConfidence
+	}
+	return threshold
+}
+
+// TesseractOCR runs a local tesseract binary on each page image
+type TesseractOCR struct {
+	Path     string
+	Language string
+}
+
+// Name identifies the backend in OCR results
+func (t *TesseractOCR) Name() string {
+	return "tesseract"
+}
+
+// Recognize OCRs an image, reading words and their confidence from tesseract's TSV output
+func (t *TesseractOCR) Recognize(ctx context.Context, imagePath string) (*OCRText, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Path, imagePath, "stdout", "-l", t.Language, "tsv")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(stdout.String()), nil
+}
+
+// parseTesseractTSV rebuilds the text of tesseract TSV output line by line,
+// with a blank line between paragraphs, and averages the word confidences
+func parseTesseractTSV(tsv string) *OCRText {
+	result := &OCRText{}
+	var text strings.Builder
+	var confidence float64
+	lastParagraph, lastLine := "", ""
+
+	scanner := bufio.NewScanner(strings.NewReader(tsv))
+	for scanner.Scan() {
+		// level page block par line word left top width height conf text
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		word := strings.TrimSpace(fields[11])
+		wordConfidence, err := strconv.ParseFloat(fields[10], 64)
+		if word == "" || err != nil || wordConfidence < 0 {
+			continue
+		}
+
+		paragraph := fields[1] + "." + fields[2] + "." + fields[3]
+		line := paragraph + "." + fields[4]
+		switch {
+		case text.Len() == 0:
+		case paragraph != lastParagraph:
+			text.WriteString("\n\n")
+		case line != lastLine:
+			text.WriteString("\n")
+		default:
+			text.WriteString(" ")
+		}
+		text.WriteString(word)
+		lastParagraph, lastLine = paragraph, line
+
+		confidence += wordConfidence
+		result.Words++
+	}
+
+	result.Text = text.String()
+	if result.Words > 0 {
+		result.Confidence = confidence / float64(result.Words)
+	}
+	return result
+}
+
+// ocrPage recovers the text of an image-only page by OCR'ing its images from
+// top to bottom
+func (e *DocumentExtractor) ocrPage(ctx context.Context, pageNumber int, images []extractor.ImageMark) (string, OCRPageResult) {
+	result := OCRPageResult{Page: pageNumber, LowConfidence: true}
+	if e.OCR == nil {
+		result.Error = "image-only page, no OCR backend configured"
+		return "", result
+	}
+	result.Backend = e.OCR.Name()
+
+	// PDF coordinates start at the bottom of the page
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Y+images[i].Height > images[j].Y+images[j].Height
+	})
+
+	var texts []string
+	var confidence float64
+	for _, mark := range images {
+		text, err := e.ocrImage(ctx, mark)
+		if err != nil {
+			result.Error = fmt.Sprintf("OCR failed: %v", err)
+			return "", result
+		}
+		if text.Text != "" {
+			texts = append(texts, text.Text)
+		}
+		confidence += text.Confidence * float64(text.Words)
+		result.Words += text.Words
+	}
+
+	if result.Words == 0 {
+		result.Error = "OCR found no text"
+		return "", result
+	}
+	result.Confidence = confidence / float64(result.Words)
+	result.LowConfidence = result.Confidence < e.OCRMinConfidence
+	log.Printf("[EXTRACTOR] OCR'd page %d with %s - %d words, %.1f%% confidence", pageNumber, result.Backend, result.Words, result.Confidence)
+	return strings.Join(texts, "\n\n"), result
+}
+
+// ocrImage writes a page image to a temporary PNG for the OCR backend
+func (e *DocumentExtractor) ocrImage(ctx context.Context, mark extractor.ImageMark) (*OCRText, error) {
+	img, err := mark.Image.ToGoImage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page image: %v", err)
+	}
+
+	file, err := os.CreateTemp("", "ocr-page-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCR image: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write OCR image: %v", err)
+	}
+	return e.OCR.Recognize(ctx, file.Name())
+}
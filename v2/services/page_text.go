@@ -0,0 +1,233 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unipdf/v3/extractor"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+// SourceBox is a region of a page as fractions of the page's width and height,
+// measured from its top-left corner, so it can be drawn over the page at any size
+type SourceBox struct {
+	Page   int     `json:"page"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TextSpan is a word of extracted text: its offsets in RawText and, for PDFs,
+// where it was drawn on the page
+type TextSpan struct {
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+	Box   *SourceBox `json:"box,omitempty"`
+}
+
+// PageText is one page of an extracted document. Start and End are offsets in
+// RawText; Width and Height are the page size in points, known for PDFs only.
+type PageText struct {
+	Number int        `json:"number"`
+	Start  int        `json:"start"`
+	End    int        `json:"end"`
+	Width  float64    `json:"width,omitempty"`
+	Height float64    `json:"height,omitempty"`
+	Spans  []TextSpan `json:"spans,omitempty"`
+}
+
+// SourceCitation names a page of a source document the way a complaint cites
+// it, e.g. "Summons_Experian.pdf, p.2"
+func SourceCitation(document string, page int) string {
+	if document == "" {
+		return ""
+	}
+	if page < 1 {
+		return filepath.Base(document)
+	}
+	return fmt.Sprintf("%s, p.%d", filepath.Base(document), page)
+}
+
+// Citation names the document and page the value was extracted from
+func (r ExtractionResult) Citation() string {
+	return SourceCitation(r.Document, r.Page)
+}
+
+// Citation names the document and page the value was extracted from
+func (p *FieldProvenance) Citation() string {
+	return SourceCitation(p.Document, p.Page)
+}
+
+// Page returns a page by its 1-based number
+func (c *ExtractedContent) Page(number int) *PageText {
+	for i := range c.Pages {
+		if c.Pages[i].Number == number {
+			return &c.Pages[i]
+		}
+	}
+	return nil
+}
+
+// PageText returns the text of a page
+func (c *ExtractedContent) PageText(number int) string {
+	page := c.Page(number)
+	if page == nil || page.End > len(c.RawText) || page.Start > page.End {
+		return ""
+	}
+	return c.RawText[page.Start:page.End]
+}
+
+// PageAt returns the 1-based page containing an offset in RawText. Content
+// extracted before pages were recorded falls back to PageOffsets.
+func (c *ExtractedContent) PageAt(offset int) int {
+	if len(c.Pages) == 0 {
+		return pageForOffset(c.PageOffsets, offset)
+	}
+	page := c.Pages[0].Number
+	for _, p := range c.Pages {
+		if offset >= p.Start && p.End > p.Start {
+			page = p.Number
+		}
+	}
+	return page
+}
+
+// Boxes returns the regions of the page where the text between two offsets
+// was drawn, one box per line. Only PDF text has positions.
+func (c *ExtractedContent) Boxes(start, end int) []SourceBox {
+	var boxes []SourceBox
+	for _, page := range c.Pages {
+		if page.End <= start || page.Start >= end {
+			continue
+		}
+		for _, span := range page.Spans {
+			if span.Box == nil || span.End <= start || span.Start >= end {
+				continue
+			}
+			box := *span.Box
+			if last := len(boxes) - 1; last >= 0 && sameLine(boxes[last], box) {
+				boxes[last] = unionBox(boxes[last], box)
+				continue
+			}
+			boxes = append(boxes, box)
+		}
+	}
+	return boxes
+}
+
+// sameLine reports whether two word boxes sit on the same line of a page
+func sameLine(a, b SourceBox) bool {
+	if a.Page != b.Page {
+		return false
+	}
+	aMiddle, bMiddle := a.Y+a.Height/2, b.Y+b.Height/2
+	return bMiddle > a.Y && bMiddle < a.Y+a.Height && aMiddle > b.Y && aMiddle < b.Y+b.Height
+}
+
+// unionBox returns the smallest box holding both boxes
+func unionBox(a, b SourceBox) SourceBox {
+	x0, y0 := minFloat(a.X, b.X), minFloat(a.Y, b.Y)
+	x1, y1 := maxFloat(a.X+a.Width, b.X+b.Width), maxFloat(a.Y+a.Height, b.Y+b.Height)
+	return SourceBox{Page: a.Page, X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// pageBuilder joins the text of each page into RawText, with a line break
+// between pages, recording where each page and its words ended up
+type pageBuilder struct {
+	text  strings.Builder
+	pages []PageText
+}
+
+// add appends the next page. Span offsets are relative to text; leading and
+// trailing whitespace is trimmed and the spans are shifted to match.
+func (b *pageBuilder) add(text string, spans []TextSpan, width, height float64) {
+	number := len(b.pages) + 1
+	lead := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	text = strings.TrimSpace(text)
+	if text != "" && b.text.Len() > 0 {
+		b.text.WriteString("\n")
+	}
+
+	page := PageText{Number: number, Start: b.text.Len(), Width: width, Height: height}
+	b.text.WriteString(text)
+	page.End = b.text.Len()
+
+	for _, span := range spans {
+		span.Start -= lead
+		span.End -= lead
+		if span.Start < 0 || span.End > len(text) || span.Start >= span.End {
+			continue
+		}
+		span.Start += page.Start
+		span.End += page.Start
+		if span.Box != nil {
+			span.Box.Page = number
+		}
+		page.Spans = append(page.Spans, span)
+	}
+	b.pages = append(b.pages, page)
+}
+
+// content returns the joined text, its pages and where each page starts
+func (b *pageBuilder) content() (string, []PageText, []int) {
+	offsets := make([]int, len(b.pages))
+	for i, page := range b.pages {
+		offsets[i] = page.Start
+	}
+	return b.text.String(), b.pages, offsets
+}
+
+// pdfWordSpans groups the text marks of a PDF page into words, converting
+// their bounding boxes to fractions of the page from its top-left corner
+func pdfWordSpans(text string, marks *extractor.TextMarkArray, mediaBox model.PdfRectangle) []TextSpan {
+	width, height := mediaBox.Urx-mediaBox.Llx, mediaBox.Ury-mediaBox.Lly
+	if marks == nil || width <= 0 || height <= 0 {
+		return nil
+	}
+
+	var spans []TextSpan
+	var word *TextSpan
+	var bbox model.PdfRectangle
+	closeWord := func() {
+		if word == nil {
+			return
+		}
+		word.Box = &SourceBox{
+			X:      clampFraction((bbox.Llx - mediaBox.Llx) / width),
+			Y:      clampFraction((mediaBox.Ury - bbox.Ury) / height),
+			Width:  clampFraction((bbox.Urx - bbox.Llx) / width),
+			Height: clampFraction((bbox.Ury - bbox.Lly) / height),
+		}
+		spans = append(spans, *word)
+		word = nil
+	}
+
+	for _, mark := range marks.Elements() {
+		end := mark.Offset + len(mark.Text)
+		// Inserted spaces and line breaks end a word, as do marks that don't line up with the text
+		if mark.Meta || strings.TrimSpace(mark.Text) == "" || mark.Offset < 0 || end > len(text) || text[mark.Offset:end] != mark.Text {
+			closeWord()
+			continue
+		}
+		if word != nil && word.End == mark.Offset {
+			word.End = end
+			bbox = model.PdfRectangle{
+				Llx: minFloat(bbox.Llx, mark.BBox.Llx), Lly: minFloat(bbox.Lly, mark.BBox.Lly),
+				Urx: maxFloat(bbox.Urx, mark.BBox.Urx), Ury: maxFloat(bbox.Ury, mark.BBox.Ury),
+			}
+			continue
+		}
+		closeWord()
+		word = &TextSpan{Start: mark.Offset, End: end}
+		bbox = mark.BBox
+	}
+	closeWord()
+	return spans
+}
+
+func clampFraction(value float64) float64 {
+	return maxFloat(0, minFloat(1, value))
+}
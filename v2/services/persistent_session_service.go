@@ -1,12 +1,16 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sync"
 	"time"
 )
@@ -14,12 +18,16 @@ import (
 // SessionData represents persistent session data
 type SessionData struct {
 	SessionID       string                 `json:"sessionId"`
+	Username        string                 `json:"username,omitempty"` // set for sessions created by login
 	WorkflowState   *WorkflowState        `json:"workflowState"`
 	CreatedAt       time.Time             `json:"createdAt"`
 	LastAccessed    time.Time             `json:"lastAccessed"`
 	ExpiresAt       time.Time             `json:"expiresAt"`
 }
 
+// sessionIDPattern limits session IDs to characters that are safe in a file name
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
 // PersistentSessionService manages session state with file-based persistence
 type PersistentSessionService struct {
 	SessionDir    string
@@ -27,6 +35,7 @@ type PersistentSessionService struct {
 	CleanupTicker *time.Ticker
 	FileLock      sync.RWMutex
 	ttl           time.Duration
+	matters       *MatterStore
 }
 
 // NewPersistentSessionService creates a new persistent session service
@@ -56,6 +65,14 @@ func NewPersistentSessionService(sessionDir string, ttl time.Duration) (*Persist
 	return service, nil
 }
 
+// SetMatterStore moves the case data of sessions that point at a matter out of
+// the session files and into the matter store
+func (s *PersistentSessionService) SetMatterStore(store *MatterStore) {
+	s.FileLock.Lock()
+	defer s.FileLock.Unlock()
+	s.matters = store
+}
+
 // GetSession retrieves or creates a workflow state for the given session ID
 func (s *PersistentSessionService) GetSession(sessionID string) *WorkflowState {
 	sessionData := s.loadSessionData(sessionID)
@@ -110,6 +127,9 @@ func (s *PersistentSessionService) UpdateSession(sessionID string, updateFunc fu
 
 // DeleteSession removes a session file
 func (s *PersistentSessionService) DeleteSession(sessionID string) {
+	if !sessionIDPattern.MatchString(sessionID) {
+		return
+	}
 	s.FileLock.Lock()
 	defer s.FileLock.Unlock()
 	
@@ -119,6 +139,92 @@ func (s *PersistentSessionService) DeleteSession(sessionID string) {
 	}
 }
 
+// DeleteUserSessions removes every login session belonging to a user and
+// returns how many were removed
+func (s *PersistentSessionService) DeleteUserSessions(username string) int {
+	if username == "" {
+		return 0
+	}
+	s.FileLock.Lock()
+	defer s.FileLock.Unlock()
+	
+	files, err := ioutil.ReadDir(s.SessionDir)
+	if err != nil {
+		log.Printf("[WARN] Failed to read session directory: %v", err)
+		return 0
+	}
+	
+	deleted := 0
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		sessionFile := filepath.Join(s.SessionDir, file.Name())
+		data, err := ioutil.ReadFile(sessionFile)
+		if err != nil {
+			continue
+		}
+		var sessionData SessionData
+		if err := json.Unmarshal(data, &sessionData); err != nil || sessionData.Username != username {
+			continue
+		}
+		if err := os.Remove(sessionFile); err != nil {
+			log.Printf("[WARN] Failed to delete session file %s: %v", sessionFile, err)
+			continue
+		}
+		deleted++
+	}
+	
+	log.Printf("[INFO] Removed %d sessions for user %s", deleted, username)
+	return deleted
+}
+
+// CreateAuthSession starts a session for a user who has just logged in and
+// returns its random token
+func (s *PersistentSessionService) CreateAuthSession(username string) (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %v", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	
+	now := time.Now()
+	sessionData := &SessionData{
+		SessionID: token,
+		Username:  username,
+		WorkflowState: &WorkflowState{
+			CurrentStep: 0,
+			LastUpdated: now,
+			Username:    username,
+		},
+		CreatedAt:    now,
+		LastAccessed: now,
+		ExpiresAt:    now.Add(s.ttl),
+	}
+	
+	s.FileLock.Lock()
+	defer s.FileLock.Unlock()
+	if err := s.writeSessionFileUnsafe(sessionData); err != nil {
+		return "", err
+	}
+	
+	log.Printf("[INFO] Created session for user %s", username)
+	return token, nil
+}
+
+// ValidateAuthSession returns the session for a login token, or nil when the
+// token is unknown, expired or was not created by a login
+func (s *PersistentSessionService) ValidateAuthSession(token string) *SessionData {
+	if token == "" {
+		return nil
+	}
+	sessionData := s.loadSessionData(token)
+	if sessionData == nil || sessionData.Username == "" {
+		return nil
+	}
+	return sessionData
+}
+
 // RestoreSession loads session data from disk (used by middleware)
 func (s *PersistentSessionService) RestoreSession(sessionID string) *SessionData {
 	return s.loadSessionData(sessionID)
@@ -169,6 +275,9 @@ func (s *PersistentSessionService) loadSessionData(sessionID string) *SessionDat
 }
 
 func (s *PersistentSessionService) loadSessionDataUnsafe(sessionID string) *SessionData {
+	if !sessionIDPattern.MatchString(sessionID) {
+		return nil
+	}
 	sessionFile := s.getSessionFilePath(sessionID)
 	
 	data, err := ioutil.ReadFile(sessionFile)
@@ -194,9 +303,54 @@ func (s *PersistentSessionService) loadSessionDataUnsafe(sessionID string) *Sess
 		return nil
 	}
 	
+	s.loadMatterStateUnsafe(sessionData.WorkflowState)
 	return &sessionData
 }
 
+// loadMatterStateUnsafe fills in the case data of a session that points at a matter
+func (s *PersistentSessionService) loadMatterStateUnsafe(state *WorkflowState) {
+	if s.matters == nil || state == nil || state.MatterID == "" || state.ResultsCleared {
+		return
+	}
+	// A session whose save failed or conflicted kept its own copy
+	if state.ProcessingResult != nil || state.ClientCase != nil {
+		return
+	}
+	matter, err := s.matters.GetMatter(state.MatterID)
+	if err != nil {
+		log.Printf("[WARN] Failed to load matter %s for session: %v", state.MatterID, err)
+		return
+	}
+	state.ProcessingResult = matter.ProcessingResult
+	state.ClientCase = matter.ClientCase
+	state.MatterRevision = matter.Revision
+}
+
+// storeMatterStateUnsafe writes the case data of a session that points at a
+// matter to the matter store and returns the state to write to the session
+// file, which then only holds the pointer. Cleared results are never written
+// over the matter, and a save over another session's later changes keeps the
+// case data in the session instead.
+func (s *PersistentSessionService) storeMatterStateUnsafe(state *WorkflowState) *WorkflowState {
+	if s.matters == nil || state == nil || state.MatterID == "" || state.ClientCase == nil {
+		return state
+	}
+	revision, err := s.matters.SaveWorkingState(state.MatterID, state.MatterRevision, state.ProcessingResult, state.ClientCase)
+	if errors.Is(err, ErrMatterConflict) {
+		log.Printf("[WARN] Matter %s was changed by another session since it was loaded, keeping case data in the session", state.MatterID)
+		return state
+	}
+	if err != nil {
+		log.Printf("[WARN] Failed to save matter %s, keeping case data in the session: %v", state.MatterID, err)
+		return state
+	}
+	state.MatterRevision = revision
+	pointer := *state
+	pointer.ProcessingResult = nil
+	pointer.ClientCase = nil
+	return &pointer
+}
+
 func (s *PersistentSessionService) saveSessionData(sessionID string, state *WorkflowState) {
 	s.FileLock.Lock()
 	defer s.FileLock.Unlock()
@@ -213,6 +367,12 @@ func (s *PersistentSessionService) saveSessionDataUnsafe(sessionID string, state
 		ExpiresAt:     now.Add(s.ttl),
 	}
 	
+	// Keep the owner of a login session when its workflow state is replaced
+	if existing := s.loadSessionDataUnsafe(sessionID); existing != nil {
+		sessionData.Username = existing.Username
+		sessionData.CreatedAt = existing.CreatedAt
+	}
+	
 	s.saveSessionDataStructUnsafe(sessionData)
 }
 
@@ -223,6 +383,10 @@ func (s *PersistentSessionService) saveSessionDataStruct(sessionData *SessionDat
 }
 
 func (s *PersistentSessionService) saveSessionDataStructUnsafe(sessionData *SessionData) {
+	if !sessionIDPattern.MatchString(sessionData.SessionID) {
+		log.Printf("[ERROR] Refusing to save session with invalid ID")
+		return
+	}
 	sessionFile := s.getSessionFilePath(sessionData.SessionID)
 	
 	// Create backup of existing file before overwriting
@@ -230,26 +394,40 @@ func (s *PersistentSessionService) saveSessionDataStructUnsafe(sessionData *Sess
 		s.createBackupUnsafe(sessionData.SessionID)
 	}
 	
+	if err := s.writeSessionFileUnsafe(sessionData); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
+}
+
+func (s *PersistentSessionService) writeSessionFileUnsafe(sessionData *SessionData) error {
+	sessionFile := s.getSessionFilePath(sessionData.SessionID)
+	
+	// Case data that belongs to a matter is kept in the matter store
+	if state := s.storeMatterStateUnsafe(sessionData.WorkflowState); state != sessionData.WorkflowState {
+		pointerData := *sessionData
+		pointerData.WorkflowState = state
+		sessionData = &pointerData
+	}
+	
 	// Marshal session data
 	data, err := json.MarshalIndent(sessionData, "", "  ")
 	if err != nil {
-		log.Printf("[ERROR] Failed to marshal session data for %s: %v", sessionData.SessionID, err)
-		return
+		return fmt.Errorf("failed to marshal session data for %s: %v", sessionData.SessionID, err)
 	}
 	
-	// Write to temporary file first (atomic operation)
+	// Write to temporary file first (atomic operation); session files hold
+	// login tokens so they are only readable by the server user
 	tempFile := sessionFile + ".tmp"
-	if err := ioutil.WriteFile(tempFile, data, 0644); err != nil {
-		log.Printf("[ERROR] Failed to write temporary session file %s: %v", tempFile, err)
-		return
+	if err := ioutil.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write temporary session file %s: %v", tempFile, err)
 	}
 	
 	// Rename to final location (atomic operation on most filesystems)
 	if err := os.Rename(tempFile, sessionFile); err != nil {
-		log.Printf("[ERROR] Failed to rename session file %s: %v", sessionFile, err)
 		os.Remove(tempFile) // Clean up temp file
-		return
+		return fmt.Errorf("failed to rename session file %s: %v", sessionFile, err)
 	}
+	return nil
 }
 
 func (s *PersistentSessionService) createBackupUnsafe(sessionID string) {
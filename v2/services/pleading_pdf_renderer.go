@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PleadingPDFRenderer renders legal documents onto federal pleading paper
+type PleadingPDFRenderer struct {
+	Style        DocumentStyle
+	LinesPerPage int
+}
+
+// PleadingCaption holds the caption block printed at the top of the first page
+type PleadingCaption struct {
+	CourtLines []string `json:"courtLines"`
+	Plaintiff  string   `json:"plaintiff"`
+	Defendants []string `json:"defendants"`
+	CaseNumber string   `json:"caseNumber"`
+	Title      string   `json:"title"`
+}
+
+// pleadingBlockKind identifies how a block of body text is laid out
+type pleadingBlockKind int
+
+const (
+	pleadingParagraph pleadingBlockKind = iota
+	pleadingHeading
+	pleadingSignature
+)
+
+// pleadingBlock is a single block of body text
+type pleadingBlock struct {
+	Kind pleadingBlockKind
+	Text string
+}
+
+// pleadingPage tracks the write position on the numbered line grid
+type pleadingPage struct {
+	pdf        *fpdf.Fpdf
+	translate  func(string) string
+	line       int
+	left       float64
+	width      float64
+	top        float64
+	lineHeight float64
+}
+
+const pointsPerInch = 72.0
+
+var (
+	captionCaseNumberPattern = regexp.MustCompile(`(?i)(?:case|civil action)\s+no\.?\s*(.*)$`)
+	numberedParagraphPattern = regexp.MustCompile(`^\d+\.\s`)
+)
+
+// NewPleadingPDFRenderer creates a pleading paper renderer for the given style
+func NewPleadingPDFRenderer(style DocumentStyle) *PleadingPDFRenderer {
+	renderer := &PleadingPDFRenderer{
+		Style: style,
+	}
+
+	// Number every line that fits between the top and bottom margins
+	lineHeight := float64(style.FontSize) * style.LineSpacing
+	bodyHeight := (11.0 - style.Margins.Top - style.Margins.Bottom) * pointsPerInch
+	renderer.LinesPerPage = int(bodyHeight / lineHeight)
+
+	return renderer
+}
+
+// Render produces a PDF with an optional caption followed by the body blocks
+func (r *PleadingPDFRenderer) Render(title string, caption *PleadingCaption, blocks []pleadingBlock) ([]byte, error) {
+	pdf := fpdf.New("P", "pt", r.Style.PageSize, "")
+	pdf.SetMargins(r.Style.Margins.Left*pointsPerInch, r.Style.Margins.Top*pointsPerInch, r.Style.Margins.Right*pointsPerInch)
+	pdf.SetAutoPageBreak(false, r.Style.Margins.Bottom*pointsPerInch)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Mallon Legal Assistant", true)
+	pdf.AliasNbPages("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	page := &pleadingPage{
+		pdf:        pdf,
+		translate:  pdf.UnicodeTranslatorFromDescriptor(""),
+		left:       r.Style.Margins.Left * pointsPerInch,
+		width:      pageWidth - (r.Style.Margins.Left+r.Style.Margins.Right)*pointsPerInch,
+		top:        r.Style.Margins.Top * pointsPerInch,
+		lineHeight: float64(r.Style.FontSize) * r.Style.LineSpacing,
+	}
+
+	fontFamily := r.pdfFontFamily()
+	fontSize := float64(r.Style.FontSize)
+
+	// Line numbers and rules are drawn on every page before the body text
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont(fontFamily, "", fontSize)
+		pdf.SetLineWidth(0.5)
+		ruleX := page.left - 6
+		pdf.Line(ruleX, 0, ruleX, pageHeight)
+		pdf.Line(ruleX-2, 0, ruleX-2, pageHeight)
+		pdf.Line(page.left+page.width+6, 0, page.left+page.width+6, pageHeight)
+		for i := 0; i < r.LinesPerPage; i++ {
+			number := fmt.Sprintf("%d", i+1)
+			pdf.Text(ruleX-10-pdf.GetStringWidth(number), page.baseline(i), number)
+		}
+	})
+
+	// Page numbers are centered in the bottom margin
+	pdf.SetFooterFunc(func() {
+		pdf.SetFont(fontFamily, "", fontSize-2)
+		footer := fmt.Sprintf("Page %d of {nb}", pdf.PageNo())
+		pdf.Text(page.left+(page.width-pdf.GetStringWidth(footer))/2, pageHeight-r.Style.Margins.Bottom*pointsPerInch/2, footer)
+	})
+
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "", fontSize)
+
+	if caption != nil {
+		r.renderCaption(page, caption, fontFamily, fontSize)
+	}
+
+	for _, block := range blocks {
+		switch block.Kind {
+		case pleadingHeading:
+			if page.line > 0 {
+				page.skip(1, r.LinesPerPage)
+			}
+			// Keep a heading on the same page as the text that follows it
+			if page.line >= r.LinesPerPage-2 {
+				page.line = r.LinesPerPage
+			}
+			pdf.SetFont(fontFamily, "B", fontSize)
+			for _, line := range strings.Split(block.Text, "\n") {
+				for _, wrapped := range page.wrap(strings.TrimSpace(line), page.width, page.width) {
+					page.writeCentered(wrapped, r.LinesPerPage)
+				}
+			}
+			pdf.SetFont(fontFamily, "", fontSize)
+
+		case pleadingSignature:
+			page.skip(1, r.LinesPerPage)
+			column := page.width / 2
+			for _, line := range strings.Split(block.Text, "\n") {
+				for _, wrapped := range page.wrap(strings.TrimSpace(line), column, column) {
+					page.writeAt(wrapped, column, r.LinesPerPage)
+				}
+			}
+
+		default:
+			for _, line := range strings.Split(block.Text, "\n") {
+				trimmed := strings.TrimLeft(line, " \t")
+				if trimmed == "" {
+					continue
+				}
+
+				// Numbered paragraphs get a half-inch first line indent; other
+				// lines keep whatever indentation the template gave them
+				indent := float64(len(line)-len(trimmed)) * 3
+				firstIndent := indent
+				if numberedParagraphPattern.MatchString(trimmed) {
+					firstIndent = 0.5 * pointsPerInch
+				}
+
+				wrapped := page.wrap(trimmed, page.width-firstIndent, page.width-indent)
+				for i, text := range wrapped {
+					if i == 0 {
+						page.writeAt(text, firstIndent, r.LinesPerPage)
+					} else {
+						page.writeAt(text, indent, r.LinesPerPage)
+					}
+				}
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %v", err)
+	}
+
+	log.Printf("[PDF_RENDERER] Rendered %q: %d pages, %d bytes", title, pdf.PageNo(), buffer.Len())
+	return buffer.Bytes(), nil
+}
+
+// renderCaption writes the court name and the parties/case number caption table
+func (r *PleadingPDFRenderer) renderCaption(page *pleadingPage, caption *PleadingCaption, fontFamily string, fontSize float64) {
+	pdf := page.pdf
+
+	pdf.SetFont(fontFamily, "B", fontSize)
+	for _, courtLine := range caption.CourtLines {
+		for _, wrapped := range page.wrap(strings.ToUpper(courtLine), page.width, page.width) {
+			page.writeCentered(wrapped, r.LinesPerPage)
+		}
+	}
+	pdf.SetFont(fontFamily, "", fontSize)
+	page.skip(1, r.LinesPerPage)
+
+	// Left column lists the parties, right column the case number and title
+	leftWidth := page.width * 0.55
+	rightX := leftWidth + 18
+	rightWidth := page.width - rightX
+
+	var left []string
+	left = append(left, page.wrap(strings.ToUpper(caption.Plaintiff)+",", leftWidth, leftWidth)...)
+	left = append(left, "                    Plaintiff,", "", "v.", "")
+	for _, defendant := range caption.Defendants {
+		name := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(defendant)), ",") + ","
+		left = append(left, page.wrap(name, leftWidth, leftWidth)...)
+	}
+	left = append(left, "                    Defendants.")
+
+	caseNumber := caption.CaseNumber
+	if caseNumber == "" {
+		caseNumber = "____________"
+	}
+	right := []string{"", "", "", page.translate("Case No. " + caseNumber), ""}
+	right = append(right, page.wrap(strings.ToUpper(caption.Title), rightWidth, rightWidth)...)
+
+	rows := len(left)
+	if len(right) > rows {
+		rows = len(right)
+	}
+
+	for i := 0; i < rows; i++ {
+		page.ensureLine(r.LinesPerPage)
+		baseline := page.baseline(page.line)
+		if i < len(left) && left[i] != "" {
+			pdf.Text(page.left, baseline, left[i])
+		}
+		pdf.Text(page.left+leftWidth+4, baseline, ")")
+		if i < len(right) && right[i] != "" {
+			pdf.Text(page.left+rightX, baseline, right[i])
+		}
+		page.line++
+	}
+
+	// Close the caption with a rule under the parties column
+	pdf.SetLineWidth(0.5)
+	ruleY := page.baseline(page.line-1) + 4
+	pdf.Line(page.left, ruleY, page.left+leftWidth, ruleY)
+	page.skip(1, r.LinesPerPage)
+}
+
+// pdfFontFamily maps the configured font to a PDF core font
+func (r *PleadingPDFRenderer) pdfFontFamily() string {
+	switch strings.ToLower(r.Style.FontFamily) {
+	case "arial", "helvetica":
+		return "Helvetica"
+	case "courier", "courier new":
+		return "Courier"
+	default:
+		return "Times"
+	}
+}
+
+// baseline returns the text baseline for a numbered line on the page
+func (p *pleadingPage) baseline(line int) float64 {
+	return p.top + float64(line)*p.lineHeight + p.lineHeight*0.7
+}
+
+// ensureLine starts a new page when the current one is full
+func (p *pleadingPage) ensureLine(linesPerPage int) {
+	if p.line >= linesPerPage {
+		p.pdf.AddPage()
+		p.line = 0
+	}
+}
+
+// skip advances past blank lines without carrying them onto a new page
+func (p *pleadingPage) skip(lines int, linesPerPage int) {
+	for i := 0; i < lines && p.line < linesPerPage; i++ {
+		p.line++
+	}
+}
+
+// writeAt writes already translated text on the current line at the given offset from the left margin
+func (p *pleadingPage) writeAt(text string, offset float64, linesPerPage int) {
+	p.ensureLine(linesPerPage)
+	p.pdf.Text(p.left+offset, p.baseline(p.line), text)
+	p.line++
+}
+
+// writeCentered writes already translated text centered on the current line
+func (p *pleadingPage) writeCentered(text string, linesPerPage int) {
+	p.ensureLine(linesPerPage)
+	p.pdf.Text(p.left+(p.width-p.pdf.GetStringWidth(text))/2, p.baseline(p.line), text)
+	p.line++
+}
+
+// wrap translates text to the PDF code page and breaks it into lines
+func (p *pleadingPage) wrap(text string, firstWidth, width float64) []string {
+	text = p.translate(text)
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := words[0]
+	limit := firstWidth
+	for _, word := range words[1:] {
+		candidate := current + " " + word
+		if p.pdf.GetStringWidth(candidate) > limit {
+			lines = append(lines, current)
+			current = word
+			limit = width
+			continue
+		}
+		current = candidate
+	}
+	lines = append(lines, current)
+
+	return lines
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages of the document processing pipeline. Extract, classify and analyze
+// run per document on a worker pool; correlate runs once over every result.
+const (
+	StageQueued    = "queued"
+	StageExtract   = "extract"
+	StageClassify  = "classify"
+	StageAnalyze   = "analyze"
+	StageCorrelate = "correlate"
+)
+
+// Outcome of a pipeline stage
+const (
+	ProcessingRunning   = "running"
+	ProcessingDone      = "done"
+	ProcessingFailed    = "failed"
+	ProcessingTimedOut  = "timed_out"
+	ProcessingCancelled = "cancelled"
+)
+
+const (
+	defaultProcessingWorkers = 4
+	defaultDocumentTimeout   = 2 * time.Minute
+)
+
+// ProcessingOptions tunes a pipeline run. Zero values use the defaults.
+type ProcessingOptions struct {
+	Workers         int
+	DocumentTimeout time.Duration
+
+	// Progress is told about every stage of every document. Calls are
+	// serialized, so it doesn't need its own locking.
+	Progress func(ProcessingEvent)
+}
+
+// DefaultProcessingOptions returns the pipeline settings, which can be
+// overridden with MALLON_PROCESSING_WORKERS and MALLON_DOCUMENT_TIMEOUT (e.g. "90s")
+func DefaultProcessingOptions() ProcessingOptions {
+	options := ProcessingOptions{Workers: defaultProcessingWorkers, DocumentTimeout: defaultDocumentTimeout}
+	if value := os.Getenv("MALLON_PROCESSING_WORKERS"); value != "" {
+		if workers, err := strconv.Atoi(value); err == nil && workers > 0 {
+			options.Workers = workers
+		} else {
+			log.Printf("[DOCUMENT_SERVICE] Warning: ignoring invalid MALLON_PROCESSING_WORKERS %q", value)
+		}
+	}
+	if value := os.Getenv("MALLON_DOCUMENT_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout > 0 {
+			options.DocumentTimeout = timeout
+		} else {
+			log.Printf("[DOCUMENT_SERVICE] Warning: ignoring invalid MALLON_DOCUMENT_TIMEOUT %q", value)
+		}
+	}
+	return options
+}
+
+// ProcessingEvent reports one document moving through the pipeline. The
+// correlate stage covers the whole case and has an Index of -1.
+type ProcessingEvent struct {
+	Index       int           `json:"index"`
+	Total       int           `json:"total"`
+	Document    string        `json:"document"`
+	Path        string        `json:"path,omitempty"`
+	Stage       string        `json:"stage"`
+	Status      string        `json:"status"`
+	ContentType string        `json:"contentType,omitempty"`
+	Confidence  float64       `json:"confidence,omitempty"`
+	Violations  int           `json:"violations,omitempty"`
+	CacheStatus string        `json:"cacheStatus,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Elapsed     time.Duration `json:"elapsed"`
+	At          time.Time     `json:"at"`
+}
+
+// StageLabel describes the stage for people
+func (e ProcessingEvent) StageLabel() string {
+	switch e.Stage {
+	case StageExtract:
+		return "Extracting text"
+	case StageClassify:
+		return "Classifying"
+	case StageAnalyze:
+		return "Analyzing"
+	case StageCorrelate:
+		return "Correlating documents"
+	default:
+		return "Waiting"
+	}
+}
+
+// ElapsedLabel is the time the document has spent in the pipeline, e.g. "1.4s"
+func (e ProcessingEvent) ElapsedLabel() string {
+	return fmt.Sprintf("%.1fs", e.Elapsed.Seconds())
+}
+
+// Finished reports whether the document has left the pipeline
+func (e ProcessingEvent) Finished() bool {
+	return e.Status != ProcessingRunning
+}
+
+// processedDocument is what the per-document stages hand to the correlate stage
+type processedDocument struct {
+	document   Document
+	extracted  bool
+	analysis   *LegalAnalysisResult
+	coverSheet *CivilCoverSheet
+}
+
+// ProcessSelectedDocumentsContext processes selected documents on a pool of
+// workers. Each document gets DocumentTimeout to get through extraction,
+// classification and analysis; one that fails or runs out of time is left out,
+// as before. Cancelling ctx stops the whole run and returns its error.
+func (s *DocumentService) ProcessSelectedDocumentsContext(ctx context.Context, source DocumentSource, selectedDocIDs []string, templateID string, options ProcessingOptions) (*DocumentProcessingResult, *ClientCase, error) {
+	if s.contentAnalyzer == nil {
+		return nil, nil, fmt.Errorf("content analyzer not initialized")
+	}
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultProcessingWorkers
+	}
+	if workers > len(selectedDocIDs) {
+		workers = len(selectedDocIDs)
+	}
+	timeout := options.DocumentTimeout
+	if timeout <= 0 {
+		timeout = defaultDocumentTimeout
+	}
+	log.Printf("[DOCUMENT_SERVICE] Processing %d selected documents with %d workers, %s per document", len(selectedDocIDs), workers, timeout)
+
+	var progressMu sync.Mutex
+	report := func(event ProcessingEvent) {
+		if options.Progress == nil {
+			return
+		}
+		event.Total = len(selectedDocIDs)
+		event.At = time.Now()
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		options.Progress(event)
+	}
+
+	processed := make([]*processedDocument, len(selectedDocIDs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				processed[index] = s.processDocument(ctx, source, index, selectedDocIDs[index], timeout, report)
+			}
+		}()
+	}
+
+	for index, docPath := range selectedDocIDs {
+		report(ProcessingEvent{Index: index, Document: filepath.Base(docPath), Path: docPath, Stage: StageQueued, Status: ProcessingRunning})
+	}
+queue:
+	for index := range selectedDocIDs {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break queue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		log.Printf("[DOCUMENT_SERVICE] Processing cancelled: %v", err)
+		return nil, nil, err
+	}
+
+	started := time.Now()
+	report(ProcessingEvent{Index: -1, Stage: StageCorrelate, Status: ProcessingRunning})
+	processingResult, clientCase := s.correlateProcessedDocuments(processed)
+
+	// Check limitations per violation here, so generation leaves out
+	// time-barred counts whether or not Step 3 was shown
+	clientCase.SetLimitations(s.checkLimitations(processingResult, clientCase, selectedDocIDs))
+	report(ProcessingEvent{Index: -1, Stage: StageCorrelate, Status: ProcessingDone, Confidence: processingResult.DataCoverage, Elapsed: time.Since(started)})
+
+	return processingResult, clientCase, nil
+}
+
+// processDocument runs one document through extraction, classification and
+// analysis within its timeout
+func (s *DocumentService) processDocument(ctx context.Context, source DocumentSource, index int, docPath string, timeout time.Duration, report func(ProcessingEvent)) *processedDocument {
+	fileName := filepath.Base(docPath)
+	started := time.Now()
+	event := func(stage, status string) ProcessingEvent {
+		return ProcessingEvent{Index: index, Document: fileName, Path: docPath, Stage: stage, Status: status, Elapsed: time.Since(started)}
+	}
+	fail := func(stage string, err error) {
+		failed := event(stage, ProcessingFailed)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			failed.Status = ProcessingTimedOut
+			failed.Error = fmt.Sprintf("took longer than %s", timeout)
+		case errors.Is(err, context.Canceled):
+			failed.Status = ProcessingCancelled
+		default:
+			failed.Error = err.Error()
+		}
+		log.Printf("[DOCUMENT_SERVICE] %s failed at %s: %v", fileName, stage, err)
+		report(failed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result := &processedDocument{}
+
+	// Extract text from a local copy of the document, or take it from the
+	// cache when the same bytes were extracted before
+	report(event(StageExtract, ProcessingRunning))
+	var content *ExtractedContent
+	var contentHash string
+	contentCached := false
+	err := runStage(ctx, func() error {
+		var err error
+		content, contentHash, contentCached, err = s.extractDocument(ctx, source, docPath)
+		return err
+	})
+	if err != nil {
+		fail(StageExtract, err)
+		return result
+	}
+
+	size := int64(0)
+	if object, err := source.Stat(docPath); err == nil {
+		size = object.Size
+	}
+	result.extracted = true
+	result.document = Document{
+		Name:     fileName,
+		Type:     strings.ToLower(filepath.Ext(fileName)),
+		Path:     docPath,
+		Size:     size,
+		OCRPages: content.OCRPages(),
+	}
+
+	report(event(StageClassify, ProcessingRunning))
+	result.document.ContentType = s.classifyContentType(docPath, contentHash, content)
+
+	// Perform intelligent analysis of document content
+	analyzing := event(StageAnalyze, ProcessingRunning)
+	analyzing.ContentType = result.document.ContentType
+	report(analyzing)
+	var analyzed *documentAnalysis
+	analysisCached := false
+	err = runStage(ctx, func() error {
+		contentType := result.document.ContentType
+		var analysis *documentAnalysis
+		ok := false
+		if contentHash != "" {
+			analysis, ok = s.cache.Analysis(contentHash, contentType)
+		}
+		if !ok {
+			var err error
+			if analysis, err = s.analyzeDocument(result.document, content); err != nil {
+				return err
+			}
+			if contentHash != "" {
+				s.cache.StoreAnalysis(contentHash, contentType, analysis)
+			}
+		}
+
+		// Sources are located after caching, since the same bytes can sit at another path
+		s.locateAnalysisSources(analysis.Analysis, docPath, content)
+		analyzed, analysisCached = analysis, ok
+		return nil
+	})
+	if err != nil {
+		fail(StageAnalyze, err)
+		return result
+	}
+	result.analysis, result.coverSheet = analyzed.Analysis, analyzed.CoverSheet
+	result.document.CacheStatus = ExtractionFresh
+	if contentCached && analysisCached {
+		result.document.CacheStatus = ExtractionCached
+	}
+
+	done := event(StageAnalyze, ProcessingDone)
+	done.ContentType = result.document.ContentType
+	done.Confidence = result.analysis.OverallConfidence
+	done.Violations = len(result.analysis.LegalViolations)
+	done.CacheStatus = result.document.CacheStatus
+	report(done)
+	log.Printf("[DOCUMENT_SERVICE] Analyzed %s (%s) in %s - %.1f%% confidence, %d violations found",
+		fileName, result.document.CacheStatus, time.Since(started).Round(time.Millisecond), result.analysis.OverallConfidence, len(result.analysis.LegalViolations))
+	return result
+}
+
+// extractDocument extracts the text of a document from a local copy, or takes
+// it from the cache when the same bytes were extracted before. The content
+// hash is empty when the cache is off or the file couldn't be hashed.
+func (s *DocumentService) extractDocument(ctx context.Context, source DocumentSource, docPath string) (*ExtractedContent, string, bool, error) {
+	localPath, err := source.LocalPath(docPath)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error locating %s in document source: %v", docPath, err)
+	}
+
+	contentHash := ""
+	if s.cache != nil {
+		if contentHash, err = HashFile(localPath); err != nil {
+			log.Printf("[DOCUMENT_SERVICE] Warning: not caching %s: %v", filepath.Base(docPath), err)
+		} else if cached, ok := s.cache.Content(contentHash); ok {
+			cached.SourceFile = localPath
+			return cached, contentHash, true, nil
+		}
+	}
+
+	content, err := s.extractor.ExtractTextContext(ctx, localPath)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error extracting text from %s: %v", docPath, err)
+	}
+	// Pages OCR couldn't read are retried next time, e.g. once tesseract is installed
+	if contentHash != "" && !content.HasUnreadPages() {
+		s.cache.StoreContent(contentHash, content)
+	}
+	return content, contentHash, false, nil
+}
+
+// ExtractDocument returns the extracted text, pages and word positions of a
+// document, from the extraction cache when it is unchanged
+func (s *DocumentService) ExtractDocument(ctx context.Context, source DocumentSource, docPath string) (*ExtractedContent, error) {
+	content, _, _, err := s.extractDocument(ctx, source, docPath)
+	return content, err
+}
+
+// documentAnalysis is the analysis of one document, with the civil cover
+// sheet mapping when the document is one. It is what the extraction cache keeps.
+type documentAnalysis struct {
+	Analysis   *LegalAnalysisResult `json:"analysis"`
+	CoverSheet *CivilCoverSheet     `json:"coverSheet,omitempty"`
+}
+
+// analyzeDocument runs the content analyzer and the specialized analyzers for
+// the document's type
+func (s *DocumentService) analyzeDocument(doc Document, content *ExtractedContent) (*documentAnalysis, error) {
+	analysis, err := s.contentAnalyzer.AnalyzeLegalContent(content.RawText, doc.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("error analyzing %s: %v", doc.Name, err)
+	}
+	result := &documentAnalysis{Analysis: analysis}
+
+	// Enhanced attorney notes analysis
+	if doc.ContentType == "attorney_notes" && s.attorneyNotesAnalyzer != nil {
+		log.Printf("[DOCUMENT_SERVICE] Performing specialized attorney notes analysis for %s", doc.Name)
+		attorneyAnalysis, err := s.attorneyNotesAnalyzer.AnalyzeAttorneyNotes(doc.Path, content.RawText)
+		if err != nil {
+			log.Printf("[DOCUMENT_SERVICE] Warning: Attorney notes analysis failed for %s: %v", doc.Name, err)
+		} else {
+			// Enhance the analysis with attorney intelligence
+			result.Analysis = s.enhanceAnalysisWithAttorneyIntelligence(analysis, attorneyAnalysis)
+			log.Printf("[DOCUMENT_SERVICE] Enhanced %s with attorney intelligence - %.1f%% confidence",
+				doc.Name, attorneyAnalysis.ConfidenceScores.OverallConfidence)
+		}
+	}
+
+	// Civil cover sheet specialized analysis
+	if doc.ContentType == "civil_cover_sheet" && s.civilCoverSheetAnalyzer != nil {
+		log.Printf("[DOCUMENT_SERVICE] Performing civil cover sheet legal mapping for %s", doc.Name)
+		coverSheetAnalysis, err := s.civilCoverSheetAnalyzer.AnalyzeCivilCoverSheet(doc.Path, content.RawText)
+		if err != nil {
+			log.Printf("[DOCUMENT_SERVICE] Warning: Civil cover sheet analysis failed for %s: %v", doc.Name, err)
+		} else {
+			result.CoverSheet = coverSheetAnalysis
+			log.Printf("[DOCUMENT_SERVICE] Civil cover sheet analysis complete - %.1f%% confidence, nature of suit: %s",
+				coverSheetAnalysis.AnalysisMetadata.ConfidenceScore, coverSheetAnalysis.NatureOfSuit.PrimaryCode)
+		}
+	}
+
+	return result, nil
+}
+
+// correlateProcessedDocuments merges the per-document results, in selection
+// order, into the case
+func (s *DocumentService) correlateProcessedDocuments(processed []*processedDocument) (*DocumentProcessingResult, *ClientCase) {
+	selectedDocs := []Document{}
+	extractedData := make(map[string]interface{})
+	allAnalysisResults := make(map[string]*LegalAnalysisResult)
+	documentTypes := make(map[string]bool)
+	clientCase := ClientCase{}
+
+	for _, result := range processed {
+		if result == nil || !result.extracted {
+			continue
+		}
+		doc := result.document
+		doc.ID = fmt.Sprintf("doc_%d", len(selectedDocs)+1)
+		selectedDocs = append(selectedDocs, doc)
+		documentTypes[doc.ContentType] = true
+
+		if result.coverSheet != nil {
+			// Store civil cover sheet analysis for legal framework enhancement
+			extractedData["civil_cover_sheet_analysis"] = result.coverSheet
+		}
+		if result.analysis != nil {
+			allAnalysisResults[doc.Name] = result.analysis
+		}
+	}
+
+	// Correlate and merge analysis results into ClientCase
+	s.correlateAnalysisResults(allAnalysisResults, &clientCase, extractedData)
+
+	// Analyze missing content based on intelligent analysis
+	missingContent := s.analyzeIntelligentMissingContent(&clientCase, documentTypes, allAnalysisResults)
+
+	// Calculate data coverage based on populated fields
+	dataCoverage := s.calculateIntelligentDataCoverage(&clientCase, allAnalysisResults)
+
+	log.Printf("[DOCUMENT_SERVICE] DYNAMIC extraction complete - %d documents processed, %.1f%% data coverage",
+		len(selectedDocs), dataCoverage)
+
+	return &DocumentProcessingResult{
+		SelectedDocuments: selectedDocs,
+		ExtractedData:     extractedData,
+		MissingContent:    missingContent,
+		DataCoverage:      dataCoverage,
+	}, &clientCase
+}
+
+// runStage runs a stage that can't be interrupted, returning early when ctx is
+// done. The stage finishes in the background and its result is dropped.
+func runStage(ctx context.Context, stage func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- stage() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3CaseStore keeps cases in an S3-compatible bucket under <prefix>/<tenant>/.
+// Requests are signed with AWS Signature Version 4, which MinIO, Ceph, R2 and
+// the other S3-compatible services accept.
+type S3CaseStore struct {
+	config   S3CaseStoreConfig
+	tenant   string
+	root     string
+	endpoint *url.URL
+	client   *http.Client
+	registry *caseRegistry
+}
+
+// s3ListResult is the ListObjectsV2 response body
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// NewS3CaseStore creates an S3-compatible case store
+func NewS3CaseStore(config S3CaseStoreConfig, tenant string) (*S3CaseStore, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials are required (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	store := &S3CaseStore{
+		config:   config,
+		tenant:   tenant,
+		root:     strings.Trim(path.Join(cleanStorePath(config.Prefix), tenant), "/"),
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+	store.registry = &caseRegistry{tenant: tenant, read: store.Read, write: store.Write}
+	return store, nil
+}
+
+// Backend names the storage backend
+func (s *S3CaseStore) Backend() string { return "s3" }
+
+// Tenant returns the tenant this store serves
+func (s *S3CaseStore) Tenant() string { return s.tenant }
+
+// List returns the visible files and folders directly inside a folder
+func (s *S3CaseStore) List(folder string) ([]StoredObject, error) {
+	folder = cleanStorePath(folder)
+	prefix := s.key(folder) + "/"
+
+	var objects []StoredObject
+	continuation := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		query.Set("delimiter", "/")
+		if continuation != "" {
+			query.Set("continuation-token", continuation)
+		}
+
+		body, _, err := s.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list folder %s: %v", folder, err)
+		}
+
+		var result s3ListResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse listing for %s: %v", folder, err)
+		}
+
+		for _, common := range result.CommonPrefixes {
+			name := path.Base(strings.TrimSuffix(common.Prefix, "/"))
+			if strings.HasPrefix(name, ".") {
+				continue
+			}
+			objects = append(objects, StoredObject{
+				Name:        name,
+				Path:        path.Join(folder, name),
+				IsDirectory: true,
+			})
+		}
+		for _, content := range result.Contents {
+			name := strings.TrimPrefix(content.Key, prefix)
+			// Zero-byte "folder/" markers and hidden files are not documents
+			if name == "" || strings.HasPrefix(name, ".") {
+				continue
+			}
+			objects = append(objects, StoredObject{
+				Name:     name,
+				Path:     path.Join(folder, name),
+				Size:     content.Size,
+				Modified: content.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuation = result.NextContinuationToken
+	}
+
+	if len(objects) == 0 && folder != "" {
+		// S3 has no empty folders, so an empty listing means the folder is missing
+		return nil, fmt.Errorf("folder %s: %w", folder, ErrCaseObjectNotFound)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+	return objects, nil
+}
+
+// Stat describes a single file or folder
+func (s *S3CaseStore) Stat(objectPath string) (*StoredObject, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	_, header, err := s.do(http.MethodHead, s.key(objectPath), nil, nil)
+	if err == nil {
+		size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		modified, _ := http.ParseTime(header.Get("Last-Modified"))
+		return &StoredObject{
+			Name:     path.Base(objectPath),
+			Path:     objectPath,
+			Size:     size,
+			Modified: modified,
+		}, nil
+	}
+	if !isS3NotFound(err) {
+		return nil, fmt.Errorf("failed to stat %s: %v", objectPath, err)
+	}
+
+	// Not an object; it may still be a folder prefix
+	if _, err := s.List(objectPath); err != nil {
+		return nil, err
+	}
+	return &StoredObject{
+		Name:        path.Base(objectPath),
+		Path:        objectPath,
+		IsDirectory: true,
+	}, nil
+}
+
+// Read returns the contents of a file
+func (s *S3CaseStore) Read(objectPath string) ([]byte, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	body, _, err := s.do(http.MethodGet, s.key(objectPath), nil, nil)
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", objectPath, err)
+	}
+	return body, nil
+}
+
+// Write uploads a file, replacing any existing object
+func (s *S3CaseStore) Write(objectPath string, data []byte) error {
+	objectPath = cleanStorePath(objectPath)
+
+	if _, _, err := s.do(http.MethodPut, s.key(objectPath), nil, data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", objectPath, err)
+	}
+	return nil
+}
+
+// LocalPath downloads the object into the cache directory
+func (s *S3CaseStore) LocalPath(objectPath string) (string, error) {
+	object, err := s.Stat(objectPath)
+	if err != nil {
+		return "", err
+	}
+	return cacheSourceObject(filepath.Join(s.config.CacheDir, s.tenant), object, s.Read)
+}
+
+// EnsureCase returns the case for a folder, registering it on first use
+func (s *S3CaseStore) EnsureCase(provider, folder string) (*CaseMetadata, error) {
+	if provider == "" || provider == ProviderCaseStore {
+		if _, err := s.Stat(folder); err != nil {
+			return nil, err
+		}
+	}
+	return s.registry.ensure(provider, folder)
+}
+
+// GetCase looks up a case by ID
+func (s *S3CaseStore) GetCase(caseID string) (*CaseMetadata, error) {
+	return s.registry.get(caseID)
+}
+
+// ListCases returns every registered case for the tenant
+func (s *S3CaseStore) ListCases() ([]CaseMetadata, error) {
+	return s.registry.list()
+}
+
+// UpdateCase saves changes to a case's metadata
+func (s *S3CaseStore) UpdateCase(metadata CaseMetadata) error {
+	return s.registry.update(metadata)
+}
+
+// key maps a clean store path to an object key under the tenant root
+func (s *S3CaseStore) key(objectPath string) string {
+	return strings.Trim(path.Join(s.root, objectPath), "/")
+}
+
+// s3Error is a non-2xx response from the bucket
+type s3Error struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *s3Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("s3 returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3 returned %d: %s", e.StatusCode, e.Body)
+}
+
+func isS3NotFound(err error) bool {
+	s3Err, ok := err.(*s3Error)
+	return ok && s3Err.StatusCode == http.StatusNotFound
+}
+
+// do sends a signed request for an object key (or the bucket when key is empty)
+func (s *S3CaseStore) do(method, key string, query url.Values, body []byte) ([]byte, http.Header, error) {
+	requestURL := *s.endpoint
+	objectPath := "/" + key
+	if s.config.UsePathStyle {
+		objectPath = "/" + s.config.Bucket + objectPath
+	} else {
+		requestURL.Host = s.config.Bucket + "." + requestURL.Host
+	}
+	requestURL.Path = objectPath
+	requestURL.RawPath = s3EscapePath(objectPath)
+	requestURL.RawQuery = s3CanonicalQuery(query)
+
+	request, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	s.sign(request, requestURL.RawPath, body)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, nil, &s3Error{StatusCode: response.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	return data, response.Header, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request
+func (s *S3CaseStore) sign(request *http.Request, canonicalPath string, body []byte) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(body)
+	payload := hex.EncodeToString(payloadHash[:])
+
+	request.Header.Set("Host", request.URL.Host)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payload)
+	if s.config.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	headerNames := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if s.config.SessionToken != "" {
+		headerNames = append(headerNames, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		value := request.Header.Get(name)
+		if name == "host" {
+			value = request.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalPath,
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := shortDate + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := s3HMAC([]byte("AWS4"+s.config.SecretAccessKey), shortDate)
+	signingKey = s3HMAC(signingKey, s.config.Region)
+	signingKey = s3HMAC(signingKey, "s3")
+	signingKey = s3HMAC(signingKey, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything except the unreserved characters, as SigV4 requires
+func s3Escape(value string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+func s3EscapePath(objectPath string) string {
+	segments := strings.Split(objectPath, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
@@ -11,6 +11,7 @@ type ServiceValidator struct {
 	ServiceRules      ServiceRuleDatabase        `json:"serviceRules"`
 	JurisdictionRules map[string]JurisdictionServiceRules `json:"jurisdictionRules"`
 	ComplianceChecks  ServiceComplianceChecks    `json:"complianceChecks"`
+	Deadlines         *DeadlineCalculator        `json:"-"`
 }
 
 type ServiceRuleDatabase struct {
@@ -317,7 +318,7 @@ type RegulatoryRisk struct {
 }
 
 func NewServiceValidator() *ServiceValidator {
-	sv := &ServiceValidator{}
+	sv := &ServiceValidator{Deadlines: NewDeadlineCalculator()}
 	sv.initializeServiceRules()
 	sv.initializeJurisdictionRules()
 	sv.initializeComplianceChecks()
@@ -574,16 +575,34 @@ func (sv *ServiceValidator) validateTimeRequirements(summons *SummonsDocument, c
 	var serviceDeadline time.Time
 	var responseDeadline time.Time
 
+	var serviceBasis, responseBasis string
+
 	if courtAnalysis.CourtType == "Federal" {
-		serviceDeadline = now.AddDate(0, 0, 90)  // 90 days for federal
-		responseDeadline = serviceDeadline.AddDate(0, 0, 21)  // 21 days to respond
+		filed := now
+		if date, err := time.Parse("01/02/2006", summons.CaseInformation.FilingDate); err == nil {
+			filed = date
+		}
+		service := sv.Deadlines.ServiceDeadline(filed)
+		serviceDeadline, serviceBasis = service.DeadlineDate, service.StatutoryBasis
+
+		// The answer period runs from actual service, or from the last day to serve
+		served := summons.ServiceDetails.ServiceDate
+		if served.IsZero() {
+			served = serviceDeadline
+		}
+		answer := sv.Deadlines.AnswerDeadline(served)
+		responseDeadline, responseBasis = answer.DeadlineDate, answer.StatutoryBasis
+		if days := summons.ResponseRequirements.ResponseDays; days > 0 && days != answerDays {
+			responseDeadline = sv.Deadlines.AddDays(served, days)
+			responseBasis = fmt.Sprintf("%d days stated in the summons; computed under %s", days, ruleTimeComputation)
+		}
 	} else {
 		serviceDeadline = now.AddDate(0, 0, 60)  // 60 days for state
 		responseDeadline = serviceDeadline.AddDate(0, 0, 30)  // 30 days to respond
-	}
-
-	if summons.ResponseRequirements.ResponseDays > 0 {
-		responseDeadline = serviceDeadline.AddDate(0, 0, summons.ResponseRequirements.ResponseDays)
+		if summons.ResponseRequirements.ResponseDays > 0 {
+			responseDeadline = serviceDeadline.AddDate(0, 0, summons.ResponseRequirements.ResponseDays)
+		}
+		serviceBasis, responseBasis = "State service rules", "State response rules"
 	}
 
 	timeline := ServiceTimeline{
@@ -607,13 +626,13 @@ func (sv *ServiceValidator) validateTimeRequirements(summons *SummonsDocument, c
 			{
 				DateType:    "Service Deadline",
 				Date:        serviceDeadline,
-				Description: "Final date for service of process",
+				Description: "Final date for service of process (" + serviceBasis + ")",
 				Importance:  "Critical",
 			},
 			{
 				DateType:    "Response Deadline",
 				Date:        responseDeadline,
-				Description: "Defendant's answer deadline",
+				Description: "Defendant's answer deadline (" + responseBasis + ")",
 				Importance:  "High",
 			},
 		},
@@ -11,8 +11,18 @@ type WorkflowState struct {
 	// Step 0: iCloud Setup
 	ICloudConnected      bool     `json:"icloudConnected"`
 	ICloudUsername       string   `json:"icloudUsername"`
+	DocumentProvider     string   `json:"documentProvider,omitempty"`
 	SelectedParentFolder string   `json:"selectedParentFolder"`
 	SelectedCaseFolder   string   `json:"selectedCaseFolder"`
+	CaseID               string   `json:"caseId,omitempty"`
+	
+	// MatterID points at the case's record in the matter store, which holds the
+	// processing result and ClientCase when the store is configured
+	MatterID             string   `json:"matterId,omitempty"`
+	
+	// MatterRevision is the matter revision the case data was loaded from, so
+	// a save over another session's later changes is refused
+	MatterRevision       int      `json:"matterRevision,omitempty"`
 	
 	// Step 1: Document Selection
 	AvailableDocuments   []ICloudDocument `json:"availableDocuments"`
@@ -25,12 +35,58 @@ type WorkflowState struct {
 	ProcessingResult     *DocumentProcessingResult `json:"processingResult,omitempty"`
 	ClientCase           *ClientCase               `json:"clientCase,omitempty"`
 	
+	// PendingFieldOverrides holds the attorney's field overrides while the
+	// documents are processed again, until the new ClientCase takes them
+	PendingFieldOverrides map[string]FieldOverride `json:"pendingFieldOverrides,omitempty"`
+	
+	// ResultsCleared is set while the documents are processed again, so the
+	// matter's previous results aren't loaded back into the session
+	ResultsCleared        bool                     `json:"resultsCleared,omitempty"`
+	
 	// Metadata
 	CurrentStep          int               `json:"currentStep"`
 	LastUpdated          time.Time         `json:"lastUpdated"`
 	Username             string            `json:"username"`
 }
 
+// ClearProcessingResults drops the results of the last processing run before
+// the documents are processed again, setting the attorney's field overrides
+// aside for the new ClientCase
+func (s *WorkflowState) ClearProcessingResults() {
+	if s.ClientCase != nil && len(s.ClientCase.FieldOverrides) > 0 {
+		s.PendingFieldOverrides = s.ClientCase.FieldOverrides
+	}
+	s.ProcessingResult = nil
+	s.ClientCase = nil
+	s.ResultsCleared = true
+}
+
+// SetProcessingResults stores the results of a processing run, carrying the
+// attorney's field overrides over to the new ClientCase
+func (s *WorkflowState) SetProcessingResults(result *DocumentProcessingResult, clientCase *ClientCase) {
+	if clientCase != nil {
+		if s.ClientCase != nil {
+			clientCase.CarryFieldOverrides(s.ClientCase.FieldOverrides)
+		}
+		clientCase.CarryFieldOverrides(s.PendingFieldOverrides)
+		s.PendingFieldOverrides = nil
+
+		// The run checked limitations with the extracted dates, so a carried
+		// override of one of them leaves generation to check again
+		for field := range clientCase.FieldOverrides {
+			if IsLimitationsDate(field) {
+				clientCase.Limitations = nil
+				break
+			}
+		}
+	}
+	s.ProcessingResult = result
+	s.ClientCase = clientCase
+	s.ResultsCleared = false
+	// The results of a new run replace the matter's, whoever changed it last
+	s.MatterRevision = 0
+}
+
 // SessionService manages user session state
 type SessionService struct {
 	sessions map[string]*WorkflowState
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -114,6 +115,7 @@ type SummonsParser struct {
 	CreditBureauPatterns map[string]CreditBureauPattern
 	CourtPatterns        CourtPatternSet
 	ViolationPatterns    ViolationPatternSet
+	Deadlines            *DeadlineCalculator
 }
 
 type CreditBureauPattern struct {
@@ -162,6 +164,7 @@ func NewSummonsParser() *SummonsParser {
 		CreditBureauPatterns: initCreditBureauPatterns(),
 		CourtPatterns:        initCourtPatterns(),
 		ViolationPatterns:    initViolationPatterns(),
+		Deadlines:            NewDeadlineCalculator(),
 	}
 }
 
@@ -411,27 +414,66 @@ func (sp *SummonsParser) extractResponseRequirements(text string, summons *Summo
 		`(\d+)\s+DAYS?\s+TO\s+ANSWER`,
 		`ANSWER\s+WITHIN\s+(\d+)\s+DAYS?`,
 		`RESPOND\s+WITHIN\s+(\d+)\s+DAYS?`,
+		`WITHIN\s+(\d+)\s+DAYS?\s+AFTER\s+SERVICE`,
 	}
 
 	for _, pattern := range responsePatterns {
 		re := regexp.MustCompile(pattern)
 		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
-			days := matches[1]
-			if days == "20" {
-				summons.ResponseRequirements.ResponseDays = 20
-			} else if days == "30" {
-				summons.ResponseRequirements.ResponseDays = 30
+			if days, err := strconv.Atoi(matches[1]); err == nil && days > 0 {
+				summons.ResponseRequirements.ResponseDays = days
 			}
 			break
 		}
 	}
 
+	waived := strings.Contains(text, "WAIVER OF SERVICE") || strings.Contains(text, "WAIVER OF THE SERVICE")
+	if summons.ResponseRequirements.ResponseDays == 0 && summons.CourtInformation.CourtType == "Federal" {
+		// Rule 12(a)(1)(A) sets the time when the summons does not state it
+		if waived {
+			summons.ResponseRequirements.ResponseDays = waiverAnswerDays
+		} else {
+			summons.ResponseRequirements.ResponseDays = answerDays
+		}
+	}
+
+	if serviceDate, ok := sp.extractServiceDate(text); ok {
+		summons.ServiceDetails.ServiceDate = serviceDate
+		summons.ServiceDetails.ServiceCompleted = true
+		if summons.ResponseRequirements.ResponseDays > 0 && !waived {
+			summons.ResponseRequirements.ResponseDeadline = sp.Deadlines.AddDays(serviceDate, summons.ResponseRequirements.ResponseDays)
+		}
+	}
+
 	if strings.Contains(text, "DEFAULT JUDGMENT") {
 		summons.ResponseRequirements.DefaultWarning = true
 		summons.ResponseRequirements.DefaultConsequences = "Default judgment may be entered against defendant"
 	}
 }
 
+// extractServiceDate reads the service date from the proof of service
+func (sp *SummonsParser) extractServiceDate(text string) (time.Time, bool) {
+	datePatterns := []string{
+		`DATE\s+OF\s+SERVICE\s*:?\s*([A-Z]+\s+\d{1,2},?\s+\d{4}|\d{1,2}/\d{1,2}/\d{4})`,
+		`SERVED\s+(?:THE\s+SUMMONS\s+)?(?:ON\s+[A-Z ,.]+?\s+)?ON\s+(?:\(DATE\)\s*)?([A-Z]+\s+\d{1,2},?\s+\d{4}|\d{1,2}/\d{1,2}/\d{4})`,
+	}
+	layouts := []string{"January 2, 2006", "January 2 2006", "Jan 2, 2006", "1/2/2006"}
+
+	for _, pattern := range datePatterns {
+		re := regexp.MustCompile(pattern)
+		matches := re.FindStringSubmatch(text)
+		if len(matches) < 2 {
+			continue
+		}
+		for _, layout := range layouts {
+			if date, err := time.Parse(layout, matches[1]); err == nil {
+				return date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
 func (sp *SummonsParser) extractLegalAllegations(text string, summons *SummonsDocument) {
 	allegations := []Allegation{}
 
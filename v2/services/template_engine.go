@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +18,7 @@ const (
 	SectionTypeFacts          SectionType = "facts"
 	SectionTypeDamages        SectionType = "damages"
 	SectionTypePrayer         SectionType = "prayer"
+	SectionTypeCustom         SectionType = "custom"
 )
 
 // TemplateEngine manages dynamic legal document generation
@@ -25,38 +27,47 @@ type TemplateEngine struct {
 	RuleEngine      *LegalRuleEngine
 	Formatter       *LegalDocumentFormatter
 	Validator       *DocumentValidator
+	TemplatesDir    string
+	LoadErrors      []TemplateLoadError
+	ReloadTicker    *time.Ticker
+	mu              sync.RWMutex
+	fileModTimes    map[string]time.Time
 }
 
 // DocumentTemplate represents a legal document template
 type DocumentTemplate struct {
-	ID              string            `json:"id"`
-	Name            string            `json:"name"`
-	Description     string            `json:"description"`
-	Sections        []TemplateSection `json:"sections"`
-	RequiredFields  []string          `json:"requiredFields"`
-	OptionalFields  []string          `json:"optionalFields"`
-	LegalRules      []LegalRule       `json:"legalRules"`
+	ID              string            `json:"id" yaml:"id"`
+	Name            string            `json:"name" yaml:"name"`
+	Version         string            `json:"version" yaml:"version"`
+	Description     string            `json:"description" yaml:"description"`
+	Sections        []TemplateSection `json:"sections" yaml:"sections"`
+	RequiredFields  []string          `json:"requiredFields" yaml:"requiredFields"`
+	OptionalFields  []string          `json:"optionalFields" yaml:"optionalFields"`
+	LegalRules      []LegalRule       `json:"legalRules" yaml:"legalRules"`
+	SourceFile      string            `json:"-" yaml:"-"`
+	Issues          []ValidationIssue `json:"-" yaml:"-"`
 }
 
 // TemplateSection represents a section within a document template
 type TemplateSection struct {
-	Name            string      `json:"name"`
-	Type            SectionType `json:"type"`
-	ConditionalLogic string     `json:"conditionalLogic"`
-	ContentTemplate string      `json:"contentTemplate"`
-	Required        bool        `json:"required"`
-	Order           int         `json:"order"`
+	Name            string      `json:"name" yaml:"name"`
+	Type            SectionType `json:"type" yaml:"type"`
+	ConditionalLogic string     `json:"conditionalLogic" yaml:"conditionalLogic"`
+	ContentTemplate string      `json:"contentTemplate" yaml:"contentTemplate"`
+	Required        bool        `json:"required" yaml:"required"`
+	Order           int         `json:"order" yaml:"order"`
+	condition       *ConditionExpression
 }
 
 // LegalRule defines legal requirements for document generation
 type LegalRule struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Statute         string   `json:"statute"`
-	Elements        []string `json:"elements"`
-	RequiredFacts   []string `json:"requiredFacts"`
-	Penalties       []string `json:"penalties"`
-	ApplicableWhen  string   `json:"applicableWhen"`
+	ID              string   `json:"id" yaml:"id"`
+	Name            string   `json:"name" yaml:"name"`
+	Statute         string   `json:"statute" yaml:"statute"`
+	Elements        []string `json:"elements" yaml:"elements"`
+	RequiredFacts   []string `json:"requiredFacts" yaml:"requiredFacts"`
+	Penalties       []string `json:"penalties" yaml:"penalties"`
+	ApplicableWhen  string   `json:"applicableWhen" yaml:"applicableWhen"`
 }
 
 // GeneratedDocument represents a dynamically generated legal document
@@ -73,7 +84,7 @@ type GeneratedSection struct {
 	Name            string      `json:"name"`
 	Type            SectionType `json:"type"`
 	Content         string      `json:"content"`
-	SourceFacts     []string    `json:"sourceFacts"`
+	SourceFacts     []SourceFact `json:"sourceFacts"`
 	Confidence      float64     `json:"confidence"`
 }
 
@@ -99,32 +110,45 @@ type ValidationIssue struct {
 // NewTemplateEngine creates a new template engine instance
 func NewTemplateEngine() *TemplateEngine {
 	engine := &TemplateEngine{
-		Templates:  make(map[string]*DocumentTemplate),
-		RuleEngine: NewLegalRuleEngine(),
-		Formatter:  NewLegalDocumentFormatter(),
-		Validator:  NewDocumentValidator(),
+		Templates:    make(map[string]*DocumentTemplate),
+		RuleEngine:   NewLegalRuleEngine(),
+		Formatter:    NewLegalDocumentFormatter(),
+		Validator:    NewDocumentValidator(),
+		TemplatesDir: defaultTemplatesDir,
+		fileModTimes: make(map[string]time.Time),
 	}
 	
-	// Load default templates
-	engine.loadDefaultTemplates()
+	// Load templates from disk and keep watching for edits
+	engine.LoadTemplates()
+	engine.ReloadTicker = time.NewTicker(templateReloadInterval)
+	go engine.watchTemplates()
 	
-	log.Printf("[TEMPLATE_ENGINE] Initialized with %d templates", len(engine.Templates))
+	log.Printf("[TEMPLATE_ENGINE] Initialized with %d templates from %s", len(engine.Templates), engine.TemplatesDir)
 	return engine
 }
 
 // GenerateDocument creates a legal document from extracted case data
 func (te *TemplateEngine) GenerateDocument(templateID string, clientCase *ClientCase) (*GeneratedDocument, error) {
-	template, exists := te.Templates[templateID]
+	template, exists := te.GetTemplate(templateID)
 	if !exists {
 		return nil, fmt.Errorf("template not found: %s", templateID)
 	}
 	
-	log.Printf("[TEMPLATE_ENGINE] Generating document using template: %s for client: %s", templateID, clientCase.ClientName)
+	// The attorney's corrections win over the extracted values
+	if len(clientCase.FieldOverrides) > 0 {
+		log.Printf("[TEMPLATE_ENGINE] Applying %d attorney field overrides", len(clientCase.FieldOverrides))
+		clientCase = clientCase.WithFieldOverrides()
+	}
+	
+	log.Printf("[TEMPLATE_ENGINE] Generating document using template: %s v%s for client: %s", templateID, template.Version, clientCase.ClientName)
 	
 	// Apply legal rules to determine applicable causes of action
 	applicableCauses := te.RuleEngine.DetermineCausesOfAction(clientCase)
 	log.Printf("[TEMPLATE_ENGINE] Determined %d applicable causes of action", len(applicableCauses))
 	
+	// Leave out counts barred by the statute of limitations
+	applicableCauses, limitationsIssues := te.applyLimitations(applicableCauses, clientCase.Limitations)
+	
 	// Generate document sections
 	sections := make([]GeneratedSection, 0, len(template.Sections))
 	var fullContent strings.Builder
@@ -147,7 +171,7 @@ func (te *TemplateEngine) GenerateDocument(templateID string, clientCase *Client
 	metadata := DocumentMetadata{
 		GeneratedAt:     time.Now(),
 		TemplateID:      templateID,
-		TemplateVersion: "1.0",
+		TemplateVersion: template.Version,
 		ClientCaseID:    clientCase.ClientName, // Using client name as ID for now
 		WordCount:       len(strings.Fields(fullContent.String())),
 		Completeness:    te.calculateCompleteness(clientCase, template),
@@ -155,13 +179,14 @@ func (te *TemplateEngine) GenerateDocument(templateID string, clientCase *Client
 	
 	// Validate the generated document
 	validationIssues := te.Validator.ValidateDocument(fullContent.String(), clientCase)
+	validationIssues = append(validationIssues, limitationsIssues...)
 	
 	document := &GeneratedDocument{
 		Title:           fmt.Sprintf("FCRA Complaint - %s", clientCase.ClientName),
 		Content:         fullContent.String(),
 		Sections:        sections,
 		Metadata:        metadata,
-		ValidationIssues: validationIssues,
+		ValidationIssues: append(validationIssues, template.Issues...),
 	}
 	
 	log.Printf("[TEMPLATE_ENGINE] Generated document: %d sections, %d words, %.1f%% complete", 
@@ -170,11 +195,50 @@ func (te *TemplateEngine) GenerateDocument(templateID string, clientCase *Client
 	return document, nil
 }
 
+// applyLimitations drops causes of action whose claims are all time-barred and
+// reports both the dropped counts and the counts kept by an attorney override
+func (te *TemplateEngine) applyLimitations(causes []CauseOfAction, limitations *LimitationsAnalysis) ([]CauseOfAction, []ValidationIssue) {
+	if limitations == nil {
+		return causes, nil
+	}
+	
+	var kept []CauseOfAction
+	var issues []ValidationIssue
+	for _, cause := range causes {
+		if limitations.BarsCause(cause.StatutoryBasis) {
+			log.Printf("[TEMPLATE_ENGINE] Omitting time-barred cause of action: %s", cause.Title)
+			issues = append(issues, ValidationIssue{
+				Type:        "time_barred_claim",
+				Section:     "CAUSES OF ACTION",
+				Description: fmt.Sprintf("'%s' (%s) is time-barred under 15 U.S.C. § 1681p and was left out", cause.Title, cause.StatutoryBasis),
+				Severity:    "high",
+				Suggestion:  "If tolling or a later violation applies, an attorney can override the limitations check in Step 3",
+			})
+			continue
+		}
+		kept = append(kept, cause)
+	}
+	
+	for _, claim := range limitations.Claims {
+		if claim.Override != nil {
+			issues = append(issues, ValidationIssue{
+				Type:        "limitations_override",
+				Section:     "CAUSES OF ACTION",
+				Description: fmt.Sprintf("%s is time-barred as of %s but was kept by %s: %s", claim.ViolationName, claim.Deadline.Format("January 2, 2006"), claim.Override.OverriddenBy, claim.Override.Reason),
+				Severity:    "medium",
+				Suggestion:  "Plead the facts supporting tolling or the later violation",
+			})
+		}
+	}
+	
+	return kept, issues
+}
+
 // generateSection creates content for a specific document section
 func (te *TemplateEngine) generateSection(sectionTemplate TemplateSection, clientCase *ClientCase, causes []CauseOfAction) (*GeneratedSection, error) {
 	// Check conditional logic
 	if sectionTemplate.ConditionalLogic != "" {
-		shouldInclude := te.evaluateConditionalLogic(sectionTemplate.ConditionalLogic, clientCase)
+		shouldInclude := te.evaluateConditionalLogic(sectionTemplate, clientCase)
 		if !shouldInclude {
 			log.Printf("[TEMPLATE_ENGINE] Skipping section %s due to conditional logic", sectionTemplate.Name)
 			return nil, nil
@@ -182,17 +246,17 @@ func (te *TemplateEngine) generateSection(sectionTemplate TemplateSection, clien
 	}
 	
 	var content string
-	var sourceFacts []string
+	var sourceFacts []SourceFact
 	confidence := 1.0
 	
 	switch sectionTemplate.Type {
 	case SectionTypeHeader:
 		content = te.generateHeaderSection(clientCase)
-		sourceFacts = []string{"case_info"}
+		sourceFacts = collectSourceFacts(content, clientCase, "courtJurisdiction", "clientName", "defendants", "caseNumber")
 		
 	case SectionTypeParties:
 		content = te.generatePartiesSection(clientCase)
-		sourceFacts = []string{"client_info", "defendant_info"}
+		sourceFacts = collectSourceFacts(content, clientCase, "clientName", "residenceLocation", "defendants")
 		
 	case SectionTypeCausesOfAction:
 		content, sourceFacts = te.generateCausesOfActionSection(causes, clientCase)
@@ -202,16 +266,16 @@ func (te *TemplateEngine) generateSection(sectionTemplate TemplateSection, clien
 		
 	case SectionTypeDamages:
 		content = te.generateDamagesSection(clientCase, causes)
-		sourceFacts = []string{"fraud_amounts", "damages"}
+		sourceFacts = collectSourceFacts(content, clientCase, "fraudAmount", "clientName", "defendants")
 		
 	case SectionTypePrayer:
 		content = te.generatePrayerSection(causes)
-		sourceFacts = []string{"legal_violations"}
+		sourceFacts = collectSourceFacts(content, clientCase, "clientName", "defendants")
 		
 	default:
 		// Use template content with variable substitution
 		content = te.substituteVariables(sectionTemplate.ContentTemplate, clientCase)
-		sourceFacts = []string{"template"}
+		sourceFacts = collectSourceFacts(content, clientCase, "clientName", "courtJurisdiction", "caseNumber", "residenceLocation")
 	}
 	
 	// Calculate confidence based on available data
@@ -269,9 +333,9 @@ func (te *TemplateEngine) generatePartiesSection(clientCase *ClientCase) string
 }
 
 // generateCausesOfActionSection creates the causes of action
-func (te *TemplateEngine) generateCausesOfActionSection(causes []CauseOfAction, clientCase *ClientCase) (string, []string) {
+func (te *TemplateEngine) generateCausesOfActionSection(causes []CauseOfAction, clientCase *ClientCase) (string, []SourceFact) {
 	var content strings.Builder
-	var sourceFacts []string
+	var sourceFacts []SourceFact
 	
 	content.WriteString("CAUSES OF ACTION\n\n")
 	
@@ -284,16 +348,21 @@ func (te *TemplateEngine) generateCausesOfActionSection(causes []CauseOfAction,
 			content.WriteString(fmt.Sprintf("%d. %s\n\n", j+1, element))
 		}
 		
-		sourceFacts = append(sourceFacts, cause.StatutoryBasis)
+		// Causes come from the template's legal rules rather than a client document
+		sourceFacts = append(sourceFacts, SourceFact{
+			Field:    "statutoryBasis",
+			Value:    cause.StatutoryBasis,
+			Sentence: cause.Title,
+		})
 	}
 	
 	return content.String(), sourceFacts
 }
 
 // generateFactsSection creates the factual allegations
-func (te *TemplateEngine) generateFactsSection(clientCase *ClientCase) (string, []string) {
+func (te *TemplateEngine) generateFactsSection(clientCase *ClientCase) (string, []SourceFact) {
 	var content strings.Builder
-	var sourceFacts []string
+	var sourceFacts []SourceFact
 	
 	content.WriteString("FACTUAL ALLEGATIONS\n\n")
 	
@@ -301,10 +370,11 @@ func (te *TemplateEngine) generateFactsSection(clientCase *ClientCase) (string,
 	
 	// Client background
 	if clientCase.ClientName != "" {
-		content.WriteString(fmt.Sprintf("%d. At all times relevant herein, Plaintiff %s was a consumer as defined by the Fair Credit Reporting Act, 15 U.S.C. § 1681 et seq.\n\n",
-			paragraphNum, clientCase.ClientName))
+		sentence := fmt.Sprintf("At all times relevant herein, Plaintiff %s was a consumer as defined by the Fair Credit Reporting Act, 15 U.S.C. § 1681 et seq.",
+			clientCase.ClientName)
+		content.WriteString(fmt.Sprintf("%d. %s\n\n", paragraphNum, sentence))
 		paragraphNum++
-		sourceFacts = append(sourceFacts, "client_name")
+		sourceFacts = append(sourceFacts, newSourceFact(clientCase, "clientName", clientCase.ClientName, sentence))
 	}
 	
 	// Fraud allegations
@@ -314,20 +384,24 @@ func (te *TemplateEngine) generateFactsSection(clientCase *ClientCase) (string,
 		paragraphNum++
 		
 		for _, fraud := range clientCase.FraudDetailsStructured {
-			content.WriteString(fmt.Sprintf("%d. Specifically, Plaintiff discovered fraudulent activity involving %s in the amount of approximately $%s.\n\n",
-				paragraphNum, fraud.Institution, fraud.Amount))
+			sentence := fmt.Sprintf("Specifically, Plaintiff discovered fraudulent activity involving %s in the amount of approximately $%s.",
+				fraud.Institution, fraud.Amount)
+			content.WriteString(fmt.Sprintf("%d. %s\n\n", paragraphNum, sentence))
 			paragraphNum++
-			sourceFacts = append(sourceFacts, fmt.Sprintf("fraud_%s", fraud.Institution))
+			sourceFacts = append(sourceFacts,
+				newSourceFact(clientCase, "financialInstitution", fraud.Institution, sentence),
+				newSourceFact(clientCase, "fraudAmount", fraud.Amount, sentence))
 		}
 	}
 	
 	// Credit bureau interactions
 	if len(clientCase.CreditBureauInteractions) > 0 {
 		for _, interaction := range clientCase.CreditBureauInteractions {
-			content.WriteString(fmt.Sprintf("%d. Plaintiff disputed the fraudulent information with %s on or about %s.\n\n",
-				paragraphNum, interaction.Bureau, interaction.Date))
+			sentence := fmt.Sprintf("Plaintiff disputed the fraudulent information with %s on or about %s.",
+				interaction.Bureau, interaction.Date)
+			content.WriteString(fmt.Sprintf("%d. %s\n\n", paragraphNum, sentence))
 			paragraphNum++
-			sourceFacts = append(sourceFacts, fmt.Sprintf("dispute_%s", interaction.Bureau))
+			sourceFacts = append(sourceFacts, newSourceFact(clientCase, "creditBureauDisputes", interaction.Bureau, sentence))
 		}
 	}
 	
@@ -403,19 +477,25 @@ func (te *TemplateEngine) formatDefendantsList(defendants []Defendant) string {
 	return strings.Join(names[:2], ", ") + ", et al."
 }
 
-func (te *TemplateEngine) evaluateConditionalLogic(logic string, clientCase *ClientCase) bool {
-	// Simple conditional logic evaluation
-	// In production, this would be a more sophisticated expression evaluator
-	switch logic {
-	case "has_fraud_details":
-		return len(clientCase.FraudDetails) > 0
-	case "has_credit_disputes":
-		return len(clientCase.CreditBureauInteractions) > 0
-	case "has_defendants":
-		return len(clientCase.Defendants) > 0
-	default:
+func (te *TemplateEngine) evaluateConditionalLogic(sectionTemplate TemplateSection, clientCase *ClientCase) bool {
+	condition := sectionTemplate.condition
+	if condition == nil {
+		// Sections built in code are not compiled at load time
+		var err error
+		condition, err = ParseCondition(sectionTemplate.ConditionalLogic)
+		if err != nil {
+			log.Printf("[TEMPLATE_ENGINE] Invalid condition for section %s, including it: %v", sectionTemplate.Name, err)
+			return true
+		}
+	}
+	
+	include, err := condition.Evaluate(clientCase)
+	if err != nil {
+		log.Printf("[TEMPLATE_ENGINE] Could not evaluate condition for section %s, including it: %v", sectionTemplate.Name, err)
 		return true
 	}
+	
+	return include
 }
 
 func (te *TemplateEngine) substituteVariables(template string, clientCase *ClientCase) string {
@@ -494,26 +574,3 @@ func (te *TemplateEngine) numberToRoman(num int) string {
 	}
 	return fmt.Sprintf("%d", num)
 }
-
-// loadDefaultTemplates loads the default legal document templates
-func (te *TemplateEngine) loadDefaultTemplates() {
-	// FCRA Credit Card Fraud Complaint Template
-	fcraTemplate := &DocumentTemplate{
-		ID:          "fcra-credit-card-fraud",
-		Name:        "FCRA Credit Card Fraud Complaint",
-		Description: "Template for Fair Credit Reporting Act violations involving credit card fraud",
-		Sections: []TemplateSection{
-			{Name: "Header", Type: SectionTypeHeader, Required: true, Order: 1},
-			{Name: "Parties", Type: SectionTypeParties, Required: true, Order: 2},
-			{Name: "Facts", Type: SectionTypeFacts, Required: true, Order: 3, ConditionalLogic: "has_fraud_details"},
-			{Name: "Causes of Action", Type: SectionTypeCausesOfAction, Required: true, Order: 4},
-			{Name: "Damages", Type: SectionTypeDamages, Required: true, Order: 5},
-			{Name: "Prayer", Type: SectionTypePrayer, Required: true, Order: 6},
-		},
-		RequiredFields: []string{"client_name", "court_jurisdiction", "defendants", "fraud_details"},
-		OptionalFields: []string{"case_number", "attorney_info", "specific_damages"},
-	}
-	
-	te.Templates[fcraTemplate.ID] = fcraTemplate
-	log.Printf("[TEMPLATE_ENGINE] Loaded template: %s", fcraTemplate.Name)
-}
\ No newline at end of file
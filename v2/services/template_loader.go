@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultTemplatesDir    = "./config/complaint_templates"
+	templateReloadInterval = 5 * time.Second
+)
+
+var (
+	templateIDPattern      = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	templateVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+)
+
+// TemplateLoadError describes a template file that could not be loaded
+type TemplateLoadError struct {
+	File    string `json:"file"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error formats the problem as file: field: message
+func (e TemplateLoadError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Field, e.Message)
+}
+
+// LoadTemplates reads every JSON and YAML template in the templates directory.
+// A file that fails to load keeps its previously loaded template, if any.
+func (te *TemplateEngine) LoadTemplates() error {
+	files, modTimes, err := te.scanTemplateFiles()
+	if err != nil {
+		log.Printf("[TEMPLATE_ENGINE] Error reading templates directory %s: %v", te.TemplatesDir, err)
+		te.mu.Lock()
+		te.LoadErrors = []TemplateLoadError{{File: te.TemplatesDir, Message: err.Error()}}
+		te.fileModTimes = modTimes
+		te.mu.Unlock()
+		return err
+	}
+
+	te.mu.RLock()
+	previous := make(map[string]*DocumentTemplate)
+	for _, template := range te.Templates {
+		previous[template.SourceFile] = template
+	}
+	te.mu.RUnlock()
+
+	templates := make(map[string]*DocumentTemplate)
+	var loadErrors []TemplateLoadError
+
+	for _, file := range files {
+		template, problems := parseTemplateFile(file)
+
+		if template != nil {
+			if existing, duplicate := templates[template.ID]; duplicate {
+				problems = append(problems, TemplateLoadError{
+					File:    file,
+					Field:   "id",
+					Message: fmt.Sprintf("template id %q is already defined in %s", template.ID, existing.SourceFile),
+				})
+			}
+		}
+
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				log.Printf("[TEMPLATE_ENGINE] Template error: %s", problem.Error())
+			}
+			loadErrors = append(loadErrors, problems...)
+
+			// Keep serving the last good version until the file is fixed
+			if last, ok := previous[file]; ok {
+				if _, taken := templates[last.ID]; !taken {
+					templates[last.ID] = last
+					log.Printf("[TEMPLATE_ENGINE] Keeping previous version %s of template %s", last.Version, last.ID)
+				}
+			}
+			continue
+		}
+
+		templates[template.ID] = template
+		log.Printf("[TEMPLATE_ENGINE] Loaded template: %s v%s (%s)", template.Name, template.Version, filepath.Base(file))
+	}
+
+	te.mu.Lock()
+	te.Templates = templates
+	te.LoadErrors = loadErrors
+	te.fileModTimes = modTimes
+	te.mu.Unlock()
+
+	if len(loadErrors) > 0 {
+		return fmt.Errorf("%d template errors in %s", len(loadErrors), te.TemplatesDir)
+	}
+	return nil
+}
+
+// GetTemplate returns the template with the given ID
+func (te *TemplateEngine) GetTemplate(templateID string) (*DocumentTemplate, bool) {
+	te.mu.RLock()
+	defer te.mu.RUnlock()
+
+	template, exists := te.Templates[templateID]
+	return template, exists
+}
+
+// ListTemplates returns all loaded templates sorted by name
+func (te *TemplateEngine) ListTemplates() []*DocumentTemplate {
+	te.mu.RLock()
+	defer te.mu.RUnlock()
+
+	templates := make([]*DocumentTemplate, 0, len(te.Templates))
+	for _, template := range te.Templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates
+}
+
+// GetLoadErrors returns the problems found during the last template load
+func (te *TemplateEngine) GetLoadErrors() []TemplateLoadError {
+	te.mu.RLock()
+	defer te.mu.RUnlock()
+
+	return append([]TemplateLoadError(nil), te.LoadErrors...)
+}
+
+// StopWatching stops the template hot-reload loop
+func (te *TemplateEngine) StopWatching() {
+	if te.ReloadTicker != nil {
+		te.ReloadTicker.Stop()
+	}
+}
+
+// watchTemplates reloads templates whenever a file is added, changed or removed
+func (te *TemplateEngine) watchTemplates() {
+	for range te.ReloadTicker.C {
+		_, modTimes, _ := te.scanTemplateFiles()
+
+		te.mu.RLock()
+		changed := len(modTimes) != len(te.fileModTimes)
+		for file, modTime := range modTimes {
+			if last, ok := te.fileModTimes[file]; !ok || !last.Equal(modTime) {
+				changed = true
+				break
+			}
+		}
+		te.mu.RUnlock()
+
+		if changed {
+			log.Printf("[TEMPLATE_ENGINE] Template files changed, reloading from %s", te.TemplatesDir)
+			te.LoadTemplates()
+		}
+	}
+}
+
+// scanTemplateFiles lists template files and their modification times
+func (te *TemplateEngine) scanTemplateFiles() ([]string, map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+
+	entries, err := os.ReadDir(te.TemplatesDir)
+	if err != nil {
+		return nil, modTimes, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(te.TemplatesDir, entry.Name())
+		files = append(files, path)
+		modTimes[path] = info.ModTime()
+	}
+
+	sort.Strings(files)
+	return files, modTimes, nil
+}
+
+// parseTemplateFile decodes a single JSON or YAML template and validates it
+func parseTemplateFile(file string) (*DocumentTemplate, []TemplateLoadError) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, []TemplateLoadError{{File: file, Message: fmt.Sprintf("failed to read file: %v", err)}}
+	}
+
+	template := &DocumentTemplate{}
+
+	// Unknown fields are rejected so a misspelled key is not silently ignored
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(template)
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(template)
+	}
+	if err != nil {
+		return nil, []TemplateLoadError{{File: file, Message: fmt.Sprintf("invalid template syntax: %v", err)}}
+	}
+
+	template.SourceFile = file
+	if problems := validateTemplate(file, template); len(problems) > 0 {
+		return template, problems
+	}
+
+	sort.SliceStable(template.Sections, func(i, j int) bool {
+		return template.Sections[i].Order < template.Sections[j].Order
+	})
+	compileTemplateConditions(template)
+
+	return template, nil
+}
+
+// compileTemplateConditions parses each section condition, recording parse
+// errors as template issues so they show up before any document is generated
+func compileTemplateConditions(template *DocumentTemplate) {
+	template.Issues = nil
+
+	for i := range template.Sections {
+		section := &template.Sections[i]
+		if strings.TrimSpace(section.ConditionalLogic) == "" {
+			continue
+		}
+
+		condition, err := ParseCondition(section.ConditionalLogic)
+		if err != nil {
+			log.Printf("[TEMPLATE_ENGINE] Template %s section %s has an invalid condition: %v", template.ID, section.Name, err)
+			template.Issues = append(template.Issues, ValidationIssue{
+				Type:        "invalid_condition",
+				Section:     section.Name,
+				Description: fmt.Sprintf("Conditional logic %q in template %s v%s could not be parsed: %v", section.ConditionalLogic, template.ID, template.Version, err),
+				Severity:    "high",
+				Suggestion:  "Fix the expression in the template file; the section is always included until then",
+			})
+			continue
+		}
+		section.condition = condition
+	}
+}
+
+// validateTemplate checks a decoded template against the template schema
+func validateTemplate(file string, template *DocumentTemplate) []TemplateLoadError {
+	var problems []TemplateLoadError
+	problem := func(field, format string, args ...interface{}) {
+		problems = append(problems, TemplateLoadError{File: file, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if template.ID == "" {
+		problem("id", "is required")
+	} else if !templateIDPattern.MatchString(template.ID) {
+		problem("id", "%q must be lowercase letters, digits and dashes", template.ID)
+	}
+	if template.Name == "" {
+		problem("name", "is required")
+	}
+	if template.Version == "" {
+		problem("version", "is required")
+	} else if !templateVersionPattern.MatchString(template.Version) {
+		problem("version", "%q must look like 1, 1.2 or 1.2.3", template.Version)
+	}
+	if len(template.Sections) == 0 {
+		problem("sections", "at least one section is required")
+	}
+
+	orders := make(map[int]string)
+	for i, section := range template.Sections {
+		field := fmt.Sprintf("sections[%d]", i)
+		if section.Name == "" {
+			problem(field+".name", "is required")
+		}
+
+		switch section.Type {
+		case SectionTypeHeader, SectionTypeParties, SectionTypeCausesOfAction,
+			SectionTypeFacts, SectionTypeDamages, SectionTypePrayer:
+		case SectionTypeCustom:
+			if strings.TrimSpace(section.ContentTemplate) == "" {
+				problem(field+".contentTemplate", "is required for custom sections")
+			}
+		case "":
+			problem(field+".type", "is required")
+		default:
+			problem(field+".type", "unknown section type %q", section.Type)
+		}
+
+		if other, taken := orders[section.Order]; taken {
+			problem(field+".order", "%d is already used by section %q", section.Order, other)
+		}
+		orders[section.Order] = section.Name
+	}
+
+	for i, rule := range template.LegalRules {
+		field := fmt.Sprintf("legalRules[%d]", i)
+		if rule.ID == "" {
+			problem(field+".id", "is required")
+		}
+		if rule.Statute == "" {
+			problem(field+".statute", "is required")
+		}
+	}
+
+	return problems
+}
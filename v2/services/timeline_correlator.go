@@ -19,6 +19,7 @@ type TimelineCorrelationEngine struct {
 	TemporalValidator       TemporalValidator         `json:"temporalValidator"`
 	TemporalPatterns        []TemporalPattern         `json:"temporalPatterns"`
 	CorrelationRules        []TemporalCorrelationRule `json:"correlationRules"`
+	Deadlines               *DeadlineCalculator       `json:"-"`
 }
 
 // CompositeTimelineBuilder builds comprehensive timelines from multiple documents
@@ -340,6 +341,7 @@ func NewTimelineCorrelationEngine() *TimelineCorrelationEngine {
 		TemporalValidator: TemporalValidator{},
 		TemporalPatterns:  []TemporalPattern{},
 		CorrelationRules:  []TemporalCorrelationRule{},
+		Deadlines:         NewDeadlineCalculator(),
 	}
 	
 	// Load temporal correlation rules
@@ -543,6 +545,78 @@ func (tce *TimelineCorrelationEngine) BuildCompositeTimeline(documents []Documen
 	return timeline
 }
 
+// CaseDeadlines builds the statutory deadlines that follow from a case's
+// credit bureau disputes and the service dates in its summonses. Event IDs
+// name the bureau or defendant rather than the date, so a corrected date
+// keeps the same deadline ID.
+func (tce *TimelineCorrelationEngine) CaseDeadlines(clientCase *ClientCase, summonses []*SummonsDocument) []StatutoryDeadline {
+	caseDocument := DocumentAnalysis{
+		DocumentPath: "case",
+		Timeline:     []TimelineEvent{},
+	}
+	
+	if clientCase != nil {
+		disputes := make(map[string]int)
+		for _, interaction := range clientCase.CreditBureauInteractions {
+			date := parseInteractionDate(interaction.Date)
+			if !strings.Contains(strings.ToLower(interaction.Type), "dispute") || date.IsZero() {
+				continue
+			}
+			key := timelineEventKey(interaction.Bureau)
+			disputes[key]++
+			caseDocument.Timeline = append(caseDocument.Timeline, TimelineEvent{
+				EventID:           fmt.Sprintf("dispute_submitted_%s_%d", key, disputes[key]),
+				EventDate:         date,
+				EventType:         "dispute_submitted",
+				EventDescription:  fmt.Sprintf("Dispute to %s", interaction.Bureau),
+				SourceDocument:    "case",
+				ConfidenceLevel:   1.0,
+				LegalSignificance: LegalSignificanceCritical,
+			})
+		}
+		if len(disputes) == 0 && !clientCase.CreditBureauDisputeDate.IsZero() {
+			caseDocument.Timeline = append(caseDocument.Timeline, TimelineEvent{
+				EventID:           "dispute_submitted_credit_bureaus",
+				EventDate:         clientCase.CreditBureauDisputeDate,
+				EventType:         "dispute_submitted",
+				EventDescription:  "Dispute to the credit bureaus",
+				SourceDocument:    "case",
+				ConfidenceLevel:   1.0,
+				LegalSignificance: LegalSignificanceCritical,
+			})
+		}
+	}
+	
+	for _, summons := range summonses {
+		if summons == nil || !summons.ServiceDetails.ServiceCompleted || summons.ServiceDetails.ServiceDate.IsZero() {
+			continue
+		}
+		caseDocument.Timeline = append(caseDocument.Timeline, TimelineEvent{
+			EventID:           "summons_served_" + timelineEventKey(summons.Defendant.LegalName),
+			EventDate:         summons.ServiceDetails.ServiceDate,
+			EventType:         "summons_served",
+			EventDescription:  fmt.Sprintf("Summons served on %s", summons.Defendant.LegalName),
+			SourceDocument:    summons.DocumentPath,
+			ConfidenceLevel:   1.0,
+			LegalSignificance: LegalSignificanceCritical,
+		})
+	}
+	
+	if len(caseDocument.Timeline) == 0 {
+		return []StatutoryDeadline{}
+	}
+	return tce.BuildCompositeTimeline([]DocumentAnalysis{caseDocument}).StatutoryDeadlines
+}
+
+// timelineEventKey turns a name into a lowercase key for event IDs
+func timelineEventKey(name string) string {
+	key := strings.Trim(caseIDUnsafePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if key == "" {
+		return "unknown"
+	}
+	return key
+}
+
 // extractEventsFromDocuments extracts timeline events from all documents
 func (tce *TimelineCorrelationEngine) extractEventsFromDocuments(documents []DocumentAnalysis) []CorrelatedTimelineEvent {
 	var allEvents []CorrelatedTimelineEvent
@@ -1115,19 +1189,26 @@ func (tce *TimelineCorrelationEngine) calculateStatutoryDeadlines(events []Corre
 	
 	for _, event := range events {
 		if tce.triggersStatutoryDeadline(event) {
-			deadline := tce.calculateDeadlineFromEvent(event)
-			deadlines = append(deadlines, deadline)
+			deadlines = append(deadlines, tce.calculateDeadlinesFromEvent(event)...)
 		}
 	}
 	
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		return deadlines[i].DeadlineDate.Before(deadlines[j].DeadlineDate)
+	})
 	return deadlines
 }
 
+// deadlineTriggerTypes are the event types that start a statutory period
+var deadlineTriggerTypes = []string{"dispute_submitted", "case_filed", "summons_served", "waiver_requested", "violation_notice", "fcra_violation"}
+
 // triggersStatutoryDeadline checks if event triggers a statutory deadline
 func (tce *TimelineCorrelationEngine) triggersStatutoryDeadline(event CorrelatedTimelineEvent) bool {
-	triggerTypes := []string{"dispute_submitted", "case_filed", "violation_notice"}
+	if event.EventDate.IsZero() {
+		return false
+	}
 	
-	for _, triggerType := range triggerTypes {
+	for _, triggerType := range deadlineTriggerTypes {
 		if strings.Contains(strings.ToLower(event.EventType), triggerType) {
 			return true
 		}
@@ -1136,24 +1217,36 @@ func (tce *TimelineCorrelationEngine) triggersStatutoryDeadline(event Correlated
 	return false
 }
 
-// calculateDeadlineFromEvent calculates deadline based on event
-func (tce *TimelineCorrelationEngine) calculateDeadlineFromEvent(event CorrelatedTimelineEvent) StatutoryDeadline {
-	// Default 30-day deadline - would be customized based on actual legal requirements
-	deadlineDate := event.EventDate.AddDate(0, 0, 30)
-	
-	deadline := StatutoryDeadline{
-		DeadlineID:          fmt.Sprintf("deadline_%s", event.EventID),
-		DeadlineDate:        deadlineDate,
-		DeadlineType:        "response_deadline",
-		DeadlineDescription: fmt.Sprintf("Response deadline for %s", event.EventDescription),
-		StatutoryBasis:      "Federal regulations",
-		ComplianceRequired:  true,
-		PenaltyForMissing:   "Loss of rights",
-		ComplianceStatus:    CompliancePending,
-		DaysRemaining:       int(time.Until(deadlineDate).Hours() / 24),
+// calculateDeadlinesFromEvent calculates the deadlines an event starts, each
+// citing the rule or statute that sets it
+func (tce *TimelineCorrelationEngine) calculateDeadlinesFromEvent(event CorrelatedTimelineEvent) []StatutoryDeadline {
+	if tce.Deadlines == nil {
+		tce.Deadlines = NewDeadlineCalculator()
 	}
 	
-	return deadline
+	eventType := strings.ToLower(event.EventType)
+	var deadlines []StatutoryDeadline
+	switch {
+	case strings.Contains(eventType, "dispute_submitted"):
+		deadlines = append(deadlines, tce.Deadlines.ReinvestigationDeadline(event.EventDate))
+	case strings.Contains(eventType, "case_filed"):
+		deadlines = append(deadlines, tce.Deadlines.ServiceDeadline(event.EventDate))
+	case strings.Contains(eventType, "summons_served"):
+		deadlines = append(deadlines, tce.Deadlines.AnswerDeadline(event.EventDate))
+	case strings.Contains(eventType, "waiver_requested"):
+		deadlines = append(deadlines, tce.Deadlines.WaiverAnswerDeadline(event.EventDate, false))
+	case strings.Contains(eventType, "violation_notice"):
+		// Notice of the violation is when the plaintiff discovered it
+		deadlines = append(deadlines, tce.Deadlines.FCRALimitations(event.EventDate, time.Time{})...)
+	case strings.Contains(eventType, "fcra_violation"):
+		deadlines = append(deadlines, tce.Deadlines.FCRALimitations(time.Time{}, event.EventDate)...)
+	}
+	
+	for i := range deadlines {
+		deadlines[i].DeadlineID = fmt.Sprintf("deadline_%s_%s", event.EventID, deadlines[i].DeadlineType)
+		deadlines[i].DeadlineDescription = fmt.Sprintf("%s (%s)", deadlines[i].DeadlineDescription, event.EventDescription)
+	}
+	return deadlines
 }
 
 // detectTimelineGaps detects gaps in the timeline
@@ -2,35 +2,77 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
+// passwordHashCost is the bcrypt work factor for stored passwords
+const passwordHashCost = 12
+
+// dummyPasswordHash is compared against when a username is unknown so failed
+// logins take the same time whether or not the account exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), passwordHashCost)
+
 // User represents a user in the system
 type User struct {
-	ID        string     `json:"id"`
-	Username  string     `json:"username"`
-	Password  string     `json:"password"`
-	Email     string     `json:"email"`
-	Role      string     `json:"role"`
-	Active    bool       `json:"active"`
-	Created   time.Time  `json:"created"`
-	LastLogin *time.Time `json:"lastLogin"`
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	// Password only holds a legacy plaintext password until LoadUsers migrates it
+	Password     string     `json:"password,omitempty"`
+	PasswordHash string     `json:"passwordHash,omitempty"`
+	DisplayName  string     `json:"displayName,omitempty"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	Active       bool       `json:"active"`
+	Created      time.Time  `json:"created"`
+	LastLogin    *time.Time `json:"lastLogin"`
+	// AssignedCases scopes associates and paralegals to these case folders or case IDs
+	AssignedCases []string `json:"assignedCases,omitempty"`
+}
+
+// Name returns the name shown in the UI
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Username
 }
 
+// Errors returned by UserService for lookups and conflicting usernames
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+)
+
 // UserData represents the structure of the users.json file
 type UserData struct {
 	Users []User `json:"users"`
 }
 
-// UserService manages user data from JSON file
+// UserService manages user data from JSON file. All access to the users map
+// goes through mu, and callers only ever receive copies of stored users.
 type UserService struct {
 	filePath string
+	mu       sync.RWMutex
 	users    map[string]*User // username -> User mapping
 }
 
+// usernamePattern limits usernames to characters that are safe in URLs and logs
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{2,64}$`)
+
+// minPasswordLength applies to passwords set through the admin API
+const minPasswordLength = 8
+
 // NewUserService creates a new UserService instance
 func NewUserService(configPath string) (*UserService, error) {
 	service := &UserService{
@@ -59,24 +101,81 @@ func (s *UserService) LoadUsers() error {
 		return fmt.Errorf("failed to parse users JSON: %v", err)
 	}
 	
-	// Build username index
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	// Build username index, hashing any plaintext passwords left from older users.json files
 	s.users = make(map[string]*User)
+	migrated := 0
 	for i := range userData.Users {
 		user := &userData.Users[i]
+		if user.Password != "" {
+			if err := migratePassword(user); err != nil {
+				return fmt.Errorf("failed to migrate password for %s: %v", user.Username, err)
+			}
+			migrated++
+		}
 		s.users[user.Username] = user
 	}
 	
 	fmt.Printf("[UserService] Loaded %d users from %s\n", len(s.users), s.filePath)
+	
+	if migrated > 0 {
+		fmt.Printf("[UserService] Migrated %d plaintext passwords to bcrypt hashes\n", migrated)
+		if err := s.saveUsersLocked(); err != nil {
+			return fmt.Errorf("failed to save migrated users: %v", err)
+		}
+	}
+	return nil
+}
+
+// migratePassword moves a legacy password into PasswordHash, hashing it unless
+// it is already a bcrypt hash
+func migratePassword(user *User) error {
+	if user.PasswordHash == "" {
+		if strings.HasPrefix(user.Password, "$2a$") || strings.HasPrefix(user.Password, "$2b$") {
+			user.PasswordHash = user.Password
+		} else {
+			hash, err := HashPassword(user.Password)
+			if err != nil {
+				return err
+			}
+			user.PasswordHash = hash
+		}
+	}
+	user.Password = ""
 	return nil
 }
 
+// HashPassword returns the bcrypt hash stored for a password
+func HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("password cannot be empty")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
 // SaveUsers saves user data to the JSON file
 func (s *UserService) SaveUsers() error {
-	// Convert map back to slice
-	var users []User
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveUsersLocked()
+}
+
+// saveUsersLocked writes users.json through a temporary file and rename so a
+// crash mid-write never leaves a truncated file. The caller must hold mu.
+func (s *UserService) saveUsersLocked() error {
+	users := make([]User, 0, len(s.users))
 	for _, user := range s.users {
 		users = append(users, *user)
 	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
 	
 	userData := UserData{Users: users}
 	
@@ -85,10 +184,29 @@ func (s *UserService) SaveUsers() error {
 		return fmt.Errorf("failed to marshal users JSON: %v", err)
 	}
 	
-	err = os.WriteFile(s.filePath, data, 0644)
+	tempFile, err := os.CreateTemp(filepath.Dir(s.filePath), ".users-*.json")
 	if err != nil {
 		return fmt.Errorf("failed to write users file: %v", err)
 	}
+	defer os.Remove(tempFile.Name())
+	
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := os.Chmod(tempFile.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
+	if err := os.Rename(tempFile.Name(), s.filePath); err != nil {
+		return fmt.Errorf("failed to write users file: %v", err)
+	}
 	
 	fmt.Printf("[UserService] Saved %d users to %s\n", len(s.users), s.filePath)
 	return nil
@@ -96,16 +214,29 @@ func (s *UserService) SaveUsers() error {
 
 // ValidateUser validates username and password
 func (s *UserService) ValidateUser(username, password string) (*User, bool) {
+	// The bcrypt comparison is slow, so it runs without holding the lock
+	s.mu.RLock()
 	user, exists := s.users[username]
+	passwordHash := ""
+	if exists {
+		passwordHash = user.PasswordHash
+	}
+	s.mu.RUnlock()
+	
 	if !exists {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
 		return nil, false
 	}
 	
-	if !user.Active {
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil {
 		return nil, false
 	}
 	
-	if user.Password != password {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	user, exists = s.users[username]
+	if !exists || !user.Active {
 		return nil, false
 	}
 	
@@ -114,59 +245,170 @@ func (s *UserService) ValidateUser(username, password string) (*User, bool) {
 	user.LastLogin = &now
 	
 	// Save updated user data
-	s.SaveUsers()
+	if err := s.saveUsersLocked(); err != nil {
+		fmt.Printf("[UserService] Warning: could not record login for %s: %v\n", username, err)
+	}
 	
-	return user, true
+	loggedIn := *user
+	return &loggedIn, true
 }
 
-// GetUser returns a user by username
+// GetUser returns a copy of a user by username
 func (s *UserService) GetUser(username string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
 	user, exists := s.users[username]
-	return user, exists
+	if !exists {
+		return nil, false
+	}
+	found := *user
+	return &found, true
 }
 
-// GetAllUsers returns all users
+// GetAllUsers returns copies of all users ordered by ID
 func (s *UserService) GetAllUsers() []*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
 	var users []*User
 	for _, user := range s.users {
-		users = append(users, user)
+		found := *user
+		users = append(users, &found)
 	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
 	return users
 }
 
-// AddUser adds a new user
+// AddUser adds a new user. A plaintext Password on the user is hashed before saving.
 func (s *UserService) AddUser(user *User) error {
+	if !usernamePattern.MatchString(user.Username) {
+		return fmt.Errorf("invalid username: %q", user.Username)
+	}
+	if !ValidRole(user.Role) {
+		return fmt.Errorf("unknown role: %s", user.Role)
+	}
+	if user.Password != "" {
+		if len(user.Password) < minPasswordLength {
+			return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+		}
+		if err := migratePassword(user); err != nil {
+			return err
+		}
+	}
+	if user.PasswordHash == "" {
+		return fmt.Errorf("password is required")
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
 	if _, exists := s.users[user.Username]; exists {
-		return fmt.Errorf("user already exists: %s", user.Username)
+		return fmt.Errorf("%s: %w", user.Username, ErrUserExists)
 	}
 	
-	user.Created = time.Now()
-	s.users[user.Username] = user
+	stored := *user
+	stored.ID = s.nextIDLocked()
+	stored.Created = time.Now()
+	stored.LastLogin = nil
+	s.users[stored.Username] = &stored
 	
-	return s.SaveUsers()
+	if err := s.saveUsersLocked(); err != nil {
+		delete(s.users, stored.Username)
+		return err
+	}
+	*user = stored
+	return nil
 }
 
 // UpdateUser updates an existing user
 func (s *UserService) UpdateUser(user *User) error {
-	if _, exists := s.users[user.Username]; !exists {
-		return fmt.Errorf("user not found: %s", user.Username)
+	return s.ModifyUser(user.Username, func(stored *User) error {
+		*stored = *user
+		return nil
+	})
+}
+
+// ModifyUser applies a change to a stored user and saves it, leaving the user
+// untouched if the change or the save fails
+func (s *UserService) ModifyUser(username string, modify func(*User) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	stored, exists := s.users[username]
+	if !exists {
+		return fmt.Errorf("%s: %w", username, ErrUserNotFound)
+	}
+	
+	updated := *stored
+	updated.AssignedCases = append([]string(nil), stored.AssignedCases...)
+	if err := modify(&updated); err != nil {
+		return err
+	}
+	if updated.Username != username {
+		return fmt.Errorf("username cannot be changed")
+	}
+	if !ValidRole(updated.Role) {
+		return fmt.Errorf("unknown role: %s", updated.Role)
 	}
 	
-	s.users[user.Username] = user
-	return s.SaveUsers()
+	s.users[username] = &updated
+	if err := s.saveUsersLocked(); err != nil {
+		s.users[username] = stored
+		return err
+	}
+	return nil
 }
 
 // DeleteUser removes a user
 func (s *UserService) DeleteUser(username string) error {
-	if _, exists := s.users[username]; !exists {
-		return fmt.Errorf("user not found: %s", username)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	stored, exists := s.users[username]
+	if !exists {
+		return fmt.Errorf("%s: %w", username, ErrUserNotFound)
 	}
 	
 	delete(s.users, username)
-	return s.SaveUsers()
+	if err := s.saveUsersLocked(); err != nil {
+		s.users[username] = stored
+		return err
+	}
+	return nil
+}
+
+// SetPassword replaces a user's password with a new bcrypt hash
+func (s *UserService) SetPassword(username, password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	
+	return s.ModifyUser(username, func(user *User) error {
+		user.PasswordHash = hash
+		user.Password = ""
+		return nil
+	})
 }
 
 // GetUserRoles returns available user roles
 func (s *UserService) GetUserRoles() []string {
-	return []string{"admin", "lawyer", "user"}
-}
\ No newline at end of file
+	return []string{RoleAdmin, RoleAttorney, RoleAssociate, RoleParalegal}
+}
+
+// nextIDLocked returns the next numeric user ID. The caller must hold mu.
+func (s *UserService) nextIDLocked() string {
+	highest := 0
+	for _, user := range s.users {
+		if id, err := strconv.Atoi(user.ID); err == nil && id > highest {
+			highest = id
+		}
+	}
+	return strconv.Itoa(highest + 1)
+}
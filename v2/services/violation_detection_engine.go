@@ -16,6 +16,7 @@ type ViolationDetectionEngine struct {
 	DocumentAnalyzers     map[string]ViolationDocumentAnalyzer
 	EvidenceCorrelator    EvidenceCorrelationEngine
 	StrengthCalculator    ViolationStrengthCalculator
+	Deadlines             *DeadlineCalculator
 }
 
 // ComprehensiveLegalViolationDatabase represents the complete violation database
@@ -114,6 +115,12 @@ type DetectedViolation struct {
 	DocumentSources         []string                    `json:"documentSources"`
 	LegalTheorySupport      LegalTheorySupport          `json:"legalTheorySupport"`
 	ConfidenceScore         float64                     `json:"confidenceScore"`
+	
+	// When the violation occurred, for the statute of limitations, and the
+	// ClientCase field the date came from
+	ViolationDate           time.Time                   `json:"violationDate"`
+	ViolationEvent          string                      `json:"violationEvent"`
+	ViolationDateSource     string                      `json:"violationDateSource"`
 }
 
 // ViolationEvidenceItem represents a piece of evidence supporting a violation
@@ -187,6 +194,7 @@ func NewViolationDetectionEngine() (*ViolationDetectionEngine, error) {
 		DocumentAnalyzers:  make(map[string]ViolationDocumentAnalyzer),
 		EvidenceCorrelator: EvidenceCorrelationEngine{},
 		StrengthCalculator: ViolationStrengthCalculator{},
+		Deadlines:          NewDeadlineCalculator(),
 	}
 
 	// Load violation database from configuration
@@ -298,6 +306,7 @@ func (vde *ViolationDetectionEngine) analyzeViolation(
 		LegalTheorySupport:  legalTheorySupport,
 		ConfidenceScore:     confidenceScore,
 	}
+	detection.ViolationDate, detection.ViolationDateSource, detection.ViolationEvent = vde.violationDate(violationDef, clientCase)
 
 	log.Printf("[INFO] Detected violation: %s (confidence: %.2f, strength: %s)", 
 		violationDef.ViolationName, confidenceScore, strengthAssessment.StrengthCategory)
@@ -305,6 +314,20 @@ func (vde *ViolationDetectionEngine) analyzeViolation(
 	return detection
 }
 
+// violationDate determines when a violation occurred from the case dates. A
+// reinvestigation violation occurs when the § 1681i(a)(1)(A) period after the
+// first dispute runs out; reporting violations occur when the inaccurate
+// account first appears.
+func (vde *ViolationDetectionEngine) violationDate(violationDef FCRAViolationDefinition, clientCase *ClientCase) (time.Time, string, string) {
+	if strings.HasPrefix(violationDef.ViolationID, "FCRA-1681i") {
+		if disputed, source := earliestDisputeDate(clientCase); !disputed.IsZero() {
+			deadline := vde.Deadlines.ReinvestigationDeadline(disputed)
+			return deadline.DeadlineDate, source, "Reinvestigation period after the first dispute ended"
+		}
+	}
+	return reportingDate(clientCase)
+}
+
 // hasRelevantDocuments checks if selected documents are relevant for a violation
 func (vde *ViolationDetectionEngine) hasRelevantDocuments(requiredDocs []string, selectedDocs []string) bool {
 	for _, required := range requiredDocs {
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WebDAVDocumentSource reads case folders from a WebDAV collection
+// (Nextcloud, ownCloud, Apache mod_dav and similar servers)
+type WebDAVDocumentSource struct {
+	endpoint *url.URL
+	username string
+	password string
+	cacheDir string
+	client   *http.Client
+}
+
+// webdavMultistatus is the PROPFIND response body
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength int64  `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// NewWebDAVDocumentSource creates a WebDAV source rooted at config.URL
+func NewWebDAVDocumentSource(config WebDAVSourceConfig, cacheDir string) (*WebDAVDocumentSource, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webdav provider is not configured")
+	}
+	if config.Username == "" || config.Password == "" {
+		return nil, fmt.Errorf("webdav username and app password are required")
+	}
+
+	endpoint, err := url.Parse(config.URL)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid webdav url %q", config.URL)
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/")
+	endpoint.RawPath = ""
+
+	return &WebDAVDocumentSource{
+		endpoint: endpoint,
+		username: config.Username,
+		password: config.Password,
+		cacheDir: cacheDir,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// List returns the visible files and folders directly inside a folder
+func (s *WebDAVDocumentSource) List(folder string) ([]StoredObject, error) {
+	folder = cleanStorePath(folder)
+
+	objects, err := s.propfind(folder, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	var children []StoredObject
+	for _, object := range objects {
+		// The folder itself is part of a Depth: 1 response
+		if object.Path == folder || strings.HasPrefix(object.Name, ".") {
+			continue
+		}
+		children = append(children, object)
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children, nil
+}
+
+// Stat describes a single file or folder
+func (s *WebDAVDocumentSource) Stat(objectPath string) (*StoredObject, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	objects, err := s.propfind(objectPath, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+	}
+	object := objects[0]
+	object.Name = path.Base(objectPath)
+	object.Path = objectPath
+	return &object, nil
+}
+
+// Read downloads a file
+func (s *WebDAVDocumentSource) Read(objectPath string) ([]byte, error) {
+	objectPath = cleanStorePath(objectPath)
+
+	response, err := s.do("GET", objectPath, "", "")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if err := s.checkStatus(response, objectPath); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", objectPath, err)
+	}
+	return data, nil
+}
+
+// LocalPath downloads the file into the cache directory
+func (s *WebDAVDocumentSource) LocalPath(objectPath string) (string, error) {
+	object, err := s.Stat(objectPath)
+	if err != nil {
+		return "", err
+	}
+	return cacheSourceObject(s.cacheDir, object, s.Read)
+}
+
+// propfind lists a resource and, with depth "1", its children
+func (s *WebDAVDocumentSource) propfind(objectPath, depth string) ([]StoredObject, error) {
+	response, err := s.do("PROPFIND", objectPath, depth, webdavPropfindBody)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if err := s.checkStatus(response, objectPath); err != nil {
+		return nil, err
+	}
+
+	var result webdavMultistatus
+	if err := xml.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid webdav response for %s: %v", objectPath, err)
+	}
+
+	var objects []StoredObject
+	for _, entry := range result.Responses {
+		href, err := url.Parse(entry.Href)
+		if err != nil {
+			continue
+		}
+		relative, ok := s.relativePath(href.Path)
+		if !ok {
+			continue
+		}
+
+		object := StoredObject{
+			Name: path.Base(relative),
+			Path: relative,
+		}
+		for _, propstat := range entry.Propstat {
+			if !strings.Contains(propstat.Status, " 200") {
+				continue
+			}
+			object.IsDirectory = propstat.Prop.ResourceType.Collection != nil
+			if !object.IsDirectory {
+				object.Size = propstat.Prop.ContentLength
+			}
+			if modified, err := http.ParseTime(propstat.Prop.LastModified); err == nil {
+				object.Modified = modified
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// relativePath maps a decoded href back to a path under the source root
+func (s *WebDAVDocumentSource) relativePath(hrefPath string) (string, bool) {
+	root := s.endpoint.Path
+	if hrefPath != root && !strings.HasPrefix(hrefPath, root+"/") {
+		return "", false
+	}
+	return cleanStorePath(strings.TrimPrefix(hrefPath, root)), true
+}
+
+func (s *WebDAVDocumentSource) do(method, objectPath, depth, body string) (*http.Response, error) {
+	requestURL := *s.endpoint
+	requestURL.Path = s.endpoint.Path + "/" + objectPath
+	if method == "PROPFIND" && depth == "1" && objectPath != "" {
+		requestURL.Path += "/"
+	}
+
+	request, err := http.NewRequest(method, requestURL.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(s.username, s.password)
+	if depth != "" {
+		request.Header.Set("Depth", depth)
+	}
+	if body != "" {
+		request.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("webdav %s %s failed: %v", method, objectPath, err)
+	}
+	return response, nil
+}
+
+func (s *WebDAVDocumentSource) checkStatus(response *http.Response, objectPath string) error {
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", objectPath, ErrCaseObjectNotFound)
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("webdav server rejected the credentials for %s", s.username)
+	case response.StatusCode >= 300:
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("webdav returned %d for %s: %s", response.StatusCode, objectPath, strings.TrimSpace(string(message)))
+	}
+	return nil
+}